@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg/closures"
+	"github.com/markhaur/messapp-backend/pkg/payroll"
+	"github.com/markhaur/messapp-backend/pkg/wallets"
+)
+
+// runCommand runs a one-off subcommand instead of starting the server.
+func runCommand(ctx context.Context, args []string, closureService closures.Service, payrollService payroll.Service, walletService wallets.Service, out io.Writer) error {
+	switch args[0] {
+	case "import-holidays":
+		return importHolidays(ctx, args[1:], closureService, out)
+	case "export-payroll":
+		return exportPayroll(ctx, args[1:], payrollService, out)
+	case "settle-wallets":
+		return settleWallets(ctx, walletService, out)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func importHolidays(ctx context.Context, args []string, service closures.Service, out io.Writer) error {
+	flags := flag.NewFlagSet("import-holidays", flag.ContinueOnError)
+	file := flags.String("file", "", "iCalendar file to import")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without saving")
+	from := flags.String("from", "", "first day to import, YYYY-MM-DD (default today)")
+	to := flags.String("to", "", "last day to import, YYYY-MM-DD (default end of next year)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("import-holidays: -file is required")
+	}
+
+	start, end, err := closures.ImportWindow(*from, *to, time.Now())
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	results, err := service.Import(ctx, f, start, end, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tSTART\tEND\tSUMMARY\tNOTE")
+	for _, r := range results {
+		end := ""
+		if !r.EndDate.IsZero() {
+			end = r.EndDate.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Status, r.StartDate.Format("2006-01-02"), end, r.Summary, r.Note)
+	}
+	return w.Flush()
+}
+
+func exportPayroll(ctx context.Context, args []string, service payroll.Service, out io.Writer) error {
+	flags := flag.NewFlagSet("export-payroll", flag.ContinueOnError)
+	month := flags.String("month", "", "month to export, YYYY-MM")
+	format := flags.String("format", "csv", "export format, csv or fixed")
+	file := flags.String("out", "", "file to write the export to (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" {
+		return fmt.Errorf("export-payroll: -month is required")
+	}
+
+	m, err := time.Parse("2006-01", *month)
+	if err != nil {
+		return fmt.Errorf("export-payroll: invalid -month: %v", err)
+	}
+	f, err := payroll.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := service.Export(ctx, &body, m, f); err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err := body.WriteTo(out)
+		return err
+	}
+	return os.WriteFile(*file, body.Bytes(), 0o644)
+}
+
+// settleWallets is meant to run daily, capturing the holds of meals from
+// earlier days whether or not they were eaten.
+func settleWallets(ctx context.Context, service wallets.Service, out io.Writer) error {
+	settled, err := service.SettleHolds(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESERVATION\tUSER\tAMOUNT\tSTATUS")
+	for _, hold := range settled {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\n", hold.ReservationID, hold.UserID, hold.Amount, hold.Status)
+	}
+	return w.Flush()
+}
@@ -5,16 +5,35 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
+	// site time zones are loaded by name, which must work on hosts without
+	// a zoneinfo database.
+	_ "time/tzdata"
 
 	"github.com/go-kit/log"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/allergens"
+	"github.com/markhaur/messapp-backend/pkg/billing"
+	"github.com/markhaur/messapp-backend/pkg/closures"
+	"github.com/markhaur/messapp-backend/pkg/idempotency"
+	"github.com/markhaur/messapp-backend/pkg/mealtypes"
+	"github.com/markhaur/messapp-backend/pkg/menus"
 	"github.com/markhaur/messapp-backend/pkg/mysql"
+	"github.com/markhaur/messapp-backend/pkg/notify"
+	"github.com/markhaur/messapp-backend/pkg/outbox"
+	"github.com/markhaur/messapp-backend/pkg/payroll"
+	"github.com/markhaur/messapp-backend/pkg/ratings"
+	"github.com/markhaur/messapp-backend/pkg/reminders"
+	"github.com/markhaur/messapp-backend/pkg/reports"
 	"github.com/markhaur/messapp-backend/pkg/reservations"
+	"github.com/markhaur/messapp-backend/pkg/sites"
 	"github.com/markhaur/messapp-backend/pkg/userlist"
+	"github.com/markhaur/messapp-backend/pkg/wallets"
+	"github.com/markhaur/messapp-backend/pkg/webhooks"
 )
 
 func main() {
@@ -37,6 +56,25 @@ func main() {
 		DBSource                   string        `envconfig:"DB_SOURCE"`
 		DBConnectTimeout           time.Duration `envconfig:"DB_CONNECT_TIMEOUT"`
 		OTELExporterJaegerEndpoint string        `envconfig:"OTEL_EXPORTER_JAEGER_ENDPOINT"`
+		NotifyFile                 string        `envconfig:"NOTIFY_FILE"`
+		AdminUserIDs               []int64       `envconfig:"ADMIN_USER_IDS"`
+		PayrollLayout              string        `envconfig:"PAYROLL_LAYOUT"`
+		WebhookDispatchInterval    time.Duration `envconfig:"WEBHOOK_DISPATCH_INTERVAL" default:"10s"`
+		WebhookTimeout             time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+		OutboxSinks                []string      `envconfig:"OUTBOX_SINKS" default:"webhooks"`
+		OutboxFile                 string        `envconfig:"OUTBOX_FILE"`
+		OutboxRelayInterval        time.Duration `envconfig:"OUTBOX_RELAY_INTERVAL" default:"1s"`
+		OutboxCleanupInterval      time.Duration `envconfig:"OUTBOX_CLEANUP_INTERVAL" default:"1h"`
+		OutboxRetention            time.Duration `envconfig:"OUTBOX_RETENTION" default:"168h"`
+		SMTPAddress                string        `envconfig:"SMTP_ADDRESS"`
+		SMTPUsername               string        `envconfig:"SMTP_USERNAME"`
+		SMTPPassword               string        `envconfig:"SMTP_PASSWORD"`
+		SMTPFrom                   string        `envconfig:"SMTP_FROM" default:"messapp@localhost"`
+		ReminderLead               time.Duration `envconfig:"REMINDER_LEAD" default:"1h"`
+		NudgeLead                  time.Duration `envconfig:"NUDGE_LEAD" default:"3h"`
+		ReminderInterval           time.Duration `envconfig:"REMINDER_INTERVAL" default:"1m"`
+		IdempotencyTTL             time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+		IdempotencyCleanupInterval time.Duration `envconfig:"IDEMPOTENCY_CLEANUP_INTERVAL" default:"1h"`
 	}
 	if err := envconfig.Process("MESSAPP", &config); err != nil {
 		logger.Log("msg", "could not load env vars", "err", err)
@@ -45,6 +83,24 @@ func main() {
 
 	var userRepository pkg.UserRepository
 	var reservationRepository pkg.ReservationRepository
+	var mealTypeRepository pkg.MealTypeRepository
+	var closureRepository pkg.ClosureRepository
+	var allergenRepository pkg.AllergenRepository
+	var dishRepository pkg.DishRepository
+	var menuRepository pkg.MenuRepository
+	var ratingRepository pkg.RatingRepository
+	var priceRepository pkg.PriceRepository
+	var subsidyRuleRepository pkg.SubsidyRuleRepository
+	var statementRepository pkg.StatementRepository
+	var walletRepository pkg.WalletRepository
+	var reportRepository pkg.ReportRepository
+	var calendarFeedRepository pkg.CalendarFeedRepository
+	var webhookRepository pkg.WebhookRepository
+	var outboxRepository pkg.OutboxRepository
+	var notificationPreferenceRepository pkg.NotificationPreferenceRepository
+	var sentReminderRepository pkg.SentReminderRepository
+	var siteRepository pkg.SiteRepository
+	var idempotencyRepository pkg.IdempotencyRepository
 
 	if config.DBSource != "" {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DBConnectTimeout)
@@ -62,6 +118,24 @@ func main() {
 
 		userRepository = mysql.NewUserRepository(db)
 		reservationRepository = mysql.NewReservationRepository(db)
+		mealTypeRepository = mysql.NewMealTypeRepository(db)
+		closureRepository = mysql.NewClosureRepository(db)
+		allergenRepository = mysql.NewAllergenRepository(db)
+		dishRepository = mysql.NewDishRepository(db)
+		menuRepository = mysql.NewMenuRepository(db)
+		ratingRepository = mysql.NewRatingRepository(db)
+		priceRepository = mysql.NewPriceRepository(db)
+		subsidyRuleRepository = mysql.NewSubsidyRuleRepository(db)
+		statementRepository = mysql.NewStatementRepository(db)
+		walletRepository = mysql.NewWalletRepository(db)
+		reportRepository = mysql.NewReportRepository(db)
+		calendarFeedRepository = mysql.NewCalendarFeedRepository(db)
+		webhookRepository = mysql.NewWebhookRepository(db)
+		outboxRepository = mysql.NewOutboxRepository(db)
+		notificationPreferenceRepository = mysql.NewNotificationPreferenceRepository(db)
+		sentReminderRepository = mysql.NewSentReminderRepository(db)
+		siteRepository = mysql.NewSiteRepository(db)
+		idempotencyRepository = mysql.NewIdempotencyRepository(db)
 
 		defer func() {
 			if err := db.Close(); err != nil {
@@ -70,17 +144,139 @@ func main() {
 		}()
 	}
 
+	var notifier pkg.Notifier
+	notifier = notify.NewLogNotifier(logger)
+	if config.NotifyFile != "" {
+		notifier = notify.NewFileNotifier(config.NotifyFile)
+	}
+	if config.SMTPAddress != "" {
+		smtp := notify.SMTPConfig{Address: config.SMTPAddress, Username: config.SMTPUsername, Password: config.SMTPPassword, From: config.SMTPFrom}
+		notifier = notify.NewEmailNotifier(smtp, notificationPreferenceRepository, notifier)
+	}
+	notifier = notify.LoggingMiddleware(logger)(notifier)
+
+	var webhookService webhooks.Service
+	webhookService = webhooks.NewService(webhookRepository, &http.Client{Timeout: config.WebhookTimeout})
+	webhookService = webhooks.ValidationMiddleware()(webhookService)
+	webhookService = webhooks.LoggingMiddleware(logger)(webhookService)
+
 	var userService userlist.Service
 	userService = userlist.NewService(userRepository)
+	userService = userlist.ValidationMiddleware(allergenRepository, siteRepository)(userService)
 	userService = userlist.LoggingMiddleware(logger)(userService)
 
+	var walletService wallets.Service
+	walletService = wallets.NewService(walletRepository, reservationRepository, priceRepository, subsidyRuleRepository, userRepository)
+	walletService = wallets.ValidationMiddleware()(walletService)
+	walletService = wallets.LoggingMiddleware(logger)(walletService)
+
 	var reservationService reservations.Service
-	reservationService = reservations.NewService(reservationRepository)
+	reservationService = reservations.NewService(reservationRepository, userRepository, mealTypeRepository, closureRepository, menuRepository, walletService, calendarFeedRepository, siteRepository, logger)
+	reservationService = reservations.ValidationMiddleware(userRepository, mealTypeRepository, allergenRepository, siteRepository)(reservationService)
 	reservationService = reservations.LoggingMiddleware(logger)(reservationService)
 
+	var siteService sites.Service
+	siteService = sites.NewService(siteRepository)
+	siteService = sites.ValidationMiddleware()(siteService)
+	siteService = sites.LoggingMiddleware(logger)(siteService)
+
+	var mealTypeService mealtypes.Service
+	mealTypeService = mealtypes.NewService(mealTypeRepository)
+	mealTypeService = mealtypes.LoggingMiddleware(logger)(mealTypeService)
+
+	var allergenService allergens.Service
+	allergenService = allergens.NewService(allergenRepository)
+	allergenService = allergens.LoggingMiddleware(logger)(allergenService)
+
+	var menuService menus.Service
+	menuService = menus.NewService(dishRepository, menuRepository, reservationRepository, userRepository, notifier)
+	menuService = menus.ValidationMiddleware(mealTypeRepository, dishRepository, allergenRepository)(menuService)
+	menuService = menus.LoggingMiddleware(logger)(menuService)
+
+	var ratingService ratings.Service
+	ratingService = ratings.NewService(ratingRepository, reservationRepository, menuRepository)
+	ratingService = ratings.ValidationMiddleware()(ratingService)
+	ratingService = ratings.LoggingMiddleware(logger)(ratingService)
+
+	var billingService billing.Service
+	billingService = billing.NewService(priceRepository, subsidyRuleRepository, statementRepository, reservationRepository, userRepository, walletRepository)
+	billingService = billing.ValidationMiddleware(mealTypeRepository)(billingService)
+	billingService = billing.LoggingMiddleware(logger)(billingService)
+
+	payrollLayout, err := payroll.ParseLayout(config.PayrollLayout)
+	if err != nil {
+		logger.Log("msg", "invalid payroll layout", "err", err)
+		os.Exit(1)
+	}
+
+	var payrollService payroll.Service
+	payrollService = payroll.NewService(statementRepository, payrollLayout)
+	payrollService = payroll.LoggingMiddleware(logger)(payrollService)
+
+	var reportService reports.Service
+	reportService = reports.NewService(reportRepository, siteRepository)
+	reportService = reports.ValidationMiddleware()(reportService)
+	reportService = reports.LoggingMiddleware(logger)(reportService)
+
+	var closureService closures.Service
+	closureService = closures.NewService(closureRepository, notifier, walletService, reservationService)
+	closureService = closures.LoggingMiddleware(logger)(closureService)
+
+	var reminderService reminders.Service
+	reminderService = reminders.NewService(reminders.Schedule{ReminderLead: config.ReminderLead, NudgeLead: config.NudgeLead, RegularWeeks: 4, RegularMeals: 2}, notificationPreferenceRepository, sentReminderRepository, reservationRepository, mealTypeRepository, closureRepository, userRepository, siteRepository, notifier)
+	reminderService = reminders.ValidationMiddleware()(reminderService)
+	reminderService = reminders.LoggingMiddleware(logger)(reminderService)
+
+	var sinks []pkg.EventPublisher
+	for _, name := range config.OutboxSinks {
+		switch name {
+		case "webhooks":
+			sinks = append(sinks, webhookService)
+		case "log":
+			sinks = append(sinks, outbox.NewLogSink(logger))
+		case "file":
+			if config.OutboxFile == "" {
+				logger.Log("msg", "the file outbox sink needs MESSAPP_OUTBOX_FILE")
+				os.Exit(1)
+			}
+			sinks = append(sinks, outbox.NewFileSink(config.OutboxFile))
+		default:
+			logger.Log("msg", "unknown outbox sink", "sink", name)
+			os.Exit(1)
+		}
+	}
+
+	var outboxService outbox.Service
+	outboxService = outbox.NewService(outboxRepository, config.OutboxRetention, sinks...)
+	outboxService = outbox.LoggingMiddleware(logger)(outboxService)
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1:], closureService, payrollService, walletService, os.Stdout); err != nil {
+			logger.Log("command", os.Args[1], "msg", "failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// retried POSTs that create users and reservations are answered from
+	// the first attempt rather than creating them twice.
+	idempotent := idempotency.Middleware(idempotencyRepository, config.IdempotencyTTL, logger)
+
 	mux := http.NewServeMux()
-	mux.Handle("/userlist/v1/", userlist.NewServer(userService, logger))
-	mux.Handle("/resvlist/v1/", reservations.NewServer(reservationService, logger))
+	mux.Handle("/userlist/v1/", idempotent(userlist.NewServer(userService, logger)))
+	mux.Handle("/resvlist/v1/", idempotent(reservations.NewServer(reservationService, logger)))
+	mux.Handle("/mealtypes/v1/", mealtypes.NewServer(mealTypeService, logger))
+	mux.Handle("/sites/v1/", sites.NewServer(siteService, logger))
+	mux.Handle("/closures/v1/", closures.NewServer(closureService, logger))
+	mux.Handle("/allergens/v1/", allergens.NewServer(allergenService, logger))
+	mux.Handle("/menu/v1/", menus.NewServer(menuService, logger))
+	mux.Handle("/ratings/v1/", ratings.NewServer(ratingService, config.AdminUserIDs, logger))
+	mux.Handle("/billing/v1/", billing.NewServer(billingService, logger))
+	mux.Handle("/payroll/v1/", payroll.NewServer(payrollService, logger))
+	mux.Handle("/wallet/v1/", wallets.NewServer(walletService, logger))
+	mux.Handle("/reports/v1/", reports.NewServer(reportService, logger))
+	mux.Handle("/webhooks/v1/", webhooks.NewServer(webhookService, logger))
+	mux.Handle("/reminders/v1/", reminders.NewServer(reminderService, logger))
 
 	server := &http.Server{
 		Addr:         config.ServerAddress,
@@ -93,6 +289,37 @@ func main() {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 
+	// the workers below need the database and stop once the server did.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var running sync.WaitGroup
+	if config.DBSource != "" {
+		running.Add(4)
+		go func() {
+			defer running.Done()
+			outbox.Run(workers, outboxService, config.OutboxRelayInterval, config.OutboxCleanupInterval, func(err error) {
+				logger.Log("msg", "could not relay outbox", "err", err)
+			})
+		}()
+		go func() {
+			defer running.Done()
+			webhooks.Run(workers, webhookService, config.WebhookDispatchInterval, func(err error) {
+				logger.Log("msg", "could not dispatch webhooks", "err", err)
+			})
+		}()
+		go func() {
+			defer running.Done()
+			reminders.Run(workers, reminderService, config.ReminderInterval, func(err error) {
+				logger.Log("msg", "could not send reminders", "err", err)
+			})
+		}()
+		go func() {
+			defer running.Done()
+			idempotency.Run(workers, idempotencyRepository, config.IdempotencyCleanupInterval, func(err error) {
+				logger.Log("msg", "could not clean up idempotency keys", "err", err)
+			})
+		}()
+	}
+
 	go func() {
 		logger.Log("transport", "http", "address", config.ServerAddress, "msg", "listening")
 		if err := server.ListenAndServe(); err != http.ErrServerClosed {
@@ -105,5 +332,7 @@ func main() {
 	if err := server.Shutdown(context.Background()); err != nil {
 		logger.Log("msg", "could not shutdown http server", "err", err)
 	}
+	stopWorkers()
+	running.Wait()
 
 }
@@ -0,0 +1,180 @@
+package allergens
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSaveAllergen http.Handler
+	handleSaveAllergen = s.handleSaveAllergen()
+	handleSaveAllergen = httpLoggingMiddleware(logger, "handleSaveAllergen")(handleSaveAllergen)
+
+	var handleListAllergens http.Handler
+	handleListAllergens = s.handleListAllergens()
+	handleListAllergens = httpLoggingMiddleware(logger, "handleListAllergens")(handleListAllergens)
+
+	var handleRemoveAllergen http.Handler
+	handleRemoveAllergen = s.handleRemoveAllergen()
+	handleRemoveAllergen = httpLoggingMiddleware(logger, "handleRemoveAllergen")(handleRemoveAllergen)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/allergens/v1/allergens", handleSaveAllergen)
+	router.Handle("GET", "/allergens/v1/allergens", handleListAllergens)
+	router.Handle("DELETE", "/allergens/v1/allergen/:code", handleRemoveAllergen)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type allergenResponse struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (s *server) handleSaveAllergen() http.HandlerFunc {
+	type request struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		allergen, err := s.service.Save(r.Context(), pkg.Allergen{Code: req.Code, Name: req.Name})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(allergenResponse{Code: allergen.Code, Name: allergen.Name})
+	}
+}
+
+func (s *server) handleListAllergens() http.HandlerFunc {
+	type response []allergenResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.List(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, allergenResponse{Code: v.Code, Name: v.Name})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleRemoveAllergen() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.service.Remove(r.Context(), way.Param(r.Context(), "code")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrAllergenNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrAllergenAlreadyExists, pkg.ErrAllergenInUse:
+		w.WriteHeader(http.StatusConflict)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package allergens
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Save(ctx context.Context, allergen pkg.Allergen) (_ *pkg.Allergen, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save",
+			"code", allergen.Code,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Save(ctx, allergen)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Allergen, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list",
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, code string) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove",
+			"code", code,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Remove(ctx, code)
+}
@@ -0,0 +1,68 @@
+package allergens
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	Save(context.Context, pkg.Allergen) (*pkg.Allergen, error)
+	List(context.Context) ([]pkg.Allergen, error)
+	Remove(context.Context, string) error
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository pkg.AllergenRepository
+}
+
+func NewService(repository pkg.AllergenRepository) Service {
+	return &service{repository: repository}
+}
+
+var codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+func (s *service) Save(ctx context.Context, allergen pkg.Allergen) (*pkg.Allergen, error) {
+	var verr pkg.ValidationError
+	if !codePattern.MatchString(allergen.Code) {
+		verr.Add("code", "invalid", "code must be up to 32 lowercase letters, digits and underscores")
+	}
+	if strings.TrimSpace(allergen.Name) == "" {
+		verr.Add("name", "required", "name is required")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Insert(ctx, &allergen); err != nil {
+		if err == pkg.ErrAllergenAlreadyExists {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save allergen: %v", err)
+	}
+	return &allergen, nil
+}
+
+func (s *service) List(ctx context.Context) ([]pkg.Allergen, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all allergens: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Remove(ctx context.Context, code string) error {
+	if err := s.repository.DeleteByCode(ctx, code); err != nil {
+		if err == pkg.ErrAllergenNotFound || err == pkg.ErrAllergenInUse {
+			return err
+		}
+		return fmt.Errorf("could not remove allergen: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrPriceNotFound       = errors.New("price not found")
+	ErrPriceOverlaps       = errors.New("price overlaps another price of the meal type")
+	ErrSubsidyNotFound     = errors.New("subsidy rule not found")
+	ErrStatementNotFound   = errors.New("statement not found")
+	ErrStatementFinalized  = errors.New("statement is finalized")
+	ErrInvalidBillingMonth = errors.New("billing month has not started yet")
+)
+
+// ErrPriceMissing means a reservation cannot be billed because its meal type
+// had no price on the day.
+type ErrPriceMissing struct {
+	MealTypeID int64
+	Date       time.Time
+}
+
+func (e ErrPriceMissing) Error() string {
+	return fmt.Sprintf("meal type %d has no price on %s", e.MealTypeID, e.Date.Format("2006-01-02"))
+}
+
+// Money is an amount in minor units of the billing currency, such as cents.
+type Money int64
+
+// Validity is the span of days from From to To, both inclusive, something
+// applies to. A nil To leaves it open-ended.
+type Validity struct {
+	From time.Time
+	To   *time.Time
+}
+
+// Covers reports whether the calendar day of date is within the validity.
+func (v Validity) Covers(date time.Time) bool {
+	day := Date(date)
+	return !day.Before(Date(v.From)) && (v.To == nil || !day.After(Date(*v.To)))
+}
+
+// Overlaps reports whether the two validities share at least one day.
+func (v Validity) Overlaps(other Validity) bool {
+	endsBefore := func(a, b Validity) bool { return a.To != nil && Date(*a.To).Before(Date(b.From)) }
+	return !endsBefore(v, other) && !endsBefore(other, v)
+}
+
+// Price is what a meal costs an employee and each of their guests.
+type Price struct {
+	ID            int64
+	MealTypeID    int64
+	EmployeePrice Money
+	GuestPrice    Money
+	Validity      Validity
+	CreatedAt     time.Time
+}
+
+// PriceOn finds the price of a meal type on the day of date, or nil.
+func PriceOn(prices []Price, mealTypeID int64, date time.Time) *Price {
+	for i, price := range prices {
+		if price.MealTypeID == mealTypeID && price.Validity.Covers(date) {
+			return &prices[i]
+		}
+	}
+	return nil
+}
+
+// SubsidyRule is the percentage of the employee price the company pays for
+// employees of a designation, a department, or both. An empty Designation or
+// Department matches everyone. Guests are never subsidised.
+type SubsidyRule struct {
+	ID          int64
+	Designation string
+	Department  string
+	Percent     int64
+	Validity    Validity
+	CreatedAt   time.Time
+}
+
+// Matches reports whether the rule applies to user on the day of date.
+func (r SubsidyRule) Matches(user User, date time.Time) bool {
+	return r.Validity.Covers(date) &&
+		(r.Designation == "" || r.Designation == user.Designation) &&
+		(r.Department == "" || r.Department == user.Department)
+}
+
+// specificity counts the fields a rule narrows by.
+func (r SubsidyRule) specificity() int {
+	n := 0
+	if r.Designation != "" {
+		n++
+	}
+	if r.Department != "" {
+		n++
+	}
+	return n
+}
+
+// SubsidyFor picks the rule that applies to user on the day of date: the
+// most specific matching rule, and of equally specific ones the most
+// generous. It returns nil when no rule matches.
+func SubsidyFor(rules []SubsidyRule, user User, date time.Time) *SubsidyRule {
+	var best *SubsidyRule
+	for i, rule := range rules {
+		if !rule.Matches(user, date) {
+			continue
+		}
+		if best == nil || rule.specificity() > best.specificity() ||
+			(rule.specificity() == best.specificity() && rule.Percent > best.Percent) {
+			best = &rules[i]
+		}
+	}
+	return best
+}
+
+type StatementStatus string
+
+const (
+	StatementDraft     StatementStatus = "draft"
+	StatementFinalized StatementStatus = "finalized"
+)
+
+// Statement is what an employee owes for the meals of one month. A draft is
+// recomputed every time statements are generated; a finalized statement
+// never changes again.
+type Statement struct {
+	ID     int64
+	UserID int64
+	// EmployeeID is that of the user when the statement was generated.
+	EmployeeID string
+	// Month is the first day of the billed month.
+	Month       time.Time
+	Status      StatementStatus
+	Lines       []StatementLine
+	Subtotal    Money
+	Subsidy     Money
+	Total       Money
+	CreatedAt   time.Time
+	FinalizedAt *time.Time
+}
+
+// StatementLine bills a single reservation. Attended is false for no-shows,
+// which are charged all the same.
+type StatementLine struct {
+	ReservationID int64
+	ServiceDate   time.Time
+	MealTypeID    int64
+	Attended      bool
+	Guests        int64
+	EmployeePrice Money
+	GuestPrice    Money
+	Subsidy       Money
+	Amount        Money
+}
+
+// NewStatementLine prices a reservation, taking percent off the employee
+// price and rounding the subsidy half up.
+func NewStatementLine(reservation Reservation, price Price, percent int64) StatementLine {
+	subsidy := (price.EmployeePrice*Money(percent) + 50) / 100
+	return StatementLine{
+		ReservationID: reservation.ID,
+		ServiceDate:   Date(reservation.ServiceDate),
+		MealTypeID:    reservation.MealTypeID,
+		Attended:      reservation.CheckedInAt != nil,
+		Guests:        reservation.NoOfGuests,
+		EmployeePrice: price.EmployeePrice,
+		GuestPrice:    price.GuestPrice,
+		Subsidy:       subsidy,
+		Amount:        price.EmployeePrice - subsidy + price.GuestPrice*Money(reservation.NoOfGuests),
+	}
+}
+
+// Add appends line and updates the totals.
+func (s *Statement) Add(line StatementLine) {
+	s.Lines = append(s.Lines, line)
+	s.Subtotal += line.EmployeePrice + line.GuestPrice*Money(line.Guests)
+	s.Subsidy += line.Subsidy
+	s.Total += line.Amount
+}
+
+type PriceRepository interface {
+	Insert(context.Context, *Price) error
+	FindAll(context.Context) ([]Price, error)
+	DeleteByID(context.Context, int64) error
+}
+
+type SubsidyRuleRepository interface {
+	Insert(context.Context, *SubsidyRule) error
+	FindAll(context.Context) ([]SubsidyRule, error)
+	DeleteByID(context.Context, int64) error
+}
+
+type StatementRepository interface {
+	// Save stores a draft statement, replacing the draft of the same user
+	// and month if there is one, or fails with ErrStatementFinalized.
+	Save(context.Context, *Statement) error
+	FindByID(context.Context, int64) (*Statement, error)
+	FindByMonth(ctx context.Context, month time.Time) ([]Statement, error)
+	FindByUser(ctx context.Context, userID int64) ([]Statement, error)
+	Finalize(ctx context.Context, id int64, at time.Time) error
+	// DeleteDraft removes a draft statement, or fails with
+	// ErrStatementFinalized.
+	DeleteDraft(context.Context, int64) error
+}
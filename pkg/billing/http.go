@@ -0,0 +1,492 @@
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSavePrice http.Handler
+	handleSavePrice = s.handleSavePrice()
+	handleSavePrice = httpLoggingMiddleware(logger, "handleSavePrice")(handleSavePrice)
+
+	var handleListPrices http.Handler
+	handleListPrices = s.handleListPrices()
+	handleListPrices = httpLoggingMiddleware(logger, "handleListPrices")(handleListPrices)
+
+	var handleRemovePrice http.Handler
+	handleRemovePrice = s.handleRemovePrice()
+	handleRemovePrice = httpLoggingMiddleware(logger, "handleRemovePrice")(handleRemovePrice)
+
+	var handleSaveSubsidy http.Handler
+	handleSaveSubsidy = s.handleSaveSubsidy()
+	handleSaveSubsidy = httpLoggingMiddleware(logger, "handleSaveSubsidy")(handleSaveSubsidy)
+
+	var handleListSubsidies http.Handler
+	handleListSubsidies = s.handleListSubsidies()
+	handleListSubsidies = httpLoggingMiddleware(logger, "handleListSubsidies")(handleListSubsidies)
+
+	var handleRemoveSubsidy http.Handler
+	handleRemoveSubsidy = s.handleRemoveSubsidy()
+	handleRemoveSubsidy = httpLoggingMiddleware(logger, "handleRemoveSubsidy")(handleRemoveSubsidy)
+
+	var handleGenerateStatements http.Handler
+	handleGenerateStatements = s.handleGenerateStatements()
+	handleGenerateStatements = httpLoggingMiddleware(logger, "handleGenerateStatements")(handleGenerateStatements)
+
+	var handleListStatements http.Handler
+	handleListStatements = s.handleListStatements()
+	handleListStatements = httpLoggingMiddleware(logger, "handleListStatements")(handleListStatements)
+
+	var handleGetStatement http.Handler
+	handleGetStatement = s.handleGetStatement()
+	handleGetStatement = httpLoggingMiddleware(logger, "handleGetStatement")(handleGetStatement)
+
+	var handleFinalizeStatement http.Handler
+	handleFinalizeStatement = s.handleFinalizeStatement()
+	handleFinalizeStatement = httpLoggingMiddleware(logger, "handleFinalizeStatement")(handleFinalizeStatement)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/billing/v1/prices", handleSavePrice)
+	router.Handle("GET", "/billing/v1/prices", handleListPrices)
+	router.Handle("DELETE", "/billing/v1/price/:id", handleRemovePrice)
+	router.Handle("POST", "/billing/v1/subsidies", handleSaveSubsidy)
+	router.Handle("GET", "/billing/v1/subsidies", handleListSubsidies)
+	router.Handle("DELETE", "/billing/v1/subsidy/:id", handleRemoveSubsidy)
+	router.Handle("POST", "/billing/v1/statements", handleGenerateStatements)
+	router.Handle("GET", "/billing/v1/statements", handleListStatements)
+	router.Handle("GET", "/billing/v1/statement/:id", handleGetStatement)
+	router.Handle("POST", "/billing/v1/statement/:id/finalize", handleFinalizeStatement)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	dateLayout       = "2006-01-02"
+	monthLayout      = "2006-01"
+)
+
+var (
+	ErrNonNumericID     = errors.New("id in path must be numeric")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+// validity is the wire form of pkg.Validity, as dates.
+type validity struct {
+	ValidFrom string  `json:"valid_from"`
+	ValidTo   *string `json:"valid_to"`
+}
+
+func newValidity(v pkg.Validity) validity {
+	resp := validity{ValidFrom: v.From.Format(dateLayout)}
+	if v.To != nil {
+		to := v.To.Format(dateLayout)
+		resp.ValidTo = &to
+	}
+	return resp
+}
+
+func (v validity) parse() (pkg.Validity, error) {
+	var parsed pkg.Validity
+	if v.ValidFrom != "" {
+		from, err := time.Parse(dateLayout, v.ValidFrom)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.From = from
+	}
+	if v.ValidTo != nil {
+		to, err := time.Parse(dateLayout, *v.ValidTo)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.To = &to
+	}
+	return parsed, nil
+}
+
+// Amounts are integer minor units of the billing currency.
+type priceResponse struct {
+	ID            int64 `json:"id"`
+	MealTypeID    int64 `json:"meal_type_id"`
+	EmployeePrice int64 `json:"employee_price"`
+	GuestPrice    int64 `json:"guest_price"`
+	validity
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newPriceResponse(price pkg.Price) priceResponse {
+	return priceResponse{ID: price.ID, MealTypeID: price.MealTypeID, EmployeePrice: int64(price.EmployeePrice), GuestPrice: int64(price.GuestPrice), validity: newValidity(price.Validity), CreatedAt: price.CreatedAt}
+}
+
+type subsidyResponse struct {
+	ID          int64  `json:"id"`
+	Designation string `json:"designation"`
+	Department  string `json:"department"`
+	Percent     int64  `json:"percent"`
+	validity
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSubsidyResponse(rule pkg.SubsidyRule) subsidyResponse {
+	return subsidyResponse{ID: rule.ID, Designation: rule.Designation, Department: rule.Department, Percent: rule.Percent, validity: newValidity(rule.Validity), CreatedAt: rule.CreatedAt}
+}
+
+type statementResponse struct {
+	ID          int64                   `json:"id"`
+	UserID      int64                   `json:"user_id"`
+	EmployeeID  string                  `json:"employee_id"`
+	Month       string                  `json:"month"`
+	Status      string                  `json:"status"`
+	Lines       []statementLineResponse `json:"lines"`
+	Subtotal    int64                   `json:"subtotal"`
+	Subsidy     int64                   `json:"subsidy"`
+	Total       int64                   `json:"total"`
+	CreatedAt   time.Time               `json:"created_at"`
+	FinalizedAt *time.Time              `json:"finalized_at"`
+}
+
+type statementLineResponse struct {
+	ReservationID int64  `json:"reservation_id"`
+	ServiceDate   string `json:"service_date"`
+	MealTypeID    int64  `json:"meal_type_id"`
+	Attended      bool   `json:"attended"`
+	Guests        int64  `json:"guests"`
+	EmployeePrice int64  `json:"employee_price"`
+	GuestPrice    int64  `json:"guest_price"`
+	Subsidy       int64  `json:"subsidy"`
+	Amount        int64  `json:"amount"`
+}
+
+func newStatementResponse(statement pkg.Statement) statementResponse {
+	resp := statementResponse{ID: statement.ID, UserID: statement.UserID, EmployeeID: statement.EmployeeID, Month: statement.Month.Format(monthLayout), Status: string(statement.Status), Lines: make([]statementLineResponse, 0, len(statement.Lines)), Subtotal: int64(statement.Subtotal), Subsidy: int64(statement.Subsidy), Total: int64(statement.Total), CreatedAt: statement.CreatedAt, FinalizedAt: statement.FinalizedAt}
+	for _, line := range statement.Lines {
+		resp.Lines = append(resp.Lines, statementLineResponse{ReservationID: line.ReservationID, ServiceDate: line.ServiceDate.Format(dateLayout), MealTypeID: line.MealTypeID, Attended: line.Attended, Guests: line.Guests, EmployeePrice: int64(line.EmployeePrice), GuestPrice: int64(line.GuestPrice), Subsidy: int64(line.Subsidy), Amount: int64(line.Amount)})
+	}
+	return resp
+}
+
+func newStatementsResponse(statements []pkg.Statement) []statementResponse {
+	resp := make([]statementResponse, 0, len(statements))
+	for _, statement := range statements {
+		resp = append(resp, newStatementResponse(statement))
+	}
+	return resp
+}
+
+func (s *server) handleSavePrice() http.HandlerFunc {
+	type request struct {
+		MealTypeID    int64 `json:"meal_type_id"`
+		EmployeePrice int64 `json:"employee_price"`
+		GuestPrice    int64 `json:"guest_price"`
+		validity
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		validity, err := req.validity.parse()
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		price, err := s.service.SavePrice(r.Context(), pkg.Price{MealTypeID: req.MealTypeID, EmployeePrice: pkg.Money(req.EmployeePrice), GuestPrice: pkg.Money(req.GuestPrice), Validity: validity})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newPriceResponse(*price))
+	}
+}
+
+func (s *server) handleListPrices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.ListPrices(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]priceResponse, 0, len(list))
+		for _, price := range list {
+			resp = append(resp, newPriceResponse(price))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleRemovePrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		if err := s.service.RemovePrice(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleSaveSubsidy() http.HandlerFunc {
+	type request struct {
+		Designation string `json:"designation"`
+		Department  string `json:"department"`
+		Percent     int64  `json:"percent"`
+		validity
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		validity, err := req.validity.parse()
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		rule, err := s.service.SaveSubsidy(r.Context(), pkg.SubsidyRule{Designation: req.Designation, Department: req.Department, Percent: req.Percent, Validity: validity})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newSubsidyResponse(*rule))
+	}
+}
+
+func (s *server) handleListSubsidies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.ListSubsidies(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]subsidyResponse, 0, len(list))
+		for _, rule := range list {
+			resp = append(resp, newSubsidyResponse(rule))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleRemoveSubsidy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		if err := s.service.RemoveSubsidy(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleGenerateStatements (re)generates the drafts of ?month=YYYY-MM, the
+// previous month by default.
+func (s *server) handleGenerateStatements() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		if v := r.URL.Query().Get("month"); v != "" {
+			var err error
+			if month, err = time.Parse(monthLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		list, err := s.service.GenerateStatements(r.Context(), month)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStatementsResponse(list))
+	}
+}
+
+// handleListStatements lists the statements of ?user_id= or, without it, of
+// ?month=YYYY-MM.
+func (s *server) handleListStatements() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var list []pkg.Statement
+		var err error
+		switch {
+		case query.Get("user_id") != "":
+			userID, perr := strconv.ParseInt(query.Get("user_id"), 10, 64)
+			if perr != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+			list, err = s.service.UserStatements(r.Context(), userID)
+		case query.Get("month") != "":
+			month, perr := time.Parse(monthLayout, query.Get("month"))
+			if perr != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+			list, err = s.service.MonthStatements(r.Context(), month)
+		default:
+			writeError(w, ErrInvalidQuery)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStatementsResponse(list))
+	}
+}
+
+func (s *server) handleGetStatement() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		statement, err := s.service.GetStatement(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStatementResponse(*statement))
+	}
+}
+
+func (s *server) handleFinalizeStatement() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		statement, err := s.service.Finalize(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStatementResponse(*statement))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrPriceNotFound, pkg.ErrSubsidyNotFound, pkg.ErrStatementNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrPriceOverlaps, pkg.ErrStatementFinalized:
+		w.WriteHeader(http.StatusConflict)
+	case ErrNonNumericID, ErrInvalidQuery, pkg.ErrUnknownMealType, pkg.ErrInvalidBillingMonth:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ErrPriceMissing:
+			w.WriteHeader(http.StatusConflict)
+			body["meal_type_id"] = e.MealTypeID
+			body["date"] = e.Date.Format(dateLayout)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) SavePrice(ctx context.Context, price pkg.Price) (_ *pkg.Price, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save_price",
+			"meal_type_id", price.MealTypeID,
+			"valid_from", price.Validity.From,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SavePrice(ctx, price)
+}
+
+func (s *loggingMiddleware) ListPrices(ctx context.Context) (list []pkg.Price, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list_prices",
+			"prices", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.ListPrices(ctx)
+}
+
+func (s *loggingMiddleware) RemovePrice(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove_price",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RemovePrice(ctx, id)
+}
+
+func (s *loggingMiddleware) SaveSubsidy(ctx context.Context, rule pkg.SubsidyRule) (_ *pkg.SubsidyRule, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save_subsidy",
+			"designation", rule.Designation,
+			"department", rule.Department,
+			"percent", rule.Percent,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SaveSubsidy(ctx, rule)
+}
+
+func (s *loggingMiddleware) ListSubsidies(ctx context.Context) (list []pkg.SubsidyRule, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list_subsidies",
+			"rules", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.ListSubsidies(ctx)
+}
+
+func (s *loggingMiddleware) RemoveSubsidy(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove_subsidy",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RemoveSubsidy(ctx, id)
+}
+
+func (s *loggingMiddleware) GenerateStatements(ctx context.Context, month time.Time) (list []pkg.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "generate_statements",
+			"month", month,
+			"statements", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.GenerateStatements(ctx, month)
+}
+
+func (s *loggingMiddleware) GetStatement(ctx context.Context, id int64) (_ *pkg.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get_statement",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.GetStatement(ctx, id)
+}
+
+func (s *loggingMiddleware) MonthStatements(ctx context.Context, month time.Time) (list []pkg.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "month_statements",
+			"month", month,
+			"statements", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.MonthStatements(ctx, month)
+}
+
+func (s *loggingMiddleware) UserStatements(ctx context.Context, userID int64) (list []pkg.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "user_statements",
+			"user_id", userID,
+			"statements", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.UserStatements(ctx, userID)
+}
+
+func (s *loggingMiddleware) Finalize(ctx context.Context, id int64) (_ *pkg.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "finalize",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Finalize(ctx, id)
+}
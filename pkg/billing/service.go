@@ -0,0 +1,275 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	SavePrice(context.Context, pkg.Price) (*pkg.Price, error)
+	ListPrices(context.Context) ([]pkg.Price, error)
+	RemovePrice(context.Context, int64) error
+
+	SaveSubsidy(context.Context, pkg.SubsidyRule) (*pkg.SubsidyRule, error)
+	ListSubsidies(context.Context) ([]pkg.SubsidyRule, error)
+	RemoveSubsidy(context.Context, int64) error
+
+	// GenerateStatements recomputes the draft statement of every employee
+	// with meals to pay for in the month of month, leaving finalized ones
+	// alone, and returns all statements of that month. Drafts of employees
+	// left with nothing to pay for are removed.
+	GenerateStatements(ctx context.Context, month time.Time) ([]pkg.Statement, error)
+	GetStatement(context.Context, int64) (*pkg.Statement, error)
+	MonthStatements(ctx context.Context, month time.Time) ([]pkg.Statement, error)
+	UserStatements(ctx context.Context, userID int64) ([]pkg.Statement, error)
+	Finalize(context.Context, int64) (*pkg.Statement, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	prices       pkg.PriceRepository
+	subsidies    pkg.SubsidyRuleRepository
+	statements   pkg.StatementRepository
+	reservations pkg.ReservationRepository
+	users        pkg.UserRepository
+	wallets      pkg.WalletRepository
+}
+
+func NewService(prices pkg.PriceRepository, subsidies pkg.SubsidyRuleRepository, statements pkg.StatementRepository, reservations pkg.ReservationRepository, users pkg.UserRepository, wallets pkg.WalletRepository) Service {
+	return &service{prices: prices, subsidies: subsidies, statements: statements, reservations: reservations, users: users, wallets: wallets}
+}
+
+func (s *service) SavePrice(ctx context.Context, price pkg.Price) (*pkg.Price, error) {
+	existing, err := s.prices.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list prices: %v", err)
+	}
+	for _, other := range existing {
+		if other.MealTypeID == price.MealTypeID && other.Validity.Overlaps(price.Validity) {
+			return nil, pkg.ErrPriceOverlaps
+		}
+	}
+
+	if err := s.prices.Insert(ctx, &price); err != nil {
+		if err == pkg.ErrUnknownMealType {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save price: %v", err)
+	}
+	return &price, nil
+}
+
+func (s *service) ListPrices(ctx context.Context) ([]pkg.Price, error) {
+	list, err := s.prices.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all prices: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) RemovePrice(ctx context.Context, id int64) error {
+	if err := s.prices.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrPriceNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove price: %v", err)
+	}
+	return nil
+}
+
+func (s *service) SaveSubsidy(ctx context.Context, rule pkg.SubsidyRule) (*pkg.SubsidyRule, error) {
+	if err := s.subsidies.Insert(ctx, &rule); err != nil {
+		return nil, fmt.Errorf("could not save subsidy rule: %v", err)
+	}
+	return &rule, nil
+}
+
+func (s *service) ListSubsidies(ctx context.Context) ([]pkg.SubsidyRule, error) {
+	list, err := s.subsidies.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all subsidy rules: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) RemoveSubsidy(ctx context.Context, id int64) error {
+	if err := s.subsidies.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrSubsidyNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove subsidy rule: %v", err)
+	}
+	return nil
+}
+
+func (s *service) GenerateStatements(ctx context.Context, month time.Time) ([]pkg.Statement, error) {
+	first := firstOfMonth(month)
+	today := pkg.Date(time.Now())
+	if first.After(today) {
+		return nil, pkg.ErrInvalidBillingMonth
+	}
+
+	// only meals that were already served are billed, whether the employee
+	// turned up or not.
+	last := first.AddDate(0, 1, -1)
+	if yesterday := today.AddDate(0, 0, -1); last.After(yesterday) {
+		last = yesterday
+	}
+
+	statements, err := s.compute(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	billed := make(map[int64]bool, len(statements))
+	for _, statement := range statements {
+		billed[statement.UserID] = true
+		err := s.statements.Save(ctx, &statement)
+		if err == pkg.ErrStatementFinalized {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not save statement: %v", err)
+		}
+	}
+
+	// a draft of an earlier run whose meals were cancelled since must not
+	// be finalized at its old total.
+	existing, err := s.MonthStatements(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	for _, statement := range existing {
+		if billed[statement.UserID] || statement.Status != pkg.StatementDraft {
+			continue
+		}
+		err := s.statements.DeleteDraft(ctx, statement.ID)
+		if err == pkg.ErrStatementFinalized || err == pkg.ErrStatementNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not remove statement: %v", err)
+		}
+	}
+	return s.MonthStatements(ctx, first)
+}
+
+// compute prices the reservations from first to last into one statement
+// per employee.
+func (s *service) compute(ctx context.Context, first, last time.Time) ([]pkg.Statement, error) {
+	var reservations []pkg.Reservation
+	if !last.Before(first) {
+		var err error
+		if reservations, err = s.reservations.FindActiveBetween(ctx, first, last); err != nil {
+			return nil, fmt.Errorf("could not list reservations: %v", err)
+		}
+	}
+	prices, err := s.prices.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list prices: %v", err)
+	}
+	rules, err := s.subsidies.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list subsidy rules: %v", err)
+	}
+
+	// meals paid from a wallet had their cost held when they were booked.
+	// Those of users who only opened a wallet later are billed like the
+	// meals of everybody else.
+	prepaid := make(map[int64]bool)
+	if !last.Before(first) {
+		held, err := s.wallets.FindHeldReservations(ctx, first, last)
+		if err != nil {
+			return nil, fmt.Errorf("could not list wallet holds: %v", err)
+		}
+		for _, id := range held {
+			prepaid[id] = true
+		}
+	}
+
+	byUser := make(map[int64]*pkg.Statement)
+	users := make(map[int64]*pkg.User)
+	for _, reservation := range reservations {
+		if prepaid[reservation.ID] {
+			continue
+		}
+
+		price := pkg.PriceOn(prices, reservation.MealTypeID, reservation.ServiceDate)
+		if price == nil {
+			return nil, pkg.ErrPriceMissing{MealTypeID: reservation.MealTypeID, Date: reservation.ServiceDate}
+		}
+
+		user, ok := users[reservation.UserID]
+		if !ok {
+			if user, err = s.users.FindByID(ctx, reservation.UserID); err != nil {
+				return nil, fmt.Errorf("could not find user: %v", err)
+			}
+			users[reservation.UserID] = user
+		}
+		var percent int64
+		if rule := pkg.SubsidyFor(rules, *user, reservation.ServiceDate); rule != nil {
+			percent = rule.Percent
+		}
+
+		statement, ok := byUser[reservation.UserID]
+		if !ok {
+			statement = &pkg.Statement{UserID: reservation.UserID, EmployeeID: user.EmployeeID, Month: first, Status: pkg.StatementDraft}
+			byUser[reservation.UserID] = statement
+		}
+		statement.Add(pkg.NewStatementLine(reservation, *price, percent))
+	}
+
+	list := make([]pkg.Statement, 0, len(byUser))
+	for _, statement := range byUser {
+		list = append(list, *statement)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UserID < list[j].UserID })
+	return list, nil
+}
+
+func (s *service) GetStatement(ctx context.Context, id int64) (*pkg.Statement, error) {
+	statement, err := s.statements.FindByID(ctx, id)
+	if err == pkg.ErrStatementNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find statement: %v", err)
+	}
+	return statement, nil
+}
+
+func (s *service) MonthStatements(ctx context.Context, month time.Time) ([]pkg.Statement, error) {
+	list, err := s.statements.FindByMonth(ctx, firstOfMonth(month))
+	if err != nil {
+		return nil, fmt.Errorf("could not list statements: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) UserStatements(ctx context.Context, userID int64) ([]pkg.Statement, error) {
+	list, err := s.statements.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list statements: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Finalize(ctx context.Context, id int64) (*pkg.Statement, error) {
+	if err := s.statements.Finalize(ctx, id, time.Now()); err != nil {
+		if err == pkg.ErrStatementNotFound || err == pkg.ErrStatementFinalized {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not finalize statement: %v", err)
+	}
+	return s.GetStatement(ctx, id)
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	day := pkg.Date(t)
+	return day.AddDate(0, 0, 1-day.Day())
+}
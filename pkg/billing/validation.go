@@ -0,0 +1,62 @@
+package billing
+
+import (
+	"context"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware(mealTypes pkg.MealTypeRepository) Middleware {
+	return func(s Service) Service { return &validationMiddleware{mealTypes, s} }
+}
+
+type validationMiddleware struct {
+	mealTypes pkg.MealTypeRepository
+	Service
+}
+
+func (s *validationMiddleware) SavePrice(ctx context.Context, price pkg.Price) (*pkg.Price, error) {
+	var verr pkg.ValidationError
+
+	if price.MealTypeID == 0 {
+		verr.Add("meal_type_id", "required", "meal_type_id is required")
+	} else if _, err := s.mealTypes.FindByID(ctx, price.MealTypeID); err == pkg.ErrMealTypeNotFound {
+		verr.Add("meal_type_id", "unknown", "meal type does not exist")
+	} else if err != nil {
+		return nil, err
+	}
+	if price.EmployeePrice < 0 {
+		verr.Add("employee_price", "min", "employee_price must not be negative")
+	}
+	if price.GuestPrice < 0 {
+		verr.Add("guest_price", "min", "guest_price must not be negative")
+	}
+	validateValidity(&verr, price.Validity)
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.SavePrice(ctx, price)
+}
+
+func (s *validationMiddleware) SaveSubsidy(ctx context.Context, rule pkg.SubsidyRule) (*pkg.SubsidyRule, error) {
+	var verr pkg.ValidationError
+
+	if rule.Percent < 0 || rule.Percent > 100 {
+		verr.Add("percent", "out_of_range", "percent must be between 0 and 100")
+	}
+	validateValidity(&verr, rule.Validity)
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.SaveSubsidy(ctx, rule)
+}
+
+func validateValidity(verr *pkg.ValidationError, validity pkg.Validity) {
+	if validity.From.IsZero() {
+		verr.Add("valid_from", "required", "valid_from is required")
+	} else if validity.To != nil && pkg.Date(*validity.To).Before(pkg.Date(validity.From)) {
+		verr.Add("valid_to", "before_start", "valid_to must not be before valid_from")
+	}
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrClosureNotFound      = errors.New("closure not found")
+	ErrClosureAlreadyExists = errors.New("closure already exists")
+	ErrMealClosed           = errors.New("mess is closed for that meal")
+)
+
+// ErrClosed is ErrMealClosed carrying the closure that blocks the booking.
+type ErrClosed struct {
+	ClosureID int64
+	Reason    string
+}
+
+func (e ErrClosed) Error() string { return fmt.Sprintf("%v: %s", ErrMealClosed, e.Reason) }
+
+func (e ErrClosed) Is(target error) bool { return target == ErrMealClosed }
+
+// Closure marks the days from StartDate to EndDate, both inclusive, as closed.
+// An empty MealTypeIDs closes every meal of those days. SourceUID is the UID
+// of the calendar event an imported closure came from.
+type Closure struct {
+	ID          int64
+	StartDate   time.Time
+	EndDate     time.Time
+	MealTypeIDs []int64
+	Reason      string
+	SourceUID   string
+	CreatedAt   time.Time
+}
+
+// Covers reports whether the closure blocks the given meal on the calendar
+// day of date.
+func (c Closure) Covers(date time.Time, mealTypeID int64) bool {
+	day := Date(date)
+	if day.Before(Date(c.StartDate)) || day.After(Date(c.EndDate)) {
+		return false
+	}
+	if len(c.MealTypeIDs) == 0 {
+		return true
+	}
+	for _, id := range c.MealTypeIDs {
+		if id == mealTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Date truncates t to midnight UTC of its calendar day, the form calendar
+// dates are stored and compared in.
+func Date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ServiceDate is the calendar day, as a Date, that t falls on in loc, the
+// time zone of the site a meal is served at.
+func ServiceDate(t time.Time, loc *time.Location) time.Time {
+	return Date(t.In(loc))
+}
+
+// LocalDate is the start of the calendar day of date in loc, which times of
+// day served there are placed on.
+func LocalDate(date time.Time, loc *time.Location) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+type ClosureRepository interface {
+	// Insert stores the closure and, in the same transaction, cancels every
+	// active reservation it covers. The cancelled reservations are returned.
+	Insert(context.Context, *Closure) ([]Reservation, error)
+	FindAll(context.Context) ([]Closure, error)
+	FindByID(context.Context, int64) (*Closure, error)
+	// FindCovering returns a closure blocking the meal on the day of date, or
+	// ErrClosureNotFound.
+	FindCovering(ctx context.Context, date time.Time, mealTypeID int64) (*Closure, error)
+	// FindBySource returns the closure imported from the event occurrence
+	// starting on startDate, or ErrClosureNotFound.
+	FindBySource(ctx context.Context, uid string, startDate time.Time) (*Closure, error)
+	DeleteByID(context.Context, int64) error
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("could not load time zone %s: %v", name, err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t.UTC()
+}
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Berlin moves its clocks forward at 02:00 on Mar 29 2026 and back at 03:00
+// on Oct 25 2026. Midnight is 23:00 UTC before the spring-forward day and
+// the fall-back day, and 22:00 UTC after them.
+func TestServiceDateAcrossDST(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"last second before spring-forward day", utc("2026-03-28T22:59:59Z"), day("2026-03-28")},
+		{"midnight of spring-forward day", utc("2026-03-28T23:00:00Z"), day("2026-03-29")},
+		{"before the gap", utc("2026-03-29T00:59:59Z"), day("2026-03-29")},
+		{"after the gap", utc("2026-03-29T01:00:00Z"), day("2026-03-29")},
+		{"last second of spring-forward day", utc("2026-03-29T21:59:59Z"), day("2026-03-29")},
+		{"midnight after spring-forward day", utc("2026-03-29T22:00:00Z"), day("2026-03-30")},
+		{"last second before fall-back day", utc("2026-10-24T21:59:59Z"), day("2026-10-24")},
+		{"midnight of fall-back day", utc("2026-10-24T22:00:00Z"), day("2026-10-25")},
+		{"first 02:30 of fall-back day", utc("2026-10-25T00:30:00Z"), day("2026-10-25")},
+		{"second 02:30 of fall-back day", utc("2026-10-25T01:30:00Z"), day("2026-10-25")},
+		{"last second of fall-back day", utc("2026-10-25T22:59:59Z"), day("2026-10-25")},
+		{"midnight after fall-back day", utc("2026-10-25T23:00:00Z"), day("2026-10-26")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ServiceDate(tt.at, berlin)
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("ServiceDate(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+			// the zone the time comes in does not matter.
+			if got := ServiceDate(tt.at.In(berlin), berlin); !got.Equal(tt.want) {
+				t.Errorf("ServiceDate(%v) = %v, want %v", tt.at.In(berlin), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalDateAcrossDST(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	lunch, _ := ParseTimeOfDay("12:00")
+
+	tests := []struct {
+		date     time.Time
+		midnight time.Time
+		length   time.Duration
+		lunch    time.Time
+	}{
+		{day("2026-03-28"), utc("2026-03-27T23:00:00Z"), 24 * time.Hour, utc("2026-03-28T11:00:00Z")},
+		{day("2026-03-29"), utc("2026-03-28T23:00:00Z"), 23 * time.Hour, utc("2026-03-29T10:00:00Z")},
+		{day("2026-03-30"), utc("2026-03-29T22:00:00Z"), 24 * time.Hour, utc("2026-03-30T10:00:00Z")},
+		{day("2026-10-25"), utc("2026-10-24T22:00:00Z"), 25 * time.Hour, utc("2026-10-25T11:00:00Z")},
+		{day("2026-10-26"), utc("2026-10-25T23:00:00Z"), 24 * time.Hour, utc("2026-10-26T11:00:00Z")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date.Format("2006-01-02"), func(t *testing.T) {
+			midnight := LocalDate(tt.date, berlin)
+			if !midnight.Equal(tt.midnight) {
+				t.Errorf("LocalDate() = %v, want %v", midnight.UTC(), tt.midnight)
+			}
+			if length := LocalDate(tt.date.AddDate(0, 0, 1), berlin).Sub(midnight); length != tt.length {
+				t.Errorf("day lasts %v, want %v", length, tt.length)
+			}
+			if got := lunch.On(midnight); !got.Equal(tt.lunch) {
+				t.Errorf("lunch starts at %v, want %v", got.UTC(), tt.lunch)
+			}
+			if got := ServiceDate(midnight, berlin); !got.Equal(tt.date) {
+				t.Errorf("ServiceDate(LocalDate()) = %v, want %v", got, tt.date)
+			}
+		})
+	}
+}
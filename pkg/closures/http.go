@@ -0,0 +1,312 @@
+package closures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSaveClosure http.Handler
+	handleSaveClosure = s.handleSaveClosure()
+	handleSaveClosure = httpLoggingMiddleware(logger, "handleSaveClosure")(handleSaveClosure)
+
+	var handleListClosures http.Handler
+	handleListClosures = s.handleListClosures()
+	handleListClosures = httpLoggingMiddleware(logger, "handleListClosures")(handleListClosures)
+
+	var handleRemoveClosure http.Handler
+	handleRemoveClosure = s.handleRemoveClosure()
+	handleRemoveClosure = httpLoggingMiddleware(logger, "handleRemoveClosure")(handleRemoveClosure)
+
+	var handleImportClosures http.Handler
+	handleImportClosures = s.handleImportClosures()
+	handleImportClosures = httpLoggingMiddleware(logger, "handleImportClosures")(handleImportClosures)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/closures/v1/closures", handleSaveClosure)
+	router.Handle("GET", "/closures/v1/closures", handleListClosures)
+	router.Handle("POST", "/closures/v1/closures/import", handleImportClosures)
+	router.Handle("DELETE", "/closures/v1/closure/:id", handleRemoveClosure)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	dateLayout       = "2006-01-02"
+)
+
+var (
+	ErrNonNumericClosureID = errors.New("closure id in path must be numberic")
+	ErrResourceNotFound    = errors.New("resource not found")
+	ErrMethodNotAllowed    = errors.New("method not allowed")
+	ErrInvalidQuery        = errors.New("invalid query parameter")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type closureResponse struct {
+	ID          int64     `json:"id"`
+	StartDate   string    `json:"start_date"`
+	EndDate     string    `json:"end_date"`
+	MealTypeIDs []int64   `json:"meal_types"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func newClosureResponse(closure pkg.Closure) closureResponse {
+	mealTypeIDs := closure.MealTypeIDs
+	if mealTypeIDs == nil {
+		mealTypeIDs = []int64{}
+	}
+	return closureResponse{ID: closure.ID, StartDate: closure.StartDate.Format(dateLayout), EndDate: closure.EndDate.Format(dateLayout), MealTypeIDs: mealTypeIDs, Reason: closure.Reason, CreatedAt: closure.CreatedAt}
+}
+
+func (s *server) handleSaveClosure() http.HandlerFunc {
+	type request struct {
+		StartDate   string  `json:"start_date"`
+		EndDate     string  `json:"end_date"`
+		MealTypeIDs []int64 `json:"meal_types"`
+		Reason      string  `json:"reason"`
+	}
+	type response struct {
+		closureResponse
+		CancelledReservations []int64 `json:"cancelled_reservations"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		var closure pkg.Closure
+		var err error
+		if req.StartDate != "" {
+			if closure.StartDate, err = time.Parse(dateLayout, req.StartDate); err != nil {
+				writeError(w, ErrInvalidRequestBody{err})
+				return
+			}
+		}
+		if req.EndDate != "" {
+			if closure.EndDate, err = time.Parse(dateLayout, req.EndDate); err != nil {
+				writeError(w, ErrInvalidRequestBody{err})
+				return
+			}
+		}
+		closure.MealTypeIDs = req.MealTypeIDs
+		closure.Reason = req.Reason
+
+		saved, cancelled, err := s.service.Save(r.Context(), closure)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := response{closureResponse: newClosureResponse(*saved), CancelledReservations: make([]int64, 0, len(cancelled))}
+		for _, reservation := range cancelled {
+			resp.CancelledReservations = append(resp.CancelledReservations, reservation.ID)
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleListClosures() http.HandlerFunc {
+	type response []closureResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.List(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, newClosureResponse(v))
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleRemoveClosure() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericClosureID)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleImportClosures() http.HandlerFunc {
+	type result struct {
+		UID                   string  `json:"uid"`
+		Summary               string  `json:"summary"`
+		StartDate             string  `json:"start_date"`
+		EndDate               string  `json:"end_date,omitempty"`
+		Status                string  `json:"status"`
+		ClosureID             int64   `json:"closure_id,omitempty"`
+		CancelledReservations []int64 `json:"cancelled_reservations,omitempty"`
+		Note                  string  `json:"note,omitempty"`
+	}
+	type response struct {
+		DryRun  bool     `json:"dry_run"`
+		Results []result `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		dryRun := false
+		if v := query.Get("dry_run"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		from, to, err := ImportWindow(query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			writeError(w, ErrInvalidQuery)
+			return
+		}
+
+		results, err := s.service.Import(r.Context(), r.Body, from, to, dryRun)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := response{DryRun: dryRun, Results: make([]result, 0, len(results))}
+		for _, v := range results {
+			res := result{UID: v.UID, Summary: v.Summary, StartDate: v.StartDate.Format(dateLayout), Status: string(v.Status), ClosureID: v.ClosureID, Note: v.Note}
+			if !v.EndDate.IsZero() {
+				res.EndDate = v.EndDate.Format(dateLayout)
+			}
+			for _, reservation := range v.Cancelled {
+				res.CancelledReservations = append(res.CancelledReservations, reservation.ID)
+			}
+			resp.Results = append(resp.Results, res)
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ImportWindow parses the optional from and to dates of an import. The
+// window defaults to today through the end of next year.
+func ImportWindow(from, to string, now time.Time) (time.Time, time.Time, error) {
+	start, end := pkg.Date(now), time.Date(now.Year()+1, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if from != "" {
+		if start, err = time.Parse(dateLayout, from); err != nil {
+			return start, end, err
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(dateLayout, to); err != nil {
+			return start, end, err
+		}
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("to %s is before from %s", end.Format(dateLayout), start.Format(dateLayout))
+	}
+	return start, end, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrClosureNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrNonNumericClosureID, ErrInvalidQuery:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case pkg.ErrClosureAlreadyExists:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody, ErrInvalidCalendar:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
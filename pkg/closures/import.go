@@ -0,0 +1,114 @@
+package closures
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/ical"
+)
+
+type ImportStatus string
+
+const (
+	ImportCreated     ImportStatus = "created"
+	ImportExists      ImportStatus = "exists"
+	ImportWouldCreate ImportStatus = "would_create"
+	ImportSkipped     ImportStatus = "skipped"
+)
+
+// ImportResult reports what an import did, or in a dry run would do, with a
+// single occurrence of a calendar event.
+type ImportResult struct {
+	UID       string
+	Summary   string
+	StartDate time.Time
+	EndDate   time.Time
+	Status    ImportStatus
+	ClosureID int64
+	Cancelled []pkg.Reservation
+	// Note explains why an event was skipped.
+	Note string
+}
+
+type ErrInvalidCalendar struct{ err error }
+
+func (e ErrInvalidCalendar) Error() string { return fmt.Sprintf("invalid calendar: %v", e.err) }
+
+func (s *service) Import(ctx context.Context, calendar io.Reader, from, to time.Time, dryRun bool) ([]ImportResult, error) {
+	events, err := ical.Parse(calendar)
+	if err != nil {
+		return nil, ErrInvalidCalendar{err}
+	}
+
+	var results []ImportResult
+	for _, event := range events {
+		skip := func(note string) {
+			results = append(results, ImportResult{UID: event.UID, Summary: event.Summary, StartDate: event.Start, Status: ImportSkipped, Note: note})
+		}
+		switch {
+		case event.UID == "":
+			skip("event has no UID")
+			continue
+		case !event.AllDay:
+			skip("not an all-day event")
+			continue
+		case event.Status == "CANCELLED":
+			skip("event is cancelled")
+			continue
+		}
+
+		occurrences, err := event.Occurrences(pkg.Date(from), pkg.Date(to))
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		for _, occurrence := range occurrences {
+			result, err := s.importOccurrence(ctx, event, occurrence, dryRun)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// importOccurrence creates the closure for one occurrence unless an earlier
+// import already did, which is what makes re-importing a calendar safe.
+func (s *service) importOccurrence(ctx context.Context, event ical.Event, occurrence ical.Occurrence, dryRun bool) (ImportResult, error) {
+	result := ImportResult{UID: event.UID, Summary: event.Summary, StartDate: occurrence.First, EndDate: occurrence.Last}
+
+	existing, err := s.repository.FindBySource(ctx, event.UID, occurrence.First)
+	switch {
+	case err == nil:
+		result.Status, result.ClosureID = ImportExists, existing.ID
+		return result, nil
+	case err != pkg.ErrClosureNotFound:
+		return result, fmt.Errorf("could not import closure: %v", err)
+	case dryRun:
+		result.Status = ImportWouldCreate
+		return result, nil
+	}
+
+	reason := strings.TrimSpace(event.Summary)
+	if reason == "" {
+		reason = "Public holiday"
+	}
+	closure, cancelled, err := s.Save(ctx, pkg.Closure{StartDate: occurrence.First, EndDate: occurrence.Last, Reason: reason, SourceUID: event.UID})
+	if err == nil {
+		result.Status, result.ClosureID, result.Cancelled = ImportCreated, closure.ID, cancelled
+		return result, nil
+	}
+
+	// a concurrent import may have created it since the lookup above.
+	if existing, ferr := s.repository.FindBySource(ctx, event.UID, occurrence.First); ferr == nil {
+		result.Status, result.ClosureID = ImportExists, existing.ID
+		return result, nil
+	}
+	return result, err
+}
@@ -0,0 +1,67 @@
+package closures
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Save(ctx context.Context, closure pkg.Closure) (_ *pkg.Closure, cancelled []pkg.Reservation, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save",
+			"start_date", closure.StartDate,
+			"end_date", closure.EndDate,
+			"cancelled", len(cancelled),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Save(ctx, closure)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Closure, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list",
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Remove(ctx, id)
+}
+
+func (s *loggingMiddleware) Import(ctx context.Context, calendar io.Reader, from, to time.Time, dryRun bool) (results []ImportResult, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "import",
+			"from", from,
+			"to", to,
+			"dry_run", dryRun,
+			"results", len(results),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Import(ctx, calendar, from, to, dryRun)
+}
@@ -0,0 +1,100 @@
+package closures
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Save stores the closure and returns the reservations it cancelled.
+	Save(context.Context, pkg.Closure) (*pkg.Closure, []pkg.Reservation, error)
+	List(context.Context) ([]pkg.Closure, error)
+	Remove(context.Context, int64) error
+	// Import turns the all-day events of an iCalendar file that occur between
+	// from and to into closures. Occurrences imported before are left alone;
+	// a dry run reports what would be created without saving anything.
+	Import(ctx context.Context, calendar io.Reader, from, to time.Time, dryRun bool) ([]ImportResult, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository pkg.ClosureRepository
+	notifier   pkg.Notifier
+	payments   pkg.Payments
+	headcounts pkg.HeadcountPublisher
+}
+
+func NewService(repository pkg.ClosureRepository, notifier pkg.Notifier, payments pkg.Payments, headcounts pkg.HeadcountPublisher) Service {
+	return &service{repository: repository, notifier: notifier, payments: payments, headcounts: headcounts}
+}
+
+func (s *service) Save(ctx context.Context, closure pkg.Closure) (*pkg.Closure, []pkg.Reservation, error) {
+	if closure.EndDate.IsZero() {
+		closure.EndDate = closure.StartDate
+	}
+	if err := validate(closure); err != nil {
+		return nil, nil, err
+	}
+
+	cancelled, err := s.repository.Insert(ctx, &closure)
+	if err == pkg.ErrClosureAlreadyExists {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not save closure: %v", err)
+	}
+
+	// the closure is committed at this point, so a failed notification is
+	// left to the notifier's own logging rather than failing the request,
+	// and a hold that is not released is left to wallets.SettleHolds.
+	dates := make([]time.Time, 0, len(cancelled))
+	for _, reservation := range cancelled {
+		dates = append(dates, reservation.ServiceDate)
+		s.payments.Release(ctx, reservation.ID)
+		s.notifier.Notify(ctx, pkg.Notification{
+			UserID:  reservation.UserID,
+			Subject: "Your reservation was cancelled",
+			Body:    fmt.Sprintf("Your reservation for %s was cancelled because the mess is closed: %s", reservation.ServiceDate.Format("Mon Jan 2 2006"), closure.Reason),
+		})
+	}
+	s.headcounts.PublishHeadcount(ctx, dates...)
+	return &closure, cancelled, nil
+}
+
+func (s *service) List(ctx context.Context) ([]pkg.Closure, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all closures: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Remove(ctx context.Context, id int64) error {
+	if err := s.repository.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrClosureNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove closure: %v", err)
+	}
+	return nil
+}
+
+func validate(closure pkg.Closure) error {
+	var verr pkg.ValidationError
+	if closure.StartDate.IsZero() {
+		verr.Add("start_date", "required", "start_date is required")
+	} else if pkg.Date(closure.EndDate).Before(pkg.Date(closure.StartDate)) {
+		verr.Add("end_date", "before_start", "end_date must not be before start_date")
+	}
+	if strings.TrimSpace(closure.Reason) == "" {
+		verr.Add("reason", "required", "reason is required")
+	}
+	return verr.Err()
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrAllergenNotFound      = errors.New("allergen not found")
+	ErrAllergenAlreadyExists = errors.New("allergen already exists")
+	ErrAllergenInUse         = errors.New("allergen is referenced by users or reservations")
+)
+
+type Diet string
+
+const (
+	DietOmnivore    Diet = "omnivore"
+	DietPescatarian Diet = "pescatarian"
+	DietVegetarian  Diet = "vegetarian"
+	DietVegan       Diet = "vegan"
+)
+
+var Diets = []Diet{DietOmnivore, DietPescatarian, DietVegetarian, DietVegan}
+
+// ParseDiet accepts one of Diets. An empty string is an omnivore.
+func ParseDiet(s string) (Diet, error) {
+	if s == "" {
+		return DietOmnivore, nil
+	}
+	for _, d := range Diets {
+		if Diet(s) == d {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("unknown diet %q", s)
+}
+
+// DietaryProfile is what the kitchen needs to know about who eats a meal.
+// Allergens holds allergen codes.
+type DietaryProfile struct {
+	Diet      Diet
+	Allergens []string
+}
+
+// Allergen is an entry of the managed allergen list, identified by its code.
+type Allergen struct {
+	Code string
+	Name string
+}
+
+type AllergenRepository interface {
+	Insert(context.Context, *Allergen) error
+	FindAll(context.Context) ([]Allergen, error)
+	DeleteByCode(context.Context, string) error
+}
+
+// Validate adds a field error to verr for an unknown diet and for allergens
+// that are repeated or missing from known. Fields are named after prefix.
+func (p DietaryProfile) Validate(verr *ValidationError, prefix string, known []Allergen) {
+	if _, err := ParseDiet(string(p.Diet)); err != nil || p.Diet == "" {
+		verr.Add(prefix+"diet", "unknown", fmt.Sprintf("diet must be one of %v", Diets))
+	}
+
+	codes := make(map[string]bool, len(known))
+	for _, a := range known {
+		codes[a.Code] = true
+	}
+	seen := make(map[string]bool, len(p.Allergens))
+	for _, code := range p.Allergens {
+		switch {
+		case seen[code]:
+			verr.Add(prefix+"allergens", "duplicate", fmt.Sprintf("allergen %q is listed twice", code))
+		case !codes[code]:
+			verr.Add(prefix+"allergens", "unknown", fmt.Sprintf("allergen %q does not exist", code))
+		}
+		seen[code] = true
+	}
+}
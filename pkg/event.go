@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventReservationCreated   EventType = "reservation.created"
+	EventReservationUpdated   EventType = "reservation.updated"
+	EventReservationCancelled EventType = "reservation.cancelled"
+	EventReservationCheckedIn EventType = "reservation.checked_in"
+	EventUserCreated          EventType = "user.created"
+	EventUserUpdated          EventType = "user.updated"
+	EventUserRemoved          EventType = "user.removed"
+)
+
+var EventTypes = []EventType{EventReservationCreated, EventReservationUpdated, EventReservationCancelled, EventReservationCheckedIn, EventUserCreated, EventUserUpdated, EventUserRemoved}
+
+// Aggregates are what events are about. The events of one aggregate are
+// published in the order they occurred.
+const (
+	AggregateReservation = "reservation"
+	AggregateUser        = "user"
+)
+
+// Event is something other systems may want to react to. Data is the JSON
+// of the reservation or user named by AggregateType and AggregateID, as it
+// was right after the event.
+type Event struct {
+	ID            string
+	Type          EventType
+	AggregateType string
+	AggregateID   int64
+	OccurredAt    time.Time
+	Data          json.RawMessage
+}
+
+// MarshalJSON gives the envelope events are handed out in.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID         string          `json:"id"`
+		Type       EventType       `json:"type"`
+		OccurredAt time.Time       `json:"occurred_at"`
+		Data       json.RawMessage `json:"data"`
+	}{e.ID, e.Type, e.OccurredAt.UTC(), e.Data})
+}
+
+func NewReservationEvent(eventType EventType, r Reservation) Event {
+	return newEvent(eventType, AggregateReservation, r.ID, struct {
+		ID              int64      `json:"id"`
+		UserID          int64      `json:"user_id"`
+		ReservationTime time.Time  `json:"reservation_time"`
+		ServiceDate     string     `json:"service_date"`
+		SiteID          int64      `json:"site_id"`
+		MealTypeID      int64      `json:"type"`
+		NoOfGuests      int64      `json:"no_of_guests"`
+		Status          string     `json:"status"`
+		CheckedInAt     *time.Time `json:"checked_in_at"`
+		CreatedAt       time.Time  `json:"createdAt"`
+		Version         int64      `json:"version"`
+	}{r.ID, r.UserID, r.ReservationTime, r.ServiceDate.Format("2006-01-02"), r.SiteID, r.MealTypeID, r.NoOfGuests, string(r.Status), r.CheckedInAt, r.CreatedAt, r.Version})
+}
+
+// NewUserEvent leaves the password out of the event.
+func NewUserEvent(eventType EventType, u User) Event {
+	return newEvent(eventType, AggregateUser, u.ID, struct {
+		ID          int64     `json:"id"`
+		Name        string    `json:"name"`
+		Designation string    `json:"designation"`
+		Department  string    `json:"department"`
+		EmployeeID  string    `json:"employeeID"`
+		CreatedAt   time.Time `json:"createdAt"`
+		Version     int64     `json:"version"`
+	}{u.ID, u.Name, u.Designation, u.Department, u.EmployeeID, u.CreatedAt, u.Version})
+}
+
+// newEvent stamps an event with a new random ID and the current time.
+func newEvent(eventType EventType, aggregateType string, aggregateID int64, data interface{}) Event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	// data only holds plain values, which always encode.
+	encoded, _ := json.Marshal(data)
+	return Event{ID: hex.EncodeToString(id), Type: eventType, AggregateType: aggregateType, AggregateID: aggregateID, OccurredAt: time.Now(), Data: encoded}
+}
+
+// EventPublisher passes events on to whoever subscribed to them. It is what
+// the outbox relay publishes stored events to, and gets every event at least
+// once.
+type EventPublisher interface {
+	Publish(context.Context, Event) error
+}
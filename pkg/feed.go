@@ -0,0 +1,17 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarFeedRepository keeps the token of each user's calendar feed. Only
+// a hash of the token is stored.
+type CalendarFeedRepository interface {
+	// Save replaces the user's feed token, if they had one.
+	Save(ctx context.Context, userID int64, tokenHash string) error
+	FindUserByTokenHash(ctx context.Context, tokenHash string) (int64, error)
+	Delete(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,250 @@
+// Package ical reads the subset of RFC 5545 iCalendar needed to import
+// holiday calendars: all-day VEVENTs, optionally repeating every year. It
+// also writes plain VEVENTs for calendar feeds.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+var ErrUnsupportedRule = errors.New("unsupported recurrence rule")
+
+// Event is a VEVENT. For all-day events End is exclusive, as in DTEND.
+// Description and Sequence are only written, never parsed.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string
+	RRule       string
+	// ExDates are the days EXDATE takes out of the recurrence.
+	ExDates  []time.Time
+	Sequence int64
+}
+
+// Occurrence is a single all-day span of an event, with an inclusive Last day.
+type Occurrence struct {
+	First time.Time
+	Last  time.Time
+}
+
+// Parse reads every VEVENT of a calendar. Properties the importer does not
+// use are ignored.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var event *Event
+	for n, line := range lines {
+		name, params, value, err := splitProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &Event{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", n+1)
+			}
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("event %q has no DTSTART", event.UID)
+			}
+			if event.End.IsZero() {
+				// an all-day event without DTEND lasts one day.
+				event.End = event.Start.AddDate(0, 0, 1)
+			}
+			events = append(events, *event)
+			event = nil
+		case event == nil:
+			continue
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescape(value)
+		case name == "STATUS":
+			event.Status = strings.ToUpper(value)
+		case name == "RRULE":
+			event.RRule = value
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, _, err := parseTime(params, v)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", n+1, err)
+				}
+				event.ExDates = append(event.ExDates, t)
+			}
+		case name == "DTSTART", name == "DTEND":
+			t, allDay, err := parseTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			if name == "DTSTART" {
+				event.Start, event.AllDay = t, allDay
+			} else {
+				event.End = t
+			}
+		}
+	}
+	if event != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// Occurrences expands the event into the all-day spans that start between
+// from and to, both inclusive. Only FREQ=YEARLY with INTERVAL, COUNT and
+// UNTIL is supported, which covers fixed-date public holidays. As in RFC
+// 5545, the years a February 29th event skips do not count towards COUNT,
+// while the days EXDATE takes out do.
+func (e Event) Occurrences(from, to time.Time) ([]Occurrence, error) {
+	days := int(e.End.Sub(e.Start).Hours()/24+0.5) - 1
+	if days < 0 {
+		days = 0
+	}
+	span := func(start time.Time) Occurrence { return Occurrence{First: start, Last: start.AddDate(0, 0, days)} }
+	within := func(t time.Time) bool { return !t.Before(from) && !t.After(to) }
+
+	if e.RRule == "" {
+		if within(e.Start) {
+			return []Occurrence{span(e.Start)}, nil
+		}
+		return nil, nil
+	}
+
+	interval, count := 1, -1
+	var until time.Time
+	for _, part := range strings.Split(e.RRule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if !strings.EqualFold(value, "YEARLY") {
+				return nil, fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRule, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL=%s", ErrUnsupportedRule, value)
+			}
+			interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%w: COUNT=%s", ErrUnsupportedRule, value)
+			}
+			count = n
+		case "UNTIL":
+			t, err := time.Parse(dateLayout, truncate(value, len(dateLayout)))
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL=%s", ErrUnsupportedRule, value)
+			}
+			until = t
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRule, part)
+		}
+	}
+
+	var occurrences []Occurrence
+	generated := 0
+	for n := 0; count < 0 || generated < count; n++ {
+		start := e.Start.AddDate(n*interval, 0, 0)
+		if start.After(to) || (!until.IsZero() && start.After(until)) {
+			break
+		}
+		// february 29th only recurs in leap years.
+		if start.Day() != e.Start.Day() {
+			continue
+		}
+		generated++
+		if within(start) && !e.excluded(start) {
+			occurrences = append(occurrences, span(start))
+		}
+	}
+	return occurrences, nil
+}
+
+func (e Event) excluded(start time.Time) bool {
+	for _, exdate := range e.ExDates {
+		if exdate.Year() == start.Year() && exdate.YearDay() == start.YearDay() {
+			return true
+		}
+	}
+	return false
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits "NAME;PARAM=x:value" into its parts. Colons inside
+// quoted parameter values do not end the parameters.
+func splitProperty(line string) (name string, params map[string]string, value string, err error) {
+	inQuotes := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == ':' && !inQuotes:
+			head := strings.Split(line[:i], ";")
+			params = make(map[string]string, len(head)-1)
+			for _, p := range head[1:] {
+				k, v, _ := strings.Cut(p, "=")
+				params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+			}
+			return strings.ToUpper(head[0]), params, line[i+1:], nil
+		}
+	}
+	return "", nil, "", fmt.Errorf("malformed content line %q", line)
+}
+
+// parseTime reads a DATE or DATE-TIME value. Date-times keep their calendar
+// date only, since the importer works with whole days.
+func parseTime(params map[string]string, value string) (time.Time, bool, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, value)
+		return t, true, err
+	}
+	t, err := time.Parse("20060102T150405", strings.TrimSuffix(value, "Z"))
+	return t, false, err
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// truncate cuts s down to its first n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,227 @@
+package ical
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:new-year@example.com",
+		"SUMMARY:New Year\\, observed",
+		"DTSTART;VALUE=DATE:20260101",
+		"RRULE:FREQ=YEARLY;COUNT=3",
+		"EXDATE;VALUE=DATE:20270101,20280101",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:retreat@example.com",
+		"SUMMARY:Staff retreat over the lo",
+		" ng weekend",
+		"DTSTART:20260605T090000Z",
+		"DTEND:20260608T170000Z",
+		"STATUS:cancelled",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Parse(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Event{
+		{
+			UID:     "new-year@example.com",
+			Summary: "New Year, observed",
+			Start:   date("2026-01-01"),
+			End:     date("2026-01-02"),
+			AllDay:  true,
+			RRule:   "FREQ=YEARLY;COUNT=3",
+			ExDates: []time.Time{date("2027-01-01"), date("2028-01-01")},
+		},
+		{
+			UID:     "retreat@example.com",
+			Summary: "Staff retreat over the long weekend",
+			Start:   time.Date(2026, 6, 5, 9, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 6, 8, 17, 0, 0, 0, time.UTC),
+			Status:  "CANCELLED",
+		},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Parse() = %+v, want %+v", events, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar string
+	}{
+		{"no dtstart", "BEGIN:VEVENT\nUID:a\nEND:VEVENT"},
+		{"unterminated", "BEGIN:VEVENT\nDTSTART:20260101"},
+		{"end without begin", "END:VEVENT"},
+		{"malformed line", "BEGIN:VEVENT\nDTSTART 20260101\nEND:VEVENT"},
+		{"bad date", "BEGIN:VEVENT\nDTSTART;VALUE=DATE:2026-01-01\nEND:VEVENT"},
+		{"bad exdate", "BEGIN:VEVENT\nDTSTART:20260101\nEXDATE:20270101,tomorrow\nEND:VEVENT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.calendar)); err == nil {
+				t.Errorf("Parse() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  Event
+		from   string
+		to     string
+		starts []string
+	}{
+		{
+			name:   "single event within range",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02")},
+			from:   "2026-01-01",
+			to:     "2026-12-31",
+			starts: []string{"2026-05-01"},
+		},
+		{
+			name:  "single event outside range",
+			event: Event{Start: date("2025-05-01"), End: date("2025-05-02")},
+			from:  "2026-01-01",
+			to:    "2026-12-31",
+		},
+		{
+			name:   "yearly without end stops at to",
+			event:  Event{Start: date("2020-12-25"), End: date("2020-12-26"), RRule: "FREQ=YEARLY"},
+			from:   "2024-01-01",
+			to:     "2026-12-31",
+			starts: []string{"2024-12-25", "2025-12-25", "2026-12-25"},
+		},
+		{
+			name:   "count",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY;COUNT=2"},
+			from:   "2026-01-01",
+			to:     "2030-12-31",
+			starts: []string{"2026-05-01", "2027-05-01"},
+		},
+		{
+			name:   "count includes occurrences before from",
+			event:  Event{Start: date("2024-05-01"), End: date("2024-05-02"), RRule: "FREQ=YEARLY;COUNT=3"},
+			from:   "2026-01-01",
+			to:     "2030-12-31",
+			starts: []string{"2026-05-01"},
+		},
+		{
+			name:   "until is inclusive",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY;UNTIL=20280501T000000Z"},
+			from:   "2026-01-01",
+			to:     "2030-12-31",
+			starts: []string{"2026-05-01", "2027-05-01", "2028-05-01"},
+		},
+		{
+			name:   "interval",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY;INTERVAL=2;COUNT=3"},
+			from:   "2026-01-01",
+			to:     "2035-12-31",
+			starts: []string{"2026-05-01", "2028-05-01", "2030-05-01"},
+		},
+		{
+			name:   "exdate takes out a year",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY", ExDates: []time.Time{date("2027-05-01")}},
+			from:   "2026-01-01",
+			to:     "2028-12-31",
+			starts: []string{"2026-05-01", "2028-05-01"},
+		},
+		{
+			name:   "exdate counts towards count",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY;COUNT=3", ExDates: []time.Time{date("2027-05-01")}},
+			from:   "2026-01-01",
+			to:     "2035-12-31",
+			starts: []string{"2026-05-01", "2028-05-01"},
+		},
+		{
+			name:   "exdate on another day leaves the year",
+			event:  Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: "FREQ=YEARLY;COUNT=2", ExDates: []time.Time{date("2027-05-02")}},
+			from:   "2026-01-01",
+			to:     "2035-12-31",
+			starts: []string{"2026-05-01", "2027-05-01"},
+		},
+		{
+			name:   "february 29th only in leap years",
+			event:  Event{Start: date("2024-02-29"), End: date("2024-03-01"), RRule: "FREQ=YEARLY"},
+			from:   "2024-01-01",
+			to:     "2032-12-31",
+			starts: []string{"2024-02-29", "2028-02-29", "2032-02-29"},
+		},
+		{
+			name:   "february 29th skipped years do not count",
+			event:  Event{Start: date("2024-02-29"), End: date("2024-03-01"), RRule: "FREQ=YEARLY;COUNT=3"},
+			from:   "2024-01-01",
+			to:     "2040-12-31",
+			starts: []string{"2024-02-29", "2028-02-29", "2032-02-29"},
+		},
+		{
+			name:   "february 29th until",
+			event:  Event{Start: date("2024-02-29"), End: date("2024-03-01"), RRule: "FREQ=YEARLY;UNTIL=20310101"},
+			from:   "2024-01-01",
+			to:     "2040-12-31",
+			starts: []string{"2024-02-29", "2028-02-29"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrences, err := tt.event.Occurrences(date(tt.from), date(tt.to))
+			if err != nil {
+				t.Fatalf("Occurrences() error = %v", err)
+			}
+			var starts []string
+			for _, occurrence := range occurrences {
+				starts = append(starts, occurrence.First.Format("2006-01-02"))
+			}
+			if !reflect.DeepEqual(starts, tt.starts) {
+				t.Errorf("Occurrences() start on %v, want %v", starts, tt.starts)
+			}
+		})
+	}
+}
+
+func TestOccurrencesSpanDays(t *testing.T) {
+	event := Event{Start: date("2026-12-24"), End: date("2026-12-27"), RRule: "FREQ=YEARLY;COUNT=2"}
+	occurrences, err := event.Occurrences(date("2026-01-01"), date("2030-12-31"))
+	if err != nil {
+		t.Fatalf("Occurrences() error = %v", err)
+	}
+	want := []Occurrence{
+		{First: date("2026-12-24"), Last: date("2026-12-26")},
+		{First: date("2027-12-24"), Last: date("2027-12-26")},
+	}
+	if !reflect.DeepEqual(occurrences, want) {
+		t.Errorf("Occurrences() = %v, want %v", occurrences, want)
+	}
+}
+
+func TestOccurrencesUnsupportedRule(t *testing.T) {
+	for _, rule := range []string{"FREQ=MONTHLY", "FREQ=YEARLY;BYMONTH=5", "FREQ=YEARLY;INTERVAL=0", "FREQ=YEARLY;COUNT=x", "FREQ=YEARLY;UNTIL=soon"} {
+		event := Event{Start: date("2026-05-01"), End: date("2026-05-02"), RRule: rule}
+		if _, err := event.Occurrences(date("2026-01-01"), date("2030-12-31")); !errors.Is(err, ErrUnsupportedRule) {
+			t.Errorf("Occurrences() with %s error = %v, want %v", rule, err, ErrUnsupportedRule)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotentRequest is a request made with an idempotency key and, once it
+// completed, the response that retries of it are given.
+type IdempotentRequest struct {
+	// KeyHash identifies the key along with the caller and route it was
+	// used on, and Fingerprint the request itself.
+	KeyHash     string
+	Fingerprint string
+	// Owner is a token only the request that stored it knows, which it has
+	// to present to renew, complete or delete it.
+	Owner string
+	// StatusCode is zero while the request is in flight.
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// InFlight reports whether the request has not completed yet.
+func (r IdempotentRequest) InFlight() bool { return r.StatusCode == 0 }
+
+type IdempotencyRepository interface {
+	// Begin stores request as in flight, unless a request with the same key
+	// is stored and has not expired, in which case that one is returned.
+	Begin(context.Context, IdempotentRequest) (*IdempotentRequest, error)
+	// Renew extends the lease of a request that is still in flight.
+	Renew(ctx context.Context, keyHash, owner string, expiresAt time.Time) error
+	// Complete stores the response to a request that was in flight.
+	Complete(context.Context, IdempotentRequest) error
+	// Delete forgets a request, so that its key can be used again.
+	Delete(ctx context.Context, keyHash, owner string) error
+	// DeleteExpired removes up to limit requests that expired before before
+	// and returns how many it removed.
+	DeleteExpired(ctx context.Context, before time.Time, limit int64) (int64, error)
+}
@@ -0,0 +1,261 @@
+// Package idempotency makes POST requests safe to retry. A request carrying
+// an Idempotency-Key header is handled once, and retries of it with the same
+// key are given the response it got.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+const (
+	HeaderKey = "Idempotency-Key"
+	// ReplayedKey is set on responses that are replayed from an earlier
+	// request rather than handled again.
+	ReplayedKey = "Idempotent-Replayed"
+	// userIDKey is the header the gateway names the caller in, so that the
+	// same key used by two callers does not clash.
+	userIDKey    = "X-User-ID"
+	maxKeyLength = 255
+	// lease is how long a request holds on to its key while in flight. It
+	// is renewed while the request is handled, so only a request that never
+	// completes, as its server went away, gives its key up after that.
+	lease        = time.Minute
+	cleanupBatch = 1000
+
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrKeyTooLong  = fmt.Errorf("%s must be at most %d characters", HeaderKey, maxKeyLength)
+	ErrKeyReused   = fmt.Errorf("%s was already used for a different request", HeaderKey)
+	ErrKeyInFlight = fmt.Errorf("a request with the same %s is still in progress", HeaderKey)
+	ErrUnreadable  = errors.New("could not read request body")
+)
+
+// Middleware handles the POST requests with an Idempotency-Key header once
+// and replays their responses to retries for ttl. A retry with a different
+// body is rejected, as is one made while the request is still in flight.
+// Server errors are not stored, so the request can be retried.
+func Middleware(repository pkg.IdempotencyRepository, ttl time.Duration, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				writeError(w, http.StatusBadRequest, ErrKeyTooLong)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, ErrUnreadable)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			owner, err := token()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("could not check %s: %v", HeaderKey, err))
+				return
+			}
+			now := time.Now()
+			request := pkg.IdempotentRequest{
+				KeyHash:     hash(r.Header.Get(userIDKey), r.URL.Path, key),
+				Fingerprint: hash(r.URL.RequestURI(), string(body)),
+				Owner:       owner,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(lease),
+			}
+			stored, err := repository.Begin(r.Context(), request)
+			if err != nil {
+				logger.Log("msg", "could not store idempotency key", "err", err)
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("could not check %s: %v", HeaderKey, err))
+				return
+			}
+			if stored != nil {
+				replay(w, request, *stored)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w}
+			stop := renew(repository, request, logger)
+			handled := false
+			defer func() {
+				// a handler that panicked gives its key up, so that the
+				// request can be retried once the panic is dealt with.
+				if !handled {
+					stop()
+					release(repository, request, logger)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			handled = true
+			stop()
+
+			if rec.status() >= http.StatusInternalServerError {
+				release(repository, request, logger)
+				return
+			}
+			request.StatusCode = rec.status()
+			request.Header = w.Header().Clone()
+			request.Body = rec.body.Bytes()
+			request.ExpiresAt = time.Now().Add(ttl)
+			// the request context may be gone by now, while the response
+			// should be stored regardless.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := repository.Complete(ctx, request); err != nil {
+				logger.Log("msg", "could not store idempotent response", "err", err)
+			}
+		})
+	}
+}
+
+// release forgets request so that its key can be used again. Like the
+// response, it does not depend on the request context still being there.
+func release(repository pkg.IdempotencyRepository, request pkg.IdempotentRequest, logger log.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repository.Delete(ctx, request.KeyHash, request.Owner); err != nil {
+		logger.Log("msg", "could not release idempotency key", "err", err)
+	}
+}
+
+// renew extends the lease of request every third of it until the returned
+// func is called, so that a slow handler keeps its key.
+func renew(repository pkg.IdempotencyRepository, request pkg.IdempotentRequest, logger log.Logger) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), lease/3)
+			err := repository.Renew(ctx, request.KeyHash, request.Owner, time.Now().Add(lease))
+			cancel()
+			if err != nil {
+				logger.Log("msg", "could not renew idempotency key", "err", err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Run removes expired keys every interval until ctx is done.
+func Run(ctx context.Context, repository pkg.IdempotencyRepository, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := repository.DeleteExpired(ctx, time.Now(), cleanupBatch)
+			if err != nil && ctx.Err() == nil {
+				report(fmt.Errorf("could not remove expired idempotency keys: %v", err))
+			}
+			if err != nil || n < cleanupBatch {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, request, stored pkg.IdempotentRequest) {
+	if stored.Fingerprint != request.Fingerprint {
+		writeError(w, http.StatusUnprocessableEntity, ErrKeyReused)
+		return
+	}
+	if stored.InFlight() {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusConflict, ErrKeyInFlight)
+		return
+	}
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedKey, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// token returns a random token for a request to own its key with.
+func token() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hash digests parts, each prefixed with its length so that no two lists
+// of parts digest alike.
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
+
+// recorder keeps a copy of the response it writes.
+type recorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.statusCode == 0 {
+		r.statusCode = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) status() int {
+	if r.statusCode == 0 {
+		return http.StatusOK
+	}
+	return r.statusCode
+}
@@ -0,0 +1,215 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// memoryRepository keeps idempotent requests in memory.
+type memoryRepository struct {
+	mu       sync.Mutex
+	requests map[string]pkg.IdempotentRequest
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{requests: make(map[string]pkg.IdempotentRequest)}
+}
+
+func (m *memoryRepository) Begin(_ context.Context, request pkg.IdempotentRequest) (*pkg.IdempotentRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.requests[request.KeyHash]; ok && stored.ExpiresAt.After(time.Now()) {
+		return &stored, nil
+	}
+	m.requests[request.KeyHash] = request
+	return nil, nil
+}
+
+func (m *memoryRepository) Renew(_ context.Context, keyHash, owner string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.requests[keyHash]; ok && stored.Owner == owner && stored.InFlight() {
+		stored.ExpiresAt = expiresAt
+		m.requests[keyHash] = stored
+	}
+	return nil
+}
+
+func (m *memoryRepository) Complete(_ context.Context, request pkg.IdempotentRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.requests[request.KeyHash]; ok && stored.Owner == request.Owner {
+		m.requests[request.KeyHash] = request
+	}
+	return nil
+}
+
+func (m *memoryRepository) Delete(_ context.Context, keyHash, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.requests[keyHash]; ok && stored.Owner == owner {
+		delete(m.requests, keyHash)
+	}
+	return nil
+}
+
+func (m *memoryRepository) DeleteExpired(_ context.Context, before time.Time, limit int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for keyHash, stored := range m.requests {
+		if n < limit && stored.ExpiresAt.Before(before) {
+			delete(m.requests, keyHash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryRepository) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+// counter is a handler that counts the requests it gets and answers them
+// with status.
+type counter struct {
+	mu     sync.Mutex
+	calls  int
+	status int
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	c.calls++
+	calls := c.calls
+	c.mu.Unlock()
+	w.Header().Set("X-Call", strings.Repeat("i", calls))
+	w.WriteHeader(c.status)
+	w.Write([]byte(`{"id":7}`))
+}
+
+func post(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/resvlist/v1/reservation", strings.NewReader(body))
+	req.Header.Set(HeaderKey, key)
+	req.Header.Set(userIDKey, "7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReplay(t *testing.T) {
+	next := &counter{status: http.StatusCreated}
+	handler := Middleware(newMemoryRepository(), time.Hour, log.NewNopLogger())(next)
+
+	first := post(handler, "abc", `{"type":1}`)
+	second := post(handler, "abc", `{"type":1}`)
+
+	if next.calls != 1 {
+		t.Errorf("handler called %d times, want 1", next.calls)
+	}
+	if first.Header().Get(ReplayedKey) != "" {
+		t.Errorf("first response has %s set", ReplayedKey)
+	}
+	if got := second.Header().Get(ReplayedKey); got != "true" {
+		t.Errorf("%s = %q, want %q", ReplayedKey, got, "true")
+	}
+	if second.Code != http.StatusCreated {
+		t.Errorf("replayed status = %d, want %d", second.Code, http.StatusCreated)
+	}
+	if got := second.Header().Get("X-Call"); got != "i" {
+		t.Errorf("replayed X-Call = %q, want %q", got, "i")
+	}
+	if got := second.Body.String(); got != `{"id":7}` {
+		t.Errorf("replayed body = %s, want %s", got, `{"id":7}`)
+	}
+}
+
+func TestKeyReusedForDifferentRequest(t *testing.T) {
+	next := &counter{status: http.StatusCreated}
+	handler := Middleware(newMemoryRepository(), time.Hour, log.NewNopLogger())(next)
+
+	post(handler, "abc", `{"type":1}`)
+	rec := post(handler, "abc", `{"type":2}`)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if next.calls != 1 {
+		t.Errorf("handler called %d times, want 1", next.calls)
+	}
+}
+
+func TestKeyInFlight(t *testing.T) {
+	entered, proceed := make(chan struct{}), make(chan struct{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-proceed
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := Middleware(newMemoryRepository(), time.Hour, log.NewNopLogger())(next)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(handler, "abc", `{"type":1}`) }()
+	<-entered
+	rec := post(handler, "abc", `{"type":1}`)
+	close(proceed)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if got := rec.Header().Get("Retry-After"); got == "" {
+		t.Errorf("Retry-After is not set")
+	}
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("status of the request in flight = %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestServerErrorIsNotStored(t *testing.T) {
+	next := &counter{status: http.StatusInternalServerError}
+	repository := newMemoryRepository()
+	handler := Middleware(repository, time.Hour, log.NewNopLogger())(next)
+
+	post(handler, "abc", `{"type":1}`)
+	if n := repository.len(); n != 0 {
+		t.Errorf("%d keys stored after a server error, want 0", n)
+	}
+	next.status = http.StatusCreated
+	rec := post(handler, "abc", `{"type":1}`)
+
+	if next.calls != 2 {
+		t.Errorf("handler called %d times, want 2", next.calls)
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get(ReplayedKey) != "" {
+		t.Errorf("retry got status %d replayed %q, want it handled again", rec.Code, rec.Header().Get(ReplayedKey))
+	}
+}
+
+func TestPanicReleasesKey(t *testing.T) {
+	repository := newMemoryRepository()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	handler := Middleware(repository, time.Hour, log.NewNopLogger())(next)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("panic did not reach the caller")
+			}
+		}()
+		post(handler, "abc", `{"type":1}`)
+	}()
+
+	if n := repository.len(); n != 0 {
+		t.Errorf("%d keys stored after a panic, want 0", n)
+	}
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMealTypeNotFound      = errors.New("meal type not found")
+	ErrMealTypeAlreadyExists = errors.New("meal type already exists")
+	ErrMealTypeInUse         = errors.New("meal type is referenced by reservations, menus or prices")
+	ErrUnknownMealType       = errors.New("unknown meal type")
+	ErrMealTypeNotServed     = errors.New("meal type is not served on that day")
+)
+
+// TimeOfDay is a wall clock time expressed in minutes since midnight.
+type TimeOfDay int64
+
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return TimeOfDay(t.Hour()*60 + t.Minute()), nil
+}
+
+func (t TimeOfDay) String() string { return fmt.Sprintf("%02d:%02d", t/60, t%60) }
+
+// On returns the instant t falls on during the calendar day of date.
+func (t TimeOfDay) On(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, int(t/60), int(t%60), 0, 0, date.Location())
+}
+
+// Weekdays is a set of days of the week, one bit per time.Weekday.
+type Weekdays int64
+
+const AllWeekdays Weekdays = 1<<7 - 1
+
+func ParseWeekdays(names []string) (Weekdays, error) {
+	var days Weekdays
+	for _, name := range names {
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(name, day.String()) {
+				days |= 1 << uint(day)
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid weekday %q", name)
+		}
+	}
+	return days, nil
+}
+
+func (d Weekdays) Has(day time.Weekday) bool { return d&(1<<uint(day)) != 0 }
+
+func (d Weekdays) Names() []string {
+	names := make([]string, 0, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if d.Has(day) {
+			names = append(names, strings.ToLower(day.String()))
+		}
+	}
+	return names
+}
+
+type MealType struct {
+	ID              int64
+	Code            string
+	Name            string
+	ServingStart    TimeOfDay
+	ServingEnd      TimeOfDay
+	ActiveDays      Weekdays
+	DefaultCapacity int64
+	Active          bool
+	// SiteID is the only site serving the meal, nil when every site does.
+	SiteID    *int64
+	CreatedAt time.Time
+}
+
+// ServedOn reports whether the meal can be booked for the given day.
+func (m MealType) ServedOn(day time.Weekday) bool { return m.Active && m.ActiveDays.Has(day) }
+
+// ServedAt reports whether the meal is served at a site.
+func (m MealType) ServedAt(siteID int64) bool { return m.SiteID == nil || *m.SiteID == siteID }
+
+// CapacityAt is the number of plates the meal takes at site, its own
+// capacity or else that of the site. Zero means there is no limit.
+func (m MealType) CapacityAt(site Site) int64 {
+	if m.DefaultCapacity > 0 {
+		return m.DefaultCapacity
+	}
+	return site.DefaultCapacity
+}
+
+type MealTypeRepository interface {
+	Insert(context.Context, *MealType) error
+	FindAll(context.Context) ([]MealType, error)
+	FindByID(context.Context, int64) (*MealType, error)
+	Update(context.Context, *MealType) error
+	DeleteByID(context.Context, int64) error
+}
@@ -0,0 +1,274 @@
+package mealtypes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSaveMealType http.Handler
+	handleSaveMealType = s.handleSaveMealType()
+	handleSaveMealType = httpLoggingMiddleware(logger, "handleSaveMealType")(handleSaveMealType)
+
+	var handleListMealTypes http.Handler
+	handleListMealTypes = s.handleListMealTypes()
+	handleListMealTypes = httpLoggingMiddleware(logger, "handleListMealTypes")(handleListMealTypes)
+
+	var handleRemoveMealType http.Handler
+	handleRemoveMealType = s.handleRemoveMealType()
+	handleRemoveMealType = httpLoggingMiddleware(logger, "handleRemoveMealType")(handleRemoveMealType)
+
+	var handleUpdateMealType http.Handler
+	handleUpdateMealType = s.handleUpdateMealType()
+	handleUpdateMealType = httpLoggingMiddleware(logger, "handleUpdateMealType")(handleUpdateMealType)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/mealtypes/v1/mealtypes", handleSaveMealType)
+	router.Handle("GET", "/mealtypes/v1/mealtypes", handleListMealTypes)
+	router.Handle("DELETE", "/mealtypes/v1/mealtype/:id", handleRemoveMealType)
+	router.Handle("PUT", "/mealtypes/v1/mealtype/:id", handleUpdateMealType)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrNonNumericMealTypeID = errors.New("meal type id in path must be numberic")
+	ErrInvalidQuery         = errors.New("invalid query parameter")
+	ErrResourceNotFound     = errors.New("resource not found")
+	ErrMethodNotAllowed     = errors.New("method not allowed")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type mealTypeRequest struct {
+	Code            string   `json:"code"`
+	Name            string   `json:"name"`
+	ServingStart    string   `json:"serving_start"`
+	ServingEnd      string   `json:"serving_end"`
+	ActiveDays      []string `json:"active_days"`
+	DefaultCapacity int64    `json:"default_capacity"`
+	Active          *bool    `json:"active"`
+	SiteID          *int64   `json:"site_id"`
+}
+
+// mealType converts the request into a meal type. A window whose end is before
+// its start crosses midnight, and active defaults to true when omitted. A meal
+// type without a site is served at every site.
+func (req mealTypeRequest) mealType(id int64) (pkg.MealType, error) {
+	if req.Code == "" {
+		return pkg.MealType{}, errors.New("code is required")
+	}
+	start, err := pkg.ParseTimeOfDay(req.ServingStart)
+	if err != nil {
+		return pkg.MealType{}, err
+	}
+	end, err := pkg.ParseTimeOfDay(req.ServingEnd)
+	if err != nil {
+		return pkg.MealType{}, err
+	}
+	days, err := pkg.ParseWeekdays(req.ActiveDays)
+	if err != nil {
+		return pkg.MealType{}, err
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	if req.SiteID != nil && *req.SiteID <= 0 {
+		return pkg.MealType{}, errors.New("site_id must be positive")
+	}
+	return pkg.MealType{ID: id, Code: req.Code, Name: req.Name, ServingStart: start, ServingEnd: end, ActiveDays: days, DefaultCapacity: req.DefaultCapacity, Active: active, SiteID: req.SiteID}, nil
+}
+
+type mealTypeResponse struct {
+	ID              int64     `json:"id"`
+	Code            string    `json:"code"`
+	Name            string    `json:"name"`
+	ServingStart    string    `json:"serving_start"`
+	ServingEnd      string    `json:"serving_end"`
+	ActiveDays      []string  `json:"active_days"`
+	DefaultCapacity int64     `json:"default_capacity"`
+	Active          bool      `json:"active"`
+	SiteID          *int64    `json:"site_id"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func newMealTypeResponse(mealType pkg.MealType) mealTypeResponse {
+	return mealTypeResponse{ID: mealType.ID, Code: mealType.Code, Name: mealType.Name, ServingStart: mealType.ServingStart.String(), ServingEnd: mealType.ServingEnd.String(), ActiveDays: mealType.ActiveDays.Names(), DefaultCapacity: mealType.DefaultCapacity, Active: mealType.Active, SiteID: mealType.SiteID, CreatedAt: mealType.CreatedAt}
+}
+
+func (s *server) handleSaveMealType() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req mealTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		mealType, err := req.mealType(0)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		saved, err := s.service.Save(r.Context(), mealType)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(newMealTypeResponse(*saved))
+	}
+}
+
+func (s *server) handleListMealTypes() http.HandlerFunc {
+	type response []mealTypeResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var siteID int64
+		if v := r.URL.Query().Get("site_id"); v != "" {
+			var err error
+			if siteID, err = strconv.ParseInt(v, 10, 64); err != nil || siteID <= 0 {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		list, err := s.service.List(r.Context(), siteID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, newMealTypeResponse(v))
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleRemoveMealType() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMealTypeID)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleUpdateMealType() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMealTypeID)
+			return
+		}
+
+		var req mealTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		mealType, err := req.mealType(id)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		updated, isCreated, err := s.service.Update(r.Context(), mealType)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		if isCreated {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(newMealTypeResponse(*updated))
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrMealTypeNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrMealTypeAlreadyExists, pkg.ErrMealTypeInUse:
+		w.WriteHeader(http.StatusConflict)
+	case ErrNonNumericMealTypeID, ErrInvalidQuery, pkg.ErrUnknownSite:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
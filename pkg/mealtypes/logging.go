@@ -0,0 +1,62 @@
+package mealtypes
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Save(ctx context.Context, mealType pkg.MealType) (_ *pkg.MealType, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save",
+			"code", mealType.Code,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Save(ctx, mealType)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context, siteID int64) (_ []pkg.MealType, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list",
+			"site_id", siteID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx, siteID)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Remove(ctx, id)
+}
+
+func (s *loggingMiddleware) Update(ctx context.Context, mealType pkg.MealType) (_ *pkg.MealType, _ bool, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "update",
+			"code", mealType.Code,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Update(ctx, mealType)
+}
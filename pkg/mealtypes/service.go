@@ -0,0 +1,85 @@
+package mealtypes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	Save(context.Context, pkg.MealType) (*pkg.MealType, error)
+	// List returns the meal types served at siteID, zero for all of them.
+	List(ctx context.Context, siteID int64) ([]pkg.MealType, error)
+	Update(context.Context, pkg.MealType) (*pkg.MealType, bool, error)
+	Remove(context.Context, int64) error
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository pkg.MealTypeRepository
+}
+
+func NewService(repository pkg.MealTypeRepository) Service {
+	return &service{repository: repository}
+}
+
+func (s *service) Save(ctx context.Context, mealType pkg.MealType) (*pkg.MealType, error) {
+	if err := s.repository.Insert(ctx, &mealType); err != nil {
+		if err == pkg.ErrMealTypeAlreadyExists || err == pkg.ErrUnknownSite {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save meal type: %v", err)
+	}
+	return &mealType, nil
+}
+
+func (s *service) List(ctx context.Context, siteID int64) ([]pkg.MealType, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all meal types: %v", err)
+	}
+	if siteID == 0 {
+		return list, nil
+	}
+	var served []pkg.MealType
+	for _, mealType := range list {
+		if mealType.ServedAt(siteID) {
+			served = append(served, mealType)
+		}
+	}
+	return served, nil
+}
+
+func (s *service) Update(ctx context.Context, mealType pkg.MealType) (*pkg.MealType, bool, error) {
+	err := s.repository.Update(ctx, &mealType)
+	if err == pkg.ErrMealTypeNotFound {
+		err = s.repository.Insert(ctx, &mealType)
+		if err == pkg.ErrMealTypeAlreadyExists || err == pkg.ErrUnknownSite {
+			return nil, false, err
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("could not create meal type: %v", err)
+		}
+		return &mealType, true, nil
+	}
+	if err == pkg.ErrMealTypeAlreadyExists || err == pkg.ErrUnknownSite {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not update meal type: %v", err)
+	}
+	return &mealType, false, nil
+}
+
+func (s *service) Remove(ctx context.Context, id int64) error {
+	if err := s.repository.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrMealTypeNotFound || err == pkg.ErrMealTypeInUse {
+			return err
+		}
+		return fmt.Errorf("could not remove meal type: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDishNotFound      = errors.New("dish not found")
+	ErrDishInUse         = errors.New("dish is on a menu")
+	ErrUnknownDish       = errors.New("unknown dish")
+	ErrMenuNotFound      = errors.New("menu not found")
+	ErrMenuAlreadyExists = errors.New("menu already exists for that day and meal")
+)
+
+// Nutrition is per serving, energy in kilocalories and the rest in grams.
+type Nutrition struct {
+	Calories int64
+	Protein  int64
+	Carbs    int64
+	Fat      int64
+}
+
+// Dish is something the kitchen serves. Diets lists the diets it is suitable
+// for and Allergens the allergen codes it contains.
+type Dish struct {
+	ID          int64
+	Name        string
+	Description string
+	Diets       []Diet
+	Allergens   []string
+	Nutrition   Nutrition
+	CreatedAt   time.Time
+}
+
+// Menu is what is served for one meal type on one day. Only published menus
+// are shown to employees. Writes only look at the ID of each dish.
+type Menu struct {
+	ID          int64
+	Date        time.Time
+	MealTypeID  int64
+	Dishes      []Dish
+	Published   bool
+	PublishedAt time.Time
+	CreatedAt   time.Time
+}
+
+type DishRepository interface {
+	Insert(context.Context, *Dish) error
+	FindAll(context.Context) ([]Dish, error)
+	FindByID(context.Context, int64) (*Dish, error)
+	Update(context.Context, *Dish) error
+	DeleteByID(context.Context, int64) error
+}
+
+type MenuRepository interface {
+	Insert(context.Context, *Menu) error
+	FindByID(context.Context, int64) (*Menu, error)
+	FindByDate(context.Context, time.Time) ([]Menu, error)
+	// FindForMeal returns the menu of a meal type on the day of date, or
+	// ErrMenuNotFound.
+	FindForMeal(ctx context.Context, date time.Time, mealTypeID int64) (*Menu, error)
+	// FindPublishedWithDish returns the published menus from the day of from
+	// on that serve a dish.
+	FindPublishedWithDish(ctx context.Context, dishID int64, from time.Time) ([]Menu, error)
+	Update(context.Context, *Menu) error
+	SetPublished(ctx context.Context, id int64, published bool) error
+	DeleteByID(context.Context, int64) error
+}
+
+// DishConflict is a dish of a menu containing allergens someone must avoid.
+type DishConflict struct {
+	DishID    int64
+	DishName  string
+	Allergens []string
+}
+
+// Conflicts lists the dishes of the menu that contain any of the profile's
+// allergens, in the order they are served.
+func (m Menu) Conflicts(profile DietaryProfile) []DishConflict {
+	avoid := make(map[string]bool, len(profile.Allergens))
+	for _, code := range profile.Allergens {
+		avoid[code] = true
+	}
+
+	var conflicts []DishConflict
+	for _, dish := range m.Dishes {
+		var found []string
+		for _, code := range dish.Allergens {
+			if avoid[code] {
+				found = append(found, code)
+			}
+		}
+		if len(found) > 0 {
+			conflicts = append(conflicts, DishConflict{DishID: dish.ID, DishName: dish.Name, Allergens: found})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,93 @@
+package menus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// AffectedEmployee is someone booked for a meal whose menu contains dishes
+// they are allergic to.
+type AffectedEmployee struct {
+	ReservationID int64
+	UserID        int64
+	Name          string
+	EmployeeID    string
+	Conflicts     []pkg.DishConflict
+}
+
+func (s *service) AllergenReport(ctx context.Context, id int64) ([]AffectedEmployee, error) {
+	menu, err := s.GetMenu(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.affected(ctx, *menu)
+}
+
+// affected checks every active reservation for the menu's meal against the
+// dietary profile that applies to it.
+func (s *service) affected(ctx context.Context, menu pkg.Menu) ([]AffectedEmployee, error) {
+	reservations, err := s.reservations.FindActiveForMeal(ctx, menu.Date, menu.MealTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list reservations: %v", err)
+	}
+
+	list := []AffectedEmployee{}
+	for _, reservation := range reservations {
+		user, err := s.users.FindByID(ctx, reservation.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("could not find user: %v", err)
+		}
+		conflicts := menu.Conflicts(reservation.DietaryProfileOf(*user))
+		if len(conflicts) == 0 {
+			continue
+		}
+		list = append(list, AffectedEmployee{ReservationID: reservation.ID, UserID: user.ID, Name: user.Name, EmployeeID: user.EmployeeID, Conflicts: conflicts})
+	}
+	return list, nil
+}
+
+// notifyAffected tells the affected employees of a published menu about
+// their conflicts, skipping anyone whose conflicts are unchanged since
+// before. Notifications are best effort, as the menu is already stored.
+func (s *service) notifyAffected(ctx context.Context, menu pkg.Menu, before map[int64]string) {
+	affected, err := s.affected(ctx, menu)
+	if err != nil {
+		return
+	}
+	for _, employee := range affected {
+		summary := conflictSummary(employee.Conflicts)
+		if before[employee.ReservationID] == summary {
+			continue
+		}
+		s.notifier.Notify(ctx, pkg.Notification{
+			UserID:  employee.UserID,
+			Subject: "Allergen warning for your meal",
+			Body:    fmt.Sprintf("The menu for your reservation on %s contains allergens you avoid: %s", menu.Date.Format("Mon Jan 2 2006"), summary),
+		})
+	}
+}
+
+// conflictsByReservation remembers what each affected reservation was
+// warned about, so a later change only re-notifies when that differs.
+func (s *service) conflictsByReservation(ctx context.Context, menu pkg.Menu) map[int64]string {
+	affected, err := s.affected(ctx, menu)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[int64]string, len(affected))
+	for _, employee := range affected {
+		seen[employee.ReservationID] = conflictSummary(employee.Conflicts)
+	}
+	return seen
+}
+
+func conflictSummary(conflicts []pkg.DishConflict) string {
+	parts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		parts = append(parts, fmt.Sprintf("%s (%s)", c.DishName, strings.Join(c.Allergens, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,551 @@
+package menus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSaveDish http.Handler
+	handleSaveDish = s.handleSaveDish()
+	handleSaveDish = httpLoggingMiddleware(logger, "handleSaveDish")(handleSaveDish)
+
+	var handleListDishes http.Handler
+	handleListDishes = s.handleListDishes()
+	handleListDishes = httpLoggingMiddleware(logger, "handleListDishes")(handleListDishes)
+
+	var handleGetDish http.Handler
+	handleGetDish = s.handleGetDish()
+	handleGetDish = httpLoggingMiddleware(logger, "handleGetDish")(handleGetDish)
+
+	var handleUpdateDish http.Handler
+	handleUpdateDish = s.handleUpdateDish()
+	handleUpdateDish = httpLoggingMiddleware(logger, "handleUpdateDish")(handleUpdateDish)
+
+	var handleRemoveDish http.Handler
+	handleRemoveDish = s.handleRemoveDish()
+	handleRemoveDish = httpLoggingMiddleware(logger, "handleRemoveDish")(handleRemoveDish)
+
+	var handleSaveMenu http.Handler
+	handleSaveMenu = s.handleSaveMenu()
+	handleSaveMenu = httpLoggingMiddleware(logger, "handleSaveMenu")(handleSaveMenu)
+
+	var handleListMenus http.Handler
+	handleListMenus = s.handleListMenus()
+	handleListMenus = httpLoggingMiddleware(logger, "handleListMenus")(handleListMenus)
+
+	var handleGetMenu http.Handler
+	handleGetMenu = s.handleGetMenu()
+	handleGetMenu = httpLoggingMiddleware(logger, "handleGetMenu")(handleGetMenu)
+
+	var handleUpdateMenu http.Handler
+	handleUpdateMenu = s.handleUpdateMenu()
+	handleUpdateMenu = httpLoggingMiddleware(logger, "handleUpdateMenu")(handleUpdateMenu)
+
+	var handleRemoveMenu http.Handler
+	handleRemoveMenu = s.handleRemoveMenu()
+	handleRemoveMenu = httpLoggingMiddleware(logger, "handleRemoveMenu")(handleRemoveMenu)
+
+	var handlePublishMenu http.Handler
+	handlePublishMenu = s.handlePublishMenu(true)
+	handlePublishMenu = httpLoggingMiddleware(logger, "handlePublishMenu")(handlePublishMenu)
+
+	var handleUnpublishMenu http.Handler
+	handleUnpublishMenu = s.handlePublishMenu(false)
+	handleUnpublishMenu = httpLoggingMiddleware(logger, "handleUnpublishMenu")(handleUnpublishMenu)
+
+	var handleAllergenReport http.Handler
+	handleAllergenReport = s.handleAllergenReport()
+	handleAllergenReport = httpLoggingMiddleware(logger, "handleAllergenReport")(handleAllergenReport)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/menu/v1/dishes", handleSaveDish)
+	router.Handle("GET", "/menu/v1/dishes", handleListDishes)
+	router.Handle("GET", "/menu/v1/dish/:id", handleGetDish)
+	router.Handle("PUT", "/menu/v1/dish/:id", handleUpdateDish)
+	router.Handle("DELETE", "/menu/v1/dish/:id", handleRemoveDish)
+	router.Handle("POST", "/menu/v1/menus", handleSaveMenu)
+	router.Handle("GET", "/menu/v1/menus", handleListMenus)
+	router.Handle("GET", "/menu/v1/menu/:id", handleGetMenu)
+	router.Handle("PUT", "/menu/v1/menu/:id", handleUpdateMenu)
+	router.Handle("DELETE", "/menu/v1/menu/:id", handleRemoveMenu)
+	router.Handle("POST", "/menu/v1/menu/:id/publish", handlePublishMenu)
+	router.Handle("POST", "/menu/v1/menu/:id/unpublish", handleUnpublishMenu)
+	router.Handle("GET", "/menu/v1/menu/:id/allergen-report", handleAllergenReport)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	dateLayout       = "2006-01-02"
+)
+
+var (
+	ErrNonNumericDishID = errors.New("dish id in path must be numberic")
+	ErrNonNumericMenuID = errors.New("menu id in path must be numberic")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type nutrition struct {
+	Calories int64 `json:"calories"`
+	Protein  int64 `json:"protein"`
+	Carbs    int64 `json:"carbs"`
+	Fat      int64 `json:"fat"`
+}
+
+type dishRequest struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Diets       []string  `json:"diets"`
+	Allergens   []string  `json:"allergens"`
+	Nutrition   nutrition `json:"nutrition"`
+}
+
+func (req dishRequest) dish(id int64) pkg.Dish {
+	dish := pkg.Dish{ID: id, Name: req.Name, Description: req.Description, Allergens: req.Allergens, Nutrition: pkg.Nutrition(req.Nutrition)}
+	for _, diet := range req.Diets {
+		dish.Diets = append(dish.Diets, pkg.Diet(diet))
+	}
+	return dish
+}
+
+type dishResponse struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Diets       []string  `json:"diets"`
+	Allergens   []string  `json:"allergens"`
+	Nutrition   nutrition `json:"nutrition"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func newDishResponse(dish pkg.Dish) dishResponse {
+	resp := dishResponse{ID: dish.ID, Name: dish.Name, Description: dish.Description, Diets: make([]string, 0, len(dish.Diets)), Allergens: dish.Allergens, Nutrition: nutrition(dish.Nutrition), CreatedAt: dish.CreatedAt}
+	for _, diet := range dish.Diets {
+		resp.Diets = append(resp.Diets, string(diet))
+	}
+	if resp.Allergens == nil {
+		resp.Allergens = []string{}
+	}
+	return resp
+}
+
+type menuRequest struct {
+	Date       string  `json:"date"`
+	MealTypeID int64   `json:"type"`
+	DishIDs    []int64 `json:"dishes"`
+}
+
+func (req menuRequest) menu(id int64) (pkg.Menu, error) {
+	menu := pkg.Menu{ID: id, MealTypeID: req.MealTypeID}
+	if req.Date != "" {
+		date, err := time.Parse(dateLayout, req.Date)
+		if err != nil {
+			return menu, err
+		}
+		menu.Date = date
+	}
+	for _, dishID := range req.DishIDs {
+		menu.Dishes = append(menu.Dishes, pkg.Dish{ID: dishID})
+	}
+	return menu, nil
+}
+
+type menuResponse struct {
+	ID          int64          `json:"id"`
+	Date        string         `json:"date"`
+	MealTypeID  int64          `json:"type"`
+	Dishes      []dishResponse `json:"dishes"`
+	Published   bool           `json:"published"`
+	PublishedAt *time.Time     `json:"published_at"`
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
+func newMenuResponse(menu pkg.Menu) menuResponse {
+	resp := menuResponse{ID: menu.ID, Date: menu.Date.Format(dateLayout), MealTypeID: menu.MealTypeID, Dishes: make([]dishResponse, 0, len(menu.Dishes)), Published: menu.Published, CreatedAt: menu.CreatedAt}
+	for _, dish := range menu.Dishes {
+		resp.Dishes = append(resp.Dishes, newDishResponse(dish))
+	}
+	if menu.Published {
+		publishedAt := menu.PublishedAt
+		resp.PublishedAt = &publishedAt
+	}
+	return resp
+}
+
+func (s *server) handleSaveDish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		dish, err := s.service.SaveDish(r.Context(), req.dish(0))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newDishResponse(*dish))
+	}
+}
+
+func (s *server) handleListDishes() http.HandlerFunc {
+	type response []dishResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.ListDishes(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, newDishResponse(v))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleGetDish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericDishID)
+			return
+		}
+
+		dish, err := s.service.GetDish(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newDishResponse(*dish))
+	}
+}
+
+func (s *server) handleUpdateDish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericDishID)
+			return
+		}
+
+		var req dishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		dish, isCreated, err := s.service.UpdateDish(r.Context(), req.dish(id))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		status := http.StatusOK
+		if isCreated {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, newDishResponse(*dish))
+	}
+}
+
+func (s *server) handleRemoveDish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericDishID)
+			return
+		}
+
+		if err := s.service.RemoveDish(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleSaveMenu() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req menuRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		menu, err := req.menu(0)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		saved, err := s.service.SaveMenu(r.Context(), menu)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMenuResponse(*saved))
+	}
+}
+
+// handleListMenus answers GET /menu/v1/menus?date= with the published menus
+// of that day, today when date is omitted.
+func (s *server) handleListMenus() http.HandlerFunc {
+	type response []menuResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := pkg.Date(time.Now())
+		if v := r.URL.Query().Get("date"); v != "" {
+			var err error
+			if date, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		list, err := s.service.PublishedMenus(r.Context(), date)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, newMenuResponse(v))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleGetMenu() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMenuID)
+			return
+		}
+
+		menu, err := s.service.GetMenu(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMenuResponse(*menu))
+	}
+}
+
+func (s *server) handleUpdateMenu() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMenuID)
+			return
+		}
+
+		var req menuRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+		menu, err := req.menu(id)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		updated, isCreated, err := s.service.UpdateMenu(r.Context(), menu)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		status := http.StatusOK
+		if isCreated {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, newMenuResponse(*updated))
+	}
+}
+
+func (s *server) handleRemoveMenu() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMenuID)
+			return
+		}
+
+		if err := s.service.RemoveMenu(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handlePublishMenu(publish bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMenuID)
+			return
+		}
+
+		var menu *pkg.Menu
+		if publish {
+			menu, err = s.service.Publish(r.Context(), id)
+		} else {
+			menu, err = s.service.Unpublish(r.Context(), id)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMenuResponse(*menu))
+	}
+}
+
+func (s *server) handleAllergenReport() http.HandlerFunc {
+	type conflict struct {
+		DishID    int64    `json:"dish_id"`
+		Dish      string   `json:"dish"`
+		Allergens []string `json:"allergens"`
+	}
+	type employee struct {
+		ReservationID int64      `json:"reservation_id"`
+		UserID        int64      `json:"user_id"`
+		Name          string     `json:"name"`
+		EmployeeID    string     `json:"employee_id"`
+		Conflicts     []conflict `json:"conflicts"`
+	}
+	type response struct {
+		MenuID   int64      `json:"menu_id"`
+		Affected []employee `json:"affected"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericMenuID)
+			return
+		}
+
+		affected, err := s.service.AllergenReport(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := response{MenuID: id, Affected: []employee{}}
+		for _, a := range affected {
+			e := employee{ReservationID: a.ReservationID, UserID: a.UserID, Name: a.Name, EmployeeID: a.EmployeeID}
+			for _, c := range a.Conflicts {
+				e.Conflicts = append(e.Conflicts, conflict{DishID: c.DishID, Dish: c.DishName, Allergens: c.Allergens})
+			}
+			resp.Affected = append(resp.Affected, e)
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrDishNotFound, pkg.ErrMenuNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrDishInUse, pkg.ErrMenuAlreadyExists:
+		w.WriteHeader(http.StatusConflict)
+	case ErrNonNumericDishID, ErrNonNumericMenuID, ErrInvalidQuery, pkg.ErrUnknownMealType, pkg.ErrUnknownDish:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package menus
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) SaveDish(ctx context.Context, dish pkg.Dish) (_ *pkg.Dish, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save_dish",
+			"name", dish.Name,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SaveDish(ctx, dish)
+}
+
+func (s *loggingMiddleware) ListDishes(ctx context.Context) (_ []pkg.Dish, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list_dishes",
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.ListDishes(ctx)
+}
+
+func (s *loggingMiddleware) GetDish(ctx context.Context, id int64) (_ *pkg.Dish, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get_dish",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.GetDish(ctx, id)
+}
+
+func (s *loggingMiddleware) UpdateDish(ctx context.Context, dish pkg.Dish) (_ *pkg.Dish, isCreated bool, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "update_dish",
+			"id", dish.ID,
+			"created", isCreated,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.UpdateDish(ctx, dish)
+}
+
+func (s *loggingMiddleware) RemoveDish(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove_dish",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RemoveDish(ctx, id)
+}
+
+func (s *loggingMiddleware) SaveMenu(ctx context.Context, menu pkg.Menu) (_ *pkg.Menu, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save_menu",
+			"date", menu.Date,
+			"meal_type_id", menu.MealTypeID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SaveMenu(ctx, menu)
+}
+
+func (s *loggingMiddleware) GetMenu(ctx context.Context, id int64) (_ *pkg.Menu, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get_menu",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.GetMenu(ctx, id)
+}
+
+func (s *loggingMiddleware) UpdateMenu(ctx context.Context, menu pkg.Menu) (_ *pkg.Menu, isCreated bool, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "update_menu",
+			"id", menu.ID,
+			"created", isCreated,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.UpdateMenu(ctx, menu)
+}
+
+func (s *loggingMiddleware) RemoveMenu(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove_menu",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RemoveMenu(ctx, id)
+}
+
+func (s *loggingMiddleware) Publish(ctx context.Context, id int64) (_ *pkg.Menu, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "publish",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Publish(ctx, id)
+}
+
+func (s *loggingMiddleware) Unpublish(ctx context.Context, id int64) (_ *pkg.Menu, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "unpublish",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Unpublish(ctx, id)
+}
+
+func (s *loggingMiddleware) PublishedMenus(ctx context.Context, date time.Time) (list []pkg.Menu, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "published_menus",
+			"date", date,
+			"menus", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.PublishedMenus(ctx, date)
+}
+
+func (s *loggingMiddleware) AllergenReport(ctx context.Context, id int64) (list []AffectedEmployee, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "allergen_report",
+			"id", id,
+			"affected", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.AllergenReport(ctx, id)
+}
@@ -0,0 +1,219 @@
+package menus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	SaveDish(context.Context, pkg.Dish) (*pkg.Dish, error)
+	ListDishes(context.Context) ([]pkg.Dish, error)
+	GetDish(context.Context, int64) (*pkg.Dish, error)
+	UpdateDish(context.Context, pkg.Dish) (*pkg.Dish, bool, error)
+	RemoveDish(context.Context, int64) error
+
+	SaveMenu(context.Context, pkg.Menu) (*pkg.Menu, error)
+	GetMenu(context.Context, int64) (*pkg.Menu, error)
+	UpdateMenu(context.Context, pkg.Menu) (*pkg.Menu, bool, error)
+	RemoveMenu(context.Context, int64) error
+	Publish(context.Context, int64) (*pkg.Menu, error)
+	Unpublish(context.Context, int64) (*pkg.Menu, error)
+	// PublishedMenus returns the published menus of the day of date, the
+	// view employees get.
+	PublishedMenus(ctx context.Context, date time.Time) ([]pkg.Menu, error)
+	// AllergenReport lists who is booked for the menu's meal and allergic to
+	// something on it.
+	AllergenReport(ctx context.Context, id int64) ([]AffectedEmployee, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	dishes       pkg.DishRepository
+	menus        pkg.MenuRepository
+	reservations pkg.ReservationRepository
+	users        pkg.UserRepository
+	notifier     pkg.Notifier
+}
+
+func NewService(dishes pkg.DishRepository, menus pkg.MenuRepository, reservations pkg.ReservationRepository, users pkg.UserRepository, notifier pkg.Notifier) Service {
+	return &service{dishes: dishes, menus: menus, reservations: reservations, users: users, notifier: notifier}
+}
+
+func (s *service) SaveDish(ctx context.Context, dish pkg.Dish) (*pkg.Dish, error) {
+	if err := s.dishes.Insert(ctx, &dish); err != nil {
+		return nil, fmt.Errorf("could not save dish: %v", err)
+	}
+	return &dish, nil
+}
+
+func (s *service) ListDishes(ctx context.Context) ([]pkg.Dish, error) {
+	list, err := s.dishes.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all dishes: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) GetDish(ctx context.Context, id int64) (*pkg.Dish, error) {
+	dish, err := s.dishes.FindByID(ctx, id)
+	if err == pkg.ErrDishNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find dish: %v", err)
+	}
+	return dish, nil
+}
+
+func (s *service) UpdateDish(ctx context.Context, dish pkg.Dish) (*pkg.Dish, bool, error) {
+	// like a change to a published menu, a change to the allergens of a
+	// dish on one is told to whoever it makes a difference to.
+	menus, err := s.menus.FindPublishedWithDish(ctx, dish.ID, time.Now())
+	if err != nil {
+		return nil, false, fmt.Errorf("could not find menus serving dish: %v", err)
+	}
+	before := make(map[int64]map[int64]string, len(menus))
+	for _, menu := range menus {
+		before[menu.ID] = s.conflictsByReservation(ctx, menu)
+	}
+
+	err = s.dishes.Update(ctx, &dish)
+	if err == pkg.ErrDishNotFound {
+		if err := s.dishes.Insert(ctx, &dish); err != nil {
+			return nil, false, fmt.Errorf("could not create dish: %v", err)
+		}
+		return &dish, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not update dish: %v", err)
+	}
+	for _, menu := range menus {
+		// the menu is read again to see the dish as it is now.
+		if updated, err := s.menus.FindByID(ctx, menu.ID); err == nil {
+			s.notifyAffected(ctx, *updated, before[menu.ID])
+		}
+	}
+	return &dish, false, nil
+}
+
+func (s *service) RemoveDish(ctx context.Context, id int64) error {
+	if err := s.dishes.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrDishNotFound || err == pkg.ErrDishInUse {
+			return err
+		}
+		return fmt.Errorf("could not remove dish: %v", err)
+	}
+	return nil
+}
+
+func (s *service) SaveMenu(ctx context.Context, menu pkg.Menu) (*pkg.Menu, error) {
+	if err := s.menus.Insert(ctx, &menu); err != nil {
+		if isMenuWriteError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save menu: %v", err)
+	}
+	return &menu, nil
+}
+
+func (s *service) GetMenu(ctx context.Context, id int64) (*pkg.Menu, error) {
+	menu, err := s.menus.FindByID(ctx, id)
+	if err == pkg.ErrMenuNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find menu: %v", err)
+	}
+	return menu, nil
+}
+
+func (s *service) UpdateMenu(ctx context.Context, menu pkg.Menu) (*pkg.Menu, bool, error) {
+	// people booked for a published menu were already warned about it, so
+	// only what the change makes different is worth telling them.
+	var before map[int64]string
+	if previous, err := s.menus.FindByID(ctx, menu.ID); err == nil && previous.Published {
+		before = s.conflictsByReservation(ctx, *previous)
+	}
+
+	err := s.menus.Update(ctx, &menu)
+	if err == pkg.ErrMenuNotFound {
+		err = s.menus.Insert(ctx, &menu)
+		if isMenuWriteError(err) {
+			return nil, false, err
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("could not create menu: %v", err)
+		}
+		if menu.Published {
+			s.notifyAffected(ctx, menu, nil)
+		}
+		return &menu, true, nil
+	}
+	if isMenuWriteError(err) {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not update menu: %v", err)
+	}
+	if menu.Published {
+		s.notifyAffected(ctx, menu, before)
+	}
+	return &menu, false, nil
+}
+
+func (s *service) RemoveMenu(ctx context.Context, id int64) error {
+	if err := s.menus.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrMenuNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove menu: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Publish(ctx context.Context, id int64) (*pkg.Menu, error) {
+	menu, err := s.setPublished(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyAffected(ctx, *menu, nil)
+	return menu, nil
+}
+
+func (s *service) Unpublish(ctx context.Context, id int64) (*pkg.Menu, error) {
+	return s.setPublished(ctx, id, false)
+}
+
+func (s *service) setPublished(ctx context.Context, id int64, published bool) (*pkg.Menu, error) {
+	if err := s.menus.SetPublished(ctx, id, published); err != nil {
+		if err == pkg.ErrMenuNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not publish menu: %v", err)
+	}
+	return s.GetMenu(ctx, id)
+}
+
+func (s *service) PublishedMenus(ctx context.Context, date time.Time) ([]pkg.Menu, error) {
+	menus, err := s.menus.FindByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("could not list menus: %v", err)
+	}
+
+	var list []pkg.Menu
+	for _, menu := range menus {
+		if menu.Published {
+			list = append(list, menu)
+		}
+	}
+	return list, nil
+}
+
+func isMenuWriteError(err error) bool {
+	return err == pkg.ErrMenuAlreadyExists || err == pkg.ErrUnknownMealType || err == pkg.ErrUnknownDish
+}
@@ -0,0 +1,135 @@
+package menus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware(mealTypes pkg.MealTypeRepository, dishes pkg.DishRepository, allergens pkg.AllergenRepository) Middleware {
+	return func(s Service) Service { return &validationMiddleware{mealTypes, dishes, allergens, s} }
+}
+
+type validationMiddleware struct {
+	mealTypes pkg.MealTypeRepository
+	dishes    pkg.DishRepository
+	allergens pkg.AllergenRepository
+	Service
+}
+
+func (s *validationMiddleware) SaveDish(ctx context.Context, dish pkg.Dish) (*pkg.Dish, error) {
+	if err := s.validateDish(ctx, dish); err != nil {
+		return nil, err
+	}
+	return s.Service.SaveDish(ctx, dish)
+}
+
+func (s *validationMiddleware) UpdateDish(ctx context.Context, dish pkg.Dish) (*pkg.Dish, bool, error) {
+	if err := s.validateDish(ctx, dish); err != nil {
+		return nil, false, err
+	}
+	return s.Service.UpdateDish(ctx, dish)
+}
+
+func (s *validationMiddleware) SaveMenu(ctx context.Context, menu pkg.Menu) (*pkg.Menu, error) {
+	if err := s.validateMenu(ctx, menu); err != nil {
+		return nil, err
+	}
+	return s.Service.SaveMenu(ctx, menu)
+}
+
+func (s *validationMiddleware) UpdateMenu(ctx context.Context, menu pkg.Menu) (*pkg.Menu, bool, error) {
+	if err := s.validateMenu(ctx, menu); err != nil {
+		return nil, false, err
+	}
+	return s.Service.UpdateMenu(ctx, menu)
+}
+
+func (s *validationMiddleware) validateDish(ctx context.Context, dish pkg.Dish) error {
+	var verr pkg.ValidationError
+
+	if strings.TrimSpace(dish.Name) == "" {
+		verr.Add("name", "required", "name is required")
+	}
+
+	seenDiets := make(map[pkg.Diet]bool)
+	for _, diet := range dish.Diets {
+		if _, err := pkg.ParseDiet(string(diet)); err != nil || diet == "" {
+			verr.Add("diets", "unknown", fmt.Sprintf("diet %q is not one of %v", diet, pkg.Diets))
+		} else if seenDiets[diet] {
+			verr.Add("diets", "duplicate", fmt.Sprintf("diet %q is listed twice", diet))
+		}
+		seenDiets[diet] = true
+	}
+
+	known, err := s.allergens.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list allergens: %v", err)
+	}
+	codes := make(map[string]bool, len(known))
+	for _, a := range known {
+		codes[a.Code] = true
+	}
+	seenAllergens := make(map[string]bool)
+	for _, code := range dish.Allergens {
+		if !codes[code] {
+			verr.Add("allergens", "unknown", fmt.Sprintf("allergen %q does not exist", code))
+		} else if seenAllergens[code] {
+			verr.Add("allergens", "duplicate", fmt.Sprintf("allergen %q is listed twice", code))
+		}
+		seenAllergens[code] = true
+	}
+
+	for _, n := range []struct {
+		field string
+		value int64
+	}{
+		{"nutrition.calories", dish.Nutrition.Calories},
+		{"nutrition.protein", dish.Nutrition.Protein},
+		{"nutrition.carbs", dish.Nutrition.Carbs},
+		{"nutrition.fat", dish.Nutrition.Fat},
+	} {
+		if n.value < 0 {
+			verr.Add(n.field, "min", n.field+" must not be negative")
+		}
+	}
+
+	return verr.Err()
+}
+
+func (s *validationMiddleware) validateMenu(ctx context.Context, menu pkg.Menu) error {
+	var verr pkg.ValidationError
+
+	if menu.Date.IsZero() {
+		verr.Add("date", "required", "date is required")
+	}
+
+	if menu.MealTypeID <= 0 {
+		verr.Add("type", "required", "type is required")
+	} else if _, err := s.mealTypes.FindByID(ctx, menu.MealTypeID); err == pkg.ErrMealTypeNotFound {
+		verr.Add("type", "unknown", fmt.Sprintf("meal type %d does not exist", menu.MealTypeID))
+	} else if err != nil {
+		return fmt.Errorf("could not find meal type: %v", err)
+	}
+
+	if len(menu.Dishes) == 0 {
+		verr.Add("dishes", "required", "a menu needs at least one dish")
+	}
+	seen := make(map[int64]bool)
+	for _, dish := range menu.Dishes {
+		if seen[dish.ID] {
+			verr.Add("dishes", "duplicate", fmt.Sprintf("dish %d is listed twice", dish.ID))
+			continue
+		}
+		seen[dish.ID] = true
+		if _, err := s.dishes.FindByID(ctx, dish.ID); err == pkg.ErrDishNotFound {
+			verr.Add("dishes", "unknown", fmt.Sprintf("dish %d does not exist", dish.ID))
+		} else if err != nil {
+			return fmt.Errorf("could not find dish: %v", err)
+		}
+	}
+
+	return verr.Err()
+}
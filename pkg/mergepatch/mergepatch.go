@@ -0,0 +1,38 @@
+// Package mergepatch implements JSON Merge Patch as defined in RFC 7386.
+package mergepatch
+
+import "encoding/json"
+
+// ContentType is the media type clients must send merge patches with.
+const ContentType = "application/merge-patch+json"
+
+// Apply merges patch into the JSON document doc and returns the result.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = merge(t[name], value)
+	}
+	return t
+}
@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type allergenRepository struct {
+	queries *gen.Queries
+}
+
+func NewAllergenRepository(db *sql.DB) pkg.AllergenRepository {
+	return &allergenRepository{queries: gen.New(db)}
+}
+
+func (a *allergenRepository) Insert(ctx context.Context, allergen *pkg.Allergen) error {
+	err := a.queries.CreateAllergen(ctx, gen.CreateAllergenParams{Code: allergen.Code, Name: allergen.Name})
+	if isDuplicateEntry(err) {
+		return pkg.ErrAllergenAlreadyExists
+	}
+	return err
+}
+
+func (a *allergenRepository) FindAll(ctx context.Context) ([]pkg.Allergen, error) {
+	allergens, err := a.queries.ListAllergens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Allergen
+	for _, allergen := range allergens {
+		list = append(list, pkg.Allergen{Code: allergen.Code, Name: allergen.Name})
+	}
+	return list, nil
+}
+
+func (a *allergenRepository) DeleteByCode(ctx context.Context, code string) error {
+	deleted, err := a.queries.DeleteAllergen(ctx, code)
+	if isRowReferenced(err) {
+		return pkg.ErrAllergenInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrAllergenNotFound
+	}
+	return nil
+}
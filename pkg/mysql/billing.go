@@ -0,0 +1,263 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type priceRepository struct {
+	queries *gen.Queries
+}
+
+func NewPriceRepository(db *sql.DB) pkg.PriceRepository {
+	return &priceRepository{queries: gen.New(db)}
+}
+
+func (p *priceRepository) Insert(ctx context.Context, price *pkg.Price) error {
+	inserted, err := p.queries.CreateMealPrice(ctx, gen.CreateMealPriceParams{MealTypeID: price.MealTypeID, EmployeePrice: int64(price.EmployeePrice), GuestPrice: int64(price.GuestPrice), ValidFrom: pkg.Date(price.Validity.From), ValidTo: validTo(price.Validity)})
+	if isMissingReference(err) {
+		return pkg.ErrUnknownMealType
+	}
+	if err != nil {
+		return err
+	}
+	price.ID, _ = inserted.LastInsertId()
+	price.CreatedAt = time.Now()
+	return nil
+}
+
+func (p *priceRepository) FindAll(ctx context.Context) ([]pkg.Price, error) {
+	prices, err := p.queries.ListMealPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Price
+	for _, price := range prices {
+		list = append(list, pkg.Price{ID: price.ID, MealTypeID: price.MealTypeID, EmployeePrice: pkg.Money(price.EmployeePrice), GuestPrice: pkg.Money(price.GuestPrice), Validity: toValidity(price.ValidFrom, price.ValidTo), CreatedAt: price.CreatedAt})
+	}
+	return list, nil
+}
+
+func (p *priceRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := p.queries.DeleteMealPrice(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrPriceNotFound
+	}
+	return nil
+}
+
+type subsidyRuleRepository struct {
+	queries *gen.Queries
+}
+
+func NewSubsidyRuleRepository(db *sql.DB) pkg.SubsidyRuleRepository {
+	return &subsidyRuleRepository{queries: gen.New(db)}
+}
+
+func (s *subsidyRuleRepository) Insert(ctx context.Context, rule *pkg.SubsidyRule) error {
+	inserted, err := s.queries.CreateSubsidyRule(ctx, gen.CreateSubsidyRuleParams{Designation: rule.Designation, Department: rule.Department, Percent: rule.Percent, ValidFrom: pkg.Date(rule.Validity.From), ValidTo: validTo(rule.Validity)})
+	if err != nil {
+		return err
+	}
+	rule.ID, _ = inserted.LastInsertId()
+	rule.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *subsidyRuleRepository) FindAll(ctx context.Context) ([]pkg.SubsidyRule, error) {
+	rules, err := s.queries.ListSubsidyRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.SubsidyRule
+	for _, rule := range rules {
+		list = append(list, pkg.SubsidyRule{ID: rule.ID, Designation: rule.Designation, Department: rule.Department, Percent: rule.Percent, Validity: toValidity(rule.ValidFrom, rule.ValidTo), CreatedAt: rule.CreatedAt})
+	}
+	return list, nil
+}
+
+func (s *subsidyRuleRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := s.queries.DeleteSubsidyRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrSubsidyNotFound
+	}
+	return nil
+}
+
+type statementRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewStatementRepository(db *sql.DB) pkg.StatementRepository {
+	return &statementRepository{db: db, queries: gen.New(db)}
+}
+
+// Save only ever updates drafts, so a statement finalized in the meantime
+// fails with pkg.ErrStatementFinalized instead of being overwritten.
+func (s *statementRepository) Save(ctx context.Context, statement *pkg.Statement) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := s.queries.WithTx(tx)
+
+	month := pkg.Date(statement.Month)
+	existing, err := queries.GetStatementByUserMonth(ctx, gen.GetStatementByUserMonthParams{UserID: statement.UserID, Month: month})
+	switch {
+	case err == sql.ErrNoRows:
+		inserted, err := queries.CreateStatement(ctx, gen.CreateStatementParams{UserID: statement.UserID, EmployeeID: statement.EmployeeID, Month: month, Subtotal: int64(statement.Subtotal), Subsidy: int64(statement.Subsidy), Total: int64(statement.Total)})
+		if err != nil {
+			return err
+		}
+		statement.ID, _ = inserted.LastInsertId()
+	case err != nil:
+		return err
+	case existing.Status != string(pkg.StatementDraft):
+		return pkg.ErrStatementFinalized
+	default:
+		updated, err := queries.UpdateDraftStatement(ctx, gen.UpdateDraftStatementParams{ID: existing.ID, EmployeeID: statement.EmployeeID, Subtotal: int64(statement.Subtotal), Subsidy: int64(statement.Subsidy), Total: int64(statement.Total)})
+		if err != nil {
+			return err
+		}
+		if n, _ := updated.RowsAffected(); n == 0 {
+			return pkg.ErrStatementFinalized
+		}
+		if err := queries.DeleteStatementLines(ctx, existing.ID); err != nil {
+			return err
+		}
+		statement.ID = existing.ID
+	}
+
+	for _, line := range statement.Lines {
+		if err := queries.CreateStatementLine(ctx, gen.CreateStatementLineParams{StatementID: statement.ID, ReservationID: line.ReservationID, ServiceDate: pkg.Date(line.ServiceDate), MealTypeID: line.MealTypeID, Attended: line.Attended, Guests: line.Guests, EmployeePrice: int64(line.EmployeePrice), GuestPrice: int64(line.GuestPrice), Subsidy: int64(line.Subsidy), Amount: int64(line.Amount)}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	stored, err := s.FindByID(ctx, statement.ID)
+	if err != nil {
+		return err
+	}
+	*statement = *stored
+	return nil
+}
+
+func (s *statementRepository) FindByID(ctx context.Context, id int64) (*pkg.Statement, error) {
+	statement, err := s.queries.GetStatementByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrStatementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.load(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (s *statementRepository) FindByMonth(ctx context.Context, month time.Time) ([]pkg.Statement, error) {
+	statements, err := s.queries.ListStatementsByMonth(ctx, pkg.Date(month))
+	if err != nil {
+		return nil, err
+	}
+	return s.loadAll(ctx, statements)
+}
+
+func (s *statementRepository) FindByUser(ctx context.Context, userID int64) ([]pkg.Statement, error) {
+	statements, err := s.queries.ListStatementsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadAll(ctx, statements)
+}
+
+func (s *statementRepository) Finalize(ctx context.Context, id int64, at time.Time) error {
+	updated, err := s.queries.FinalizeStatement(ctx, gen.FinalizeStatementParams{ID: id, FinalizedAt: sql.NullTime{Time: at, Valid: true}})
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n > 0 {
+		return nil
+	}
+	if _, err := s.queries.GetStatementByID(ctx, id); err == sql.ErrNoRows {
+		return pkg.ErrStatementNotFound
+	}
+	return pkg.ErrStatementFinalized
+}
+
+func (s *statementRepository) DeleteDraft(ctx context.Context, id int64) error {
+	deleted, err := s.queries.DeleteDraftStatement(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n > 0 {
+		return nil
+	}
+	if _, err := s.queries.GetStatementByID(ctx, id); err == sql.ErrNoRows {
+		return pkg.ErrStatementNotFound
+	}
+	return pkg.ErrStatementFinalized
+}
+
+func (s *statementRepository) loadAll(ctx context.Context, statements []gen.Statement) ([]pkg.Statement, error) {
+	var list []pkg.Statement
+	for _, statement := range statements {
+		found, err := s.load(ctx, statement)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+// load converts a row and fetches its lines.
+func (s *statementRepository) load(ctx context.Context, statement gen.Statement) (pkg.Statement, error) {
+	found := pkg.Statement{ID: statement.ID, UserID: statement.UserID, EmployeeID: statement.EmployeeID, Month: statement.Month, Status: pkg.StatementStatus(statement.Status), Subtotal: pkg.Money(statement.Subtotal), Subsidy: pkg.Money(statement.Subsidy), Total: pkg.Money(statement.Total), CreatedAt: statement.CreatedAt}
+	if statement.FinalizedAt.Valid {
+		found.FinalizedAt = &statement.FinalizedAt.Time
+	}
+
+	lines, err := s.queries.ListStatementLines(ctx, statement.ID)
+	if err != nil {
+		return found, err
+	}
+	for _, line := range lines {
+		found.Lines = append(found.Lines, pkg.StatementLine{ReservationID: line.ReservationID, ServiceDate: line.ServiceDate, MealTypeID: line.MealTypeID, Attended: line.Attended, Guests: line.Guests, EmployeePrice: pkg.Money(line.EmployeePrice), GuestPrice: pkg.Money(line.GuestPrice), Subsidy: pkg.Money(line.Subsidy), Amount: pkg.Money(line.Amount)})
+	}
+	return found, nil
+}
+
+func validTo(validity pkg.Validity) sql.NullTime {
+	if validity.To == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: pkg.Date(*validity.To), Valid: true}
+}
+
+func toValidity(from time.Time, to sql.NullTime) pkg.Validity {
+	validity := pkg.Validity{From: from}
+	if to.Valid {
+		validity.To = &to.Time
+	}
+	return validity
+}
@@ -0,0 +1,154 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type closureRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewClosureRepository(db *sql.DB) pkg.ClosureRepository {
+	return &closureRepository{db: db, queries: gen.New(db)}
+}
+
+func (c *closureRepository) Insert(ctx context.Context, closure *pkg.Closure) ([]pkg.Reservation, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	queries := c.queries.WithTx(tx)
+
+	inserted, err := queries.CreateClosure(ctx, gen.CreateClosureParams{
+		StartDate: pkg.Date(closure.StartDate),
+		EndDate:   pkg.Date(closure.EndDate),
+		Reason:    closure.Reason,
+		SourceUid: sql.NullString{String: closure.SourceUID, Valid: closure.SourceUID != ""},
+	})
+	if isDuplicateEntry(err) {
+		return nil, pkg.ErrClosureAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	closure.ID, _ = inserted.LastInsertId()
+
+	for _, mealTypeID := range closure.MealTypeIDs {
+		if err := queries.CreateClosureMealType(ctx, gen.CreateClosureMealTypeParams{ClosureID: closure.ID, MealTypeID: mealTypeID}); err != nil {
+			return nil, err
+		}
+	}
+
+	reservations, err := queries.ListActiveReservationsBetween(ctx, gen.ListActiveReservationsBetweenParams{FromDate: pkg.Date(closure.StartDate), ToDate: pkg.Date(closure.EndDate)})
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled []pkg.Reservation
+	for _, reservation := range reservations {
+		if !closure.Covers(reservation.ServiceDate, reservation.Type) {
+			continue
+		}
+		if err := queries.CancelReservation(ctx, reservation.ID); err != nil {
+			return nil, err
+		}
+		r, err := loadReservation(ctx, queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		r.Status = pkg.ReservationCancelled
+		r.Version++
+		if err := insertEvent(ctx, queries, pkg.NewReservationEvent(pkg.EventReservationCancelled, r)); err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, r)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	closure.CreatedAt = time.Now()
+	return cancelled, nil
+}
+
+func (c *closureRepository) FindAll(ctx context.Context) ([]pkg.Closure, error) {
+	closures, err := c.queries.ListClosures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mealTypes, err := c.queries.ListClosureMealTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byClosure := make(map[int64][]int64)
+	for _, m := range mealTypes {
+		byClosure[m.ClosureID] = append(byClosure[m.ClosureID], m.MealTypeID)
+	}
+
+	var list []pkg.Closure
+	for _, closure := range closures {
+		list = append(list, toClosure(closure, byClosure[closure.ID]))
+	}
+	return list, nil
+}
+
+func (c *closureRepository) FindByID(ctx context.Context, id int64) (*pkg.Closure, error) {
+	closure, err := c.queries.GetClosureByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrClosureNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	mealTypeIDs, err := c.queries.ListClosureMealTypesByClosureID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	found := toClosure(closure, mealTypeIDs)
+	return &found, nil
+}
+
+func (c *closureRepository) FindCovering(ctx context.Context, date time.Time, mealTypeID int64) (*pkg.Closure, error) {
+	id, err := c.queries.GetCoveringClosureID(ctx, gen.GetCoveringClosureIDParams{Date: pkg.Date(date), MealTypeID: mealTypeID})
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrClosureNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.FindByID(ctx, id)
+}
+
+func (c *closureRepository) FindBySource(ctx context.Context, uid string, startDate time.Time) (*pkg.Closure, error) {
+	id, err := c.queries.GetClosureIDBySource(ctx, gen.GetClosureIDBySourceParams{SourceUid: sql.NullString{String: uid, Valid: true}, StartDate: pkg.Date(startDate)})
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrClosureNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.FindByID(ctx, id)
+}
+
+func (c *closureRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := c.queries.DeleteClosure(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrClosureNotFound
+	}
+	return nil
+}
+
+func toClosure(closure gen.Closure, mealTypeIDs []int64) pkg.Closure {
+	return pkg.Closure{ID: closure.ID, StartDate: closure.StartDate, EndDate: closure.EndDate, MealTypeIDs: mealTypeIDs, Reason: closure.Reason, SourceUID: closure.SourceUid.String, CreatedAt: closure.CreatedAt}
+}
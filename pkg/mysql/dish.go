@@ -0,0 +1,147 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type dishRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewDishRepository(db *sql.DB) pkg.DishRepository {
+	return &dishRepository{db: db, queries: gen.New(db)}
+}
+
+func (d *dishRepository) Insert(ctx context.Context, dish *pkg.Dish) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := d.queries.WithTx(tx)
+
+	inserted, err := queries.CreateDish(ctx, gen.CreateDishParams{Name: dish.Name, Description: dish.Description, Calories: dish.Nutrition.Calories, Protein: dish.Nutrition.Protein, Carbs: dish.Nutrition.Carbs, Fat: dish.Nutrition.Fat})
+	if err != nil {
+		return err
+	}
+	dish.ID, _ = inserted.LastInsertId()
+	if err := insertDishTags(ctx, queries, dish); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *dishRepository) FindAll(ctx context.Context) ([]pkg.Dish, error) {
+	dishes, err := d.queries.ListDishes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Dish
+	for _, dish := range dishes {
+		found, err := loadDish(ctx, d.queries, dish)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+func (d *dishRepository) FindByID(ctx context.Context, id int64) (*pkg.Dish, error) {
+	dish, err := d.queries.GetDishByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrDishNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := loadDish(ctx, d.queries, dish)
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (d *dishRepository) Update(ctx context.Context, dish *pkg.Dish) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := d.queries.WithTx(tx)
+
+	updated, err := queries.UpdateDish(ctx, gen.UpdateDishParams{ID: dish.ID, Name: dish.Name, Description: dish.Description, Calories: dish.Nutrition.Calories, Protein: dish.Nutrition.Protein, Carbs: dish.Nutrition.Carbs, Fat: dish.Nutrition.Fat})
+	if err != nil {
+		return err
+	}
+	// mysql reports zero affected rows when nothing changed, so only a
+	// missing row means the dish does not exist.
+	if n, _ := updated.RowsAffected(); n == 0 {
+		if _, err := queries.GetDishByID(ctx, dish.ID); err == sql.ErrNoRows {
+			return pkg.ErrDishNotFound
+		}
+	}
+	if err := queries.DeleteDishDiets(ctx, dish.ID); err != nil {
+		return err
+	}
+	if err := queries.DeleteDishAllergens(ctx, dish.ID); err != nil {
+		return err
+	}
+	if err := insertDishTags(ctx, queries, dish); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *dishRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := d.queries.DeleteDish(ctx, id)
+	if isRowReferenced(err) {
+		return pkg.ErrDishInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrDishNotFound
+	}
+	return nil
+}
+
+func insertDishTags(ctx context.Context, queries *gen.Queries, dish *pkg.Dish) error {
+	for _, diet := range dish.Diets {
+		if err := queries.CreateDishDiet(ctx, gen.CreateDishDietParams{DishID: dish.ID, Diet: string(diet)}); err != nil {
+			return err
+		}
+	}
+	for _, code := range dish.Allergens {
+		if err := queries.CreateDishAllergen(ctx, gen.CreateDishAllergenParams{DishID: dish.ID, AllergenCode: code}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDish converts a row, fetching its diet and allergen tags.
+func loadDish(ctx context.Context, queries *gen.Queries, dish gen.Dish) (pkg.Dish, error) {
+	found := pkg.Dish{ID: dish.ID, Name: dish.Name, Description: dish.Description, Nutrition: pkg.Nutrition{Calories: dish.Calories, Protein: dish.Protein, Carbs: dish.Carbs, Fat: dish.Fat}, CreatedAt: dish.CreatedAt}
+
+	diets, err := queries.ListDishDiets(ctx, dish.ID)
+	if err != nil {
+		return found, err
+	}
+	for _, diet := range diets {
+		found.Diets = append(found.Diets, pkg.Diet(diet))
+	}
+
+	found.Allergens, err = queries.ListDishAllergens(ctx, dish.ID)
+	if err != nil {
+		return found, err
+	}
+	return found, nil
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"errors"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	// erDupEntry is the server error number for a unique key violation.
+	erDupEntry = 1062
+	// erRowIsReferenced is the server error number for deleting a row that
+	// a foreign key still points at.
+	erRowIsReferenced = 1451
+	// erNoReferencedRow is the server error number for a foreign key that
+	// points at a missing row.
+	erNoReferencedRow = 1452
+)
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDupEntry
+}
+
+func isRowReferenced(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erRowIsReferenced
+}
+
+func isMissingReference(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erNoReferencedRow
+}
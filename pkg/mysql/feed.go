@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type calendarFeedRepository struct {
+	queries *gen.Queries
+}
+
+func NewCalendarFeedRepository(db *sql.DB) pkg.CalendarFeedRepository {
+	return &calendarFeedRepository{queries: gen.New(db)}
+}
+
+func (c *calendarFeedRepository) Save(ctx context.Context, userID int64, tokenHash string) error {
+	err := c.queries.SaveCalendarFeed(ctx, gen.SaveCalendarFeedParams{UserID: userID, TokenHash: tokenHash, CreatedAt: time.Now()})
+	if isMissingReference(err) {
+		return pkg.ErrUserNotFound
+	}
+	return err
+}
+
+func (c *calendarFeedRepository) FindUserByTokenHash(ctx context.Context, tokenHash string) (int64, error) {
+	feed, err := c.queries.GetCalendarFeedByTokenHash(ctx, tokenHash)
+	if err == sql.ErrNoRows {
+		return 0, pkg.ErrFeedNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return feed.UserID, nil
+}
+
+func (c *calendarFeedRepository) Delete(ctx context.Context, userID int64) error {
+	deleted, err := c.queries.DeleteCalendarFeed(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrFeedNotFound
+	}
+	return nil
+}
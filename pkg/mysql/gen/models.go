@@ -5,18 +5,234 @@
 package gen
 
 import (
+	"database/sql"
 	"time"
 )
 
+type Allergen struct {
+	Code string
+	Name string
+}
+
+type CalendarFeed struct {
+	UserID    int64
+	TokenHash string
+	CreatedAt time.Time
+}
+
+type Closure struct {
+	ID        int64
+	StartDate time.Time
+	EndDate   time.Time
+	Reason    string
+	CreatedAt time.Time
+	SourceUid sql.NullString
+}
+
+type ClosureMealType struct {
+	ClosureID  int64
+	MealTypeID int64
+}
+
+type Dish struct {
+	ID          int64
+	Name        string
+	Description string
+	Calories    int64
+	Protein     int64
+	Carbs       int64
+	Fat         int64
+	CreatedAt   time.Time
+}
+
+type DishAllergen struct {
+	DishID       int64
+	AllergenCode string
+}
+
+type DishDiet struct {
+	DishID int64
+	Diet   string
+}
+
+type DishRating struct {
+	ID            int64
+	ReservationID int64
+	DishID        int64
+	Stars         int8
+	Comment       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type IdempotencyKey struct {
+	KeyHash     string
+	Fingerprint string
+	Owner       string
+	StatusCode  int32
+	Header      []byte
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type LedgerEntry struct {
+	ID            int64
+	TransactionID int64
+	Account       string
+	Amount        int64
+}
+
+type LedgerTransaction struct {
+	ID            int64
+	UserID        int64
+	Kind          string
+	Amount        int64
+	ReservationID sql.NullInt64
+	Description   string
+	CreatedAt     time.Time
+}
+
+type MealPrice struct {
+	ID            int64
+	MealTypeID    int64
+	EmployeePrice int64
+	GuestPrice    int64
+	ValidFrom     time.Time
+	ValidTo       sql.NullTime
+	CreatedAt     time.Time
+}
+
+type MealType struct {
+	ID              int64
+	Code            string
+	Name            string
+	ServingStart    int64
+	ServingEnd      int64
+	ActiveDays      int64
+	DefaultCapacity int64
+	Active          bool
+	CreatedAt       time.Time
+	SiteID          sql.NullInt64
+}
+
+type Menu struct {
+	ID          int64
+	MenuDate    time.Time
+	MealTypeID  int64
+	Published   bool
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+}
+
+type MenuDish struct {
+	MenuID   int64
+	DishID   int64
+	Position int64
+}
+
+type NotificationPreference struct {
+	UserID        int64
+	Email         string
+	MealReminders bool
+	BookingNudges bool
+	UpdatedAt     time.Time
+}
+
+type Outbox struct {
+	ID            int64
+	EventID       string
+	EventType     string
+	AggregateType string
+	AggregateID   int64
+	Data          []byte
+	OccurredAt    time.Time
+	Attempts      int64
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   sql.NullTime
+}
+
 type Reservation struct {
+	ID                int64
+	UserID            int64
+	ReservationTime   time.Time
+	Type              int64
+	NoOfGuests        int64
+	CreatedAt         time.Time
+	ServiceDate       time.Time
+	Version           int64
+	Status            string
+	ActiveServiceDate sql.NullTime
+	DietOverride      sql.NullString
+	CheckedInAt       sql.NullTime
+	SiteID            int64
+}
+
+type ReservationAllergen struct {
+	ReservationID int64
+	AllergenCode  string
+}
+
+type SentReminder struct {
+	Kind        string
+	UserID      int64
+	MealTypeID  int64
+	ServiceDate time.Time
+	SentAt      time.Time
+}
+
+type Site struct {
 	ID              int64
-	UserID          int64
-	ReservationTime time.Time
-	Type            int64
-	NoOfGuests      int64
+	Name            string
+	Timezone        string
+	Address         string
+	DefaultCapacity int64
 	CreatedAt       time.Time
 }
 
+type SiteManager struct {
+	SiteID int64
+	UserID int64
+}
+
+type Statement struct {
+	ID          int64
+	UserID      int64
+	Month       time.Time
+	Status      string
+	Subtotal    int64
+	Subsidy     int64
+	Total       int64
+	CreatedAt   time.Time
+	FinalizedAt sql.NullTime
+	EmployeeID  string
+}
+
+type StatementLine struct {
+	ID            int64
+	StatementID   int64
+	ReservationID int64
+	ServiceDate   time.Time
+	MealTypeID    int64
+	Attended      bool
+	Guests        int64
+	EmployeePrice int64
+	GuestPrice    int64
+	Subsidy       int64
+	Amount        int64
+}
+
+type SubsidyRule struct {
+	ID          int64
+	Designation string
+	Department  string
+	Percent     int64
+	ValidFrom   time.Time
+	ValidTo     sql.NullTime
+	CreatedAt   time.Time
+}
+
 type User struct {
 	ID          int64
 	Name        string
@@ -24,4 +240,51 @@ type User struct {
 	Designation string
 	EmployeeID  string
 	CreatedAt   time.Time
+	Version     int64
+	Department  string
+	Diet        string
+	SiteID      int64
+}
+
+type UserAllergen struct {
+	UserID       int64
+	AllergenCode string
+}
+
+type Wallet struct {
+	UserID    int64
+	CreatedAt time.Time
+}
+
+type WalletHold struct {
+	ID            int64
+	UserID        int64
+	ReservationID int64
+	Amount        int64
+	Status        string
+	CreatedAt     time.Time
+	SettledAt     sql.NullTime
+}
+
+type Webhook struct {
+	ID         int64
+	Url        string
+	EventTypes string
+	Secret     string
+	CreatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	ResponseStatus int64
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
 }
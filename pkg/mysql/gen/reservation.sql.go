@@ -11,11 +11,53 @@ import (
 	"time"
 )
 
+const cancelReservation = `-- name: CancelReservation :exec
+UPDATE reservations SET status = 'cancelled', version = version + 1
+WHERE id = ?
+`
+
+func (q *Queries) CancelReservation(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, cancelReservation, id)
+	return err
+}
+
+const checkInReservation = `-- name: CheckInReservation :execresult
+UPDATE reservations SET checked_in_at = ?, version = version + 1
+WHERE id = ? AND status = 'active' AND checked_in_at IS NULL
+`
+
+type CheckInReservationParams struct {
+	CheckedInAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) CheckInReservation(ctx context.Context, arg CheckInReservationParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, checkInReservation, arg.CheckedInAt, arg.ID)
+}
+
+const countSlotPlates = `-- name: CountSlotPlates :one
+SELECT CAST(COALESCE(SUM(1 + no_of_guests), 0) AS SIGNED) FROM reservations
+WHERE service_date = ? AND type = ? AND site_id = ? AND status = 'active'
+`
+
+type CountSlotPlatesParams struct {
+	ServiceDate time.Time
+	Type        int64
+	SiteID      int64
+}
+
+func (q *Queries) CountSlotPlates(ctx context.Context, arg CountSlotPlatesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSlotPlates, arg.ServiceDate, arg.Type, arg.SiteID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const createReservation = `-- name: CreateReservation :execresult
 INSERT INTO reservations (
-    user_id, reservation_time, type, no_of_guests, created_at
+    user_id, reservation_time, type, no_of_guests, created_at, diet_override, site_id, service_date
 ) VALUES (
-    ?, ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?, ?, ?
 )
 `
 
@@ -25,6 +67,9 @@ type CreateReservationParams struct {
 	Type            int64
 	NoOfGuests      int64
 	CreatedAt       time.Time
+	DietOverride    sql.NullString
+	SiteID          int64
+	ServiceDate     time.Time
 }
 
 func (q *Queries) CreateReservation(ctx context.Context, arg CreateReservationParams) (sql.Result, error) {
@@ -34,21 +79,28 @@ func (q *Queries) CreateReservation(ctx context.Context, arg CreateReservationPa
 		arg.Type,
 		arg.NoOfGuests,
 		arg.CreatedAt,
+		arg.DietOverride,
+		arg.SiteID,
+		arg.ServiceDate,
 	)
 }
 
-const deleteReservation = `-- name: DeleteReservation :exec
+const deleteReservation = `-- name: DeleteReservation :execresult
 DELETE FROM reservations
-where id = ?
+WHERE id = ? AND (? = 0 OR version = ?)
 `
 
-func (q *Queries) DeleteReservation(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteReservation, id)
-	return err
+type DeleteReservationParams struct {
+	ID              int64
+	ExpectedVersion int64
+}
+
+func (q *Queries) DeleteReservation(ctx context.Context, arg DeleteReservationParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteReservation, arg.ID, arg.ExpectedVersion, arg.ExpectedVersion)
 }
 
 const getReservationByID = `-- name: GetReservationByID :one
-SELECT id, user_id, reservation_time, type, no_of_guests, created_at FROM reservations
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
 WHERE id = ? LIMIT 1
 `
 
@@ -62,17 +114,43 @@ func (q *Queries) GetReservationByID(ctx context.Context, id int64) (Reservation
 		&i.Type,
 		&i.NoOfGuests,
 		&i.CreatedAt,
+		&i.ServiceDate,
+		&i.Version,
+		&i.Status,
+		&i.ActiveServiceDate,
+		&i.DietOverride,
+		&i.CheckedInAt,
+		&i.SiteID,
 	)
 	return i, err
 }
 
+const getReservationIDBySlot = `-- name: GetReservationIDBySlot :one
+SELECT id FROM reservations
+WHERE user_id = ? AND type = ? AND active_service_date = ? LIMIT 1
+`
+
+type GetReservationIDBySlotParams struct {
+	UserID            int64
+	Type              int64
+	ActiveServiceDate sql.NullTime
+}
+
+func (q *Queries) GetReservationIDBySlot(ctx context.Context, arg GetReservationIDBySlotParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReservationIDBySlot, arg.UserID, arg.Type, arg.ActiveServiceDate)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getReservationsByDate = `-- name: GetReservationsByDate :many
-SELECT id, user_id, reservation_time, type, no_of_guests, created_at FROM reservations
-where reservation_time = ?
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
+WHERE service_date = ?
+ORDER BY id
 `
 
-func (q *Queries) GetReservationsByDate(ctx context.Context, reservationTime time.Time) ([]Reservation, error) {
-	rows, err := q.db.QueryContext(ctx, getReservationsByDate, reservationTime)
+func (q *Queries) GetReservationsByDate(ctx context.Context, serviceDate time.Time) ([]Reservation, error) {
+	rows, err := q.db.QueryContext(ctx, getReservationsByDate, serviceDate)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +165,13 @@ func (q *Queries) GetReservationsByDate(ctx context.Context, reservationTime tim
 			&i.Type,
 			&i.NoOfGuests,
 			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
 		); err != nil {
 			return nil, err
 		}
@@ -102,7 +187,7 @@ func (q *Queries) GetReservationsByDate(ctx context.Context, reservationTime tim
 }
 
 const getReservationsByEmployeeID = `-- name: GetReservationsByEmployeeID :many
-SELECT id, user_id, reservation_time, type, no_of_guests, created_at FROM reservations
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
 WHERE user_id = ?
 `
 
@@ -122,6 +207,13 @@ func (q *Queries) GetReservationsByEmployeeID(ctx context.Context, userID int64)
 			&i.Type,
 			&i.NoOfGuests,
 			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
 		); err != nil {
 			return nil, err
 		}
@@ -136,17 +228,351 @@ func (q *Queries) GetReservationsByEmployeeID(ctx context.Context, userID int64)
 	return items, nil
 }
 
-const updateReservation = `-- name: UpdateReservation :exec
-UPDATE reservations SET no_of_guests = ?
-WHERE id = ?
+const headcount = `-- name: Headcount :many
+SELECT r.service_date,
+    IF(?, r.type, 0) AS meal_type_id,
+    IF(?, r.site_id, 0) AS site,
+    IF(?, COALESCE(u.department, ''), '') AS department_name,
+    IF(?, COALESCE(r.diet_override, u.diet, 'omnivore'), '') AS diet_name,
+    IF(?, COALESCE(ra.allergen_code, ua.allergen_code, ''), '') AS allergen,
+    COUNT(*) AS employees,
+    CAST(SUM(r.no_of_guests) AS SIGNED) AS guests
+FROM reservations r
+LEFT JOIN users u ON u.id = r.user_id
+LEFT JOIN reservation_allergens ra ON ? AND r.diet_override IS NOT NULL AND ra.reservation_id = r.id
+LEFT JOIN user_allergens ua ON ? AND r.diet_override IS NULL AND ua.user_id = r.user_id
+WHERE r.service_date BETWEEN ? AND ? AND r.status = 'active'
+    AND (? = '' OR FIND_IN_SET(r.site_id, ?))
+GROUP BY r.service_date, meal_type_id, site, department_name, diet_name, allergen
+ORDER BY r.service_date, meal_type_id, site, department_name, diet_name, allergen
+`
+
+type HeadcountParams struct {
+	ByMeal       bool
+	BySite       bool
+	ByDepartment bool
+	ByDiet       bool
+	ByAllergen   bool
+	FromDate     time.Time
+	ToDate       time.Time
+	Sites        string
+}
+
+type HeadcountRow struct {
+	ServiceDate    time.Time
+	MealTypeID     int64
+	Site           int64
+	DepartmentName string
+	DietName       string
+	Allergen       string
+	Employees      int64
+	Guests         int64
+}
+
+func (q *Queries) Headcount(ctx context.Context, arg HeadcountParams) ([]HeadcountRow, error) {
+	rows, err := q.db.QueryContext(ctx, headcount,
+		arg.ByMeal,
+		arg.BySite,
+		arg.ByDepartment,
+		arg.ByDiet,
+		arg.ByAllergen,
+		arg.ByAllergen,
+		arg.ByAllergen,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Sites,
+		arg.Sites,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []HeadcountRow{}
+	for rows.Next() {
+		var i HeadcountRow
+		if err := rows.Scan(
+			&i.ServiceDate,
+			&i.MealTypeID,
+			&i.Site,
+			&i.DepartmentName,
+			&i.DietName,
+			&i.Allergen,
+			&i.Employees,
+			&i.Guests,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveReservationsBetween = `-- name: ListActiveReservationsBetween :many
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
+WHERE service_date BETWEEN ? AND ? AND status = 'active'
+ORDER BY service_date, id
+`
+
+type ListActiveReservationsBetweenParams struct {
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+func (q *Queries) ListActiveReservationsBetween(ctx context.Context, arg ListActiveReservationsBetweenParams) ([]Reservation, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveReservationsBetween, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Reservation{}
+	for rows.Next() {
+		var i Reservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ReservationTime,
+			&i.Type,
+			&i.NoOfGuests,
+			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveReservationsForMeal = `-- name: ListActiveReservationsForMeal :many
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
+WHERE service_date = ? AND type = ? AND status = 'active'
+ORDER BY id
+`
+
+type ListActiveReservationsForMealParams struct {
+	ServiceDate time.Time
+	Type        int64
+}
+
+func (q *Queries) ListActiveReservationsForMeal(ctx context.Context, arg ListActiveReservationsForMealParams) ([]Reservation, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveReservationsForMeal, arg.ServiceDate, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Reservation{}
+	for rows.Next() {
+		var i Reservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ReservationTime,
+			&i.Type,
+			&i.NoOfGuests,
+			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRegularDiners = `-- name: ListRegularDiners :many
+SELECT user_id FROM reservations
+WHERE type = ? AND status = 'active'
+    AND service_date BETWEEN ? AND ?
+    AND DAYOFWEEK(service_date) = DAYOFWEEK(?)
+GROUP BY user_id
+HAVING COUNT(*) >= ?
+ORDER BY user_id
+`
+
+type ListRegularDinersParams struct {
+	Type     int64
+	FromDate time.Time
+	ToDate   time.Time
+	MinMeals int64
+}
+
+func (q *Queries) ListRegularDiners(ctx context.Context, arg ListRegularDinersParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listRegularDiners,
+		arg.Type,
+		arg.FromDate,
+		arg.ToDate,
+		arg.ToDate,
+		arg.MinMeals,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var user_id int64
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReservations = `-- name: ListReservations :many
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
+ORDER BY service_date, id
+`
+
+func (q *Queries) ListReservations(ctx context.Context) ([]Reservation, error) {
+	rows, err := q.db.QueryContext(ctx, listReservations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Reservation{}
+	for rows.Next() {
+		var i Reservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ReservationTime,
+			&i.Type,
+			&i.NoOfGuests,
+			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUpcomingReservationsByUser = `-- name: ListUpcomingReservationsByUser :many
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, service_date, version, status, active_service_date, diet_override, checked_in_at, site_id FROM reservations
+WHERE user_id = ? AND service_date >= ?
+ORDER BY service_date, type
+`
+
+type ListUpcomingReservationsByUserParams struct {
+	UserID   int64
+	FromDate time.Time
+}
+
+func (q *Queries) ListUpcomingReservationsByUser(ctx context.Context, arg ListUpcomingReservationsByUserParams) ([]Reservation, error) {
+	rows, err := q.db.QueryContext(ctx, listUpcomingReservationsByUser, arg.UserID, arg.FromDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Reservation{}
+	for rows.Next() {
+		var i Reservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ReservationTime,
+			&i.Type,
+			&i.NoOfGuests,
+			&i.CreatedAt,
+			&i.ServiceDate,
+			&i.Version,
+			&i.Status,
+			&i.ActiveServiceDate,
+			&i.DietOverride,
+			&i.CheckedInAt,
+			&i.SiteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateReservation = `-- name: UpdateReservation :execresult
+UPDATE reservations SET user_id = ?, reservation_time = ?, type = ?, no_of_guests = ?, diet_override = ?, site_id = ?, service_date = ?, version = version + 1
+WHERE id = ? AND (? = 0 OR version = ?)
 `
 
 type UpdateReservationParams struct {
-	NoOfGuests int64
-	ID         int64
+	UserID          int64
+	ReservationTime time.Time
+	Type            int64
+	NoOfGuests      int64
+	DietOverride    sql.NullString
+	SiteID          int64
+	ServiceDate     time.Time
+	ID              int64
+	ExpectedVersion int64
 }
 
-func (q *Queries) UpdateReservation(ctx context.Context, arg UpdateReservationParams) error {
-	_, err := q.db.ExecContext(ctx, updateReservation, arg.NoOfGuests, arg.ID)
-	return err
+func (q *Queries) UpdateReservation(ctx context.Context, arg UpdateReservationParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateReservation,
+		arg.UserID,
+		arg.ReservationTime,
+		arg.Type,
+		arg.NoOfGuests,
+		arg.DietOverride,
+		arg.SiteID,
+		arg.ServiceDate,
+		arg.ID,
+		arg.ExpectedVersion,
+		arg.ExpectedVersion,
+	)
 }
@@ -12,9 +12,9 @@ import (
 
 const createUser = `-- name: CreateUser :execresult
 INSERT INTO users(
-    name, password, designation, employee_id
+    name, password, designation, department, employee_id, diet, site_id
 ) VALUES (
-    ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?, ?
 )
 `
 
@@ -22,7 +22,10 @@ type CreateUserParams struct {
 	Name        string
 	Password    string
 	Designation string
+	Department  string
 	EmployeeID  string
+	Diet        string
+	SiteID      int64
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Result, error) {
@@ -30,22 +33,29 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Res
 		arg.Name,
 		arg.Password,
 		arg.Designation,
+		arg.Department,
 		arg.EmployeeID,
+		arg.Diet,
+		arg.SiteID,
 	)
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execresult
 DELETE FROM users
-where id = ?
+WHERE id = ? AND (? = 0 OR version = ?)
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
-	return err
+type DeleteUserParams struct {
+	ID              int64
+	ExpectedVersion int64
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteUser, arg.ID, arg.ExpectedVersion, arg.ExpectedVersion)
 }
 
 const getUserByEmployeeID = `-- name: GetUserByEmployeeID :one
-SELECT id, name, password, designation, employee_id, created_at FROM users
+SELECT id, name, password, designation, employee_id, created_at, version, department, diet, site_id FROM users
 WHERE employee_id = ? LIMIT 1
 `
 
@@ -59,12 +69,16 @@ func (q *Queries) GetUserByEmployeeID(ctx context.Context, employeeID string) (U
 		&i.Designation,
 		&i.EmployeeID,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Department,
+		&i.Diet,
+		&i.SiteID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, password, designation, employee_id, created_at FROM users
+SELECT id, name, password, designation, employee_id, created_at, version, department, diet, site_id FROM users
 WHERE id = ? LIMIT 1
 `
 
@@ -78,12 +92,16 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.Designation,
 		&i.EmployeeID,
 		&i.CreatedAt,
+		&i.Version,
+		&i.Department,
+		&i.Diet,
+		&i.SiteID,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, password, designation, employee_id, created_at FROM users
+SELECT id, name, password, designation, employee_id, created_at, version, department, diet, site_id FROM users
 ORDER BY name
 `
 
@@ -103,6 +121,10 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.Designation,
 			&i.EmployeeID,
 			&i.CreatedAt,
+			&i.Version,
+			&i.Department,
+			&i.Diet,
+			&i.SiteID,
 		); err != nil {
 			return nil, err
 		}
@@ -117,17 +139,34 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
-const updateUser = `-- name: UpdateUser :exec
-UPDATE users SET password = ?
-WHERE id = ?
+const updateUser = `-- name: UpdateUser :execresult
+UPDATE users SET name = ?, password = ?, designation = ?, department = ?, employee_id = ?, diet = ?, site_id = ?, version = version + 1
+WHERE id = ? AND (? = 0 OR version = ?)
 `
 
 type UpdateUserParams struct {
-	Password string
-	ID       int64
+	Name            string
+	Password        string
+	Designation     string
+	Department      string
+	EmployeeID      string
+	Diet            string
+	SiteID          int64
+	ID              int64
+	ExpectedVersion int64
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.ExecContext(ctx, updateUser, arg.Password, arg.ID)
-	return err
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateUser,
+		arg.Name,
+		arg.Password,
+		arg.Designation,
+		arg.Department,
+		arg.EmployeeID,
+		arg.Diet,
+		arg.SiteID,
+		arg.ID,
+		arg.ExpectedVersion,
+		arg.ExpectedVersion,
+	)
 }
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type idempotencyRepository struct {
+	queries *gen.Queries
+}
+
+func NewIdempotencyRepository(db *sql.DB) pkg.IdempotencyRepository {
+	return &idempotencyRepository{queries: gen.New(db)}
+}
+
+// Begin relies on the primary key to let only one of several requests with
+// the same key in. An expired request is removed first to make room.
+func (i *idempotencyRepository) Begin(ctx context.Context, request pkg.IdempotentRequest) (*pkg.IdempotentRequest, error) {
+	err := i.queries.DeleteExpiredIdempotencyKey(ctx, gen.DeleteExpiredIdempotencyKeyParams{KeyHash: request.KeyHash, ExpiresAt: request.CreatedAt})
+	if err != nil {
+		return nil, err
+	}
+	err = i.queries.CreateIdempotencyKey(ctx, gen.CreateIdempotencyKeyParams{KeyHash: request.KeyHash, Fingerprint: request.Fingerprint, Owner: request.Owner, CreatedAt: request.CreatedAt, ExpiresAt: request.ExpiresAt})
+	if err == nil {
+		return nil, nil
+	}
+	if !isDuplicateEntry(err) {
+		return nil, err
+	}
+
+	stored, err := i.queries.GetIdempotencyKey(ctx, request.KeyHash)
+	if err == sql.ErrNoRows {
+		// the request holding the key gave it up meanwhile.
+		return i.Begin(ctx, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toIdempotentRequest(stored)
+}
+
+func (i *idempotencyRepository) Complete(ctx context.Context, request pkg.IdempotentRequest) error {
+	header, err := json.Marshal(request.Header)
+	if err != nil {
+		return err
+	}
+	return i.queries.CompleteIdempotencyKey(ctx, gen.CompleteIdempotencyKeyParams{StatusCode: int32(request.StatusCode), Header: header, Body: request.Body, ExpiresAt: request.ExpiresAt, KeyHash: request.KeyHash, Owner: request.Owner})
+}
+
+func (i *idempotencyRepository) Renew(ctx context.Context, keyHash, owner string, expiresAt time.Time) error {
+	return i.queries.RenewIdempotencyKey(ctx, gen.RenewIdempotencyKeyParams{ExpiresAt: expiresAt, KeyHash: keyHash, Owner: owner})
+}
+
+func (i *idempotencyRepository) Delete(ctx context.Context, keyHash, owner string) error {
+	return i.queries.DeleteIdempotencyKey(ctx, gen.DeleteIdempotencyKeyParams{KeyHash: keyHash, Owner: owner})
+}
+
+func (i *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time, limit int64) (int64, error) {
+	deleted, err := i.queries.DeleteExpiredIdempotencyKeys(ctx, gen.DeleteExpiredIdempotencyKeysParams{ExpiresAt: before, Limit: int32(limit)})
+	if err != nil {
+		return 0, err
+	}
+	return deleted.RowsAffected()
+}
+
+func toIdempotentRequest(key gen.IdempotencyKey) (*pkg.IdempotentRequest, error) {
+	request := &pkg.IdempotentRequest{KeyHash: key.KeyHash, Fingerprint: key.Fingerprint, Owner: key.Owner, StatusCode: int(key.StatusCode), Body: key.Body, CreatedAt: key.CreatedAt, ExpiresAt: key.ExpiresAt}
+	// the header is only written once the request completes.
+	if len(key.Header) > 0 {
+		if err := json.Unmarshal(key.Header, &request.Header); err != nil {
+			return nil, err
+		}
+	}
+	return request, nil
+}
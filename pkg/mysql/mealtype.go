@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type mealTypeRepository struct {
+	queries *gen.Queries
+}
+
+func NewMealTypeRepository(db *sql.DB) pkg.MealTypeRepository {
+	return &mealTypeRepository{queries: gen.New(db)}
+}
+
+func (m *mealTypeRepository) Insert(ctx context.Context, mealType *pkg.MealType) error {
+	inserted, err := m.queries.CreateMealType(ctx, gen.CreateMealTypeParams{Code: mealType.Code, Name: mealType.Name, ServingStart: int64(mealType.ServingStart), ServingEnd: int64(mealType.ServingEnd), ActiveDays: int64(mealType.ActiveDays), DefaultCapacity: mealType.DefaultCapacity, Active: mealType.Active, SiteID: mealTypeSite(mealType)})
+	if isDuplicateEntry(err) {
+		return pkg.ErrMealTypeAlreadyExists
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return err
+	}
+	mealType.ID, _ = inserted.LastInsertId()
+	return nil
+}
+
+func (m *mealTypeRepository) FindAll(ctx context.Context) ([]pkg.MealType, error) {
+	mealTypes, err := m.queries.ListMealTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.MealType
+	for _, mealType := range mealTypes {
+		list = append(list, toMealType(mealType))
+	}
+	return list, nil
+}
+
+func (m *mealTypeRepository) FindByID(ctx context.Context, id int64) (*pkg.MealType, error) {
+	mealType, err := m.queries.GetMealTypeByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrMealTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := toMealType(mealType)
+	return &found, nil
+}
+
+func (m *mealTypeRepository) Update(ctx context.Context, mealType *pkg.MealType) error {
+	updated, err := m.queries.UpdateMealType(ctx, gen.UpdateMealTypeParams{ID: mealType.ID, Code: mealType.Code, Name: mealType.Name, ServingStart: int64(mealType.ServingStart), ServingEnd: int64(mealType.ServingEnd), ActiveDays: int64(mealType.ActiveDays), DefaultCapacity: mealType.DefaultCapacity, Active: mealType.Active, SiteID: mealTypeSite(mealType)})
+	if isDuplicateEntry(err) {
+		return pkg.ErrMealTypeAlreadyExists
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return err
+	}
+	// mysql reports zero affected rows when nothing changed, so only a
+	// missing row means the meal type does not exist.
+	if n, _ := updated.RowsAffected(); n == 0 {
+		if _, err := m.queries.GetMealTypeByID(ctx, mealType.ID); err == sql.ErrNoRows {
+			return pkg.ErrMealTypeNotFound
+		}
+	}
+	return nil
+}
+
+func (m *mealTypeRepository) DeleteByID(ctx context.Context, id int64) error {
+	count, err := m.queries.CountReservationsByMealType(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return pkg.ErrMealTypeInUse
+	}
+
+	deleted, err := m.queries.DeleteMealType(ctx, id)
+	if isRowReferenced(err) {
+		return pkg.ErrMealTypeInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrMealTypeNotFound
+	}
+	return nil
+}
+
+func toMealType(mealType gen.MealType) pkg.MealType {
+	found := pkg.MealType{ID: mealType.ID, Code: mealType.Code, Name: mealType.Name, ServingStart: pkg.TimeOfDay(mealType.ServingStart), ServingEnd: pkg.TimeOfDay(mealType.ServingEnd), ActiveDays: pkg.Weekdays(mealType.ActiveDays), DefaultCapacity: mealType.DefaultCapacity, Active: mealType.Active, CreatedAt: mealType.CreatedAt}
+	if mealType.SiteID.Valid {
+		found.SiteID = &mealType.SiteID.Int64
+	}
+	return found
+}
+
+// mealTypeSite is the stored form of the site of a meal type; a NULL site_id
+// means every site serves it.
+func mealTypeSite(mealType *pkg.MealType) sql.NullInt64 {
+	if mealType.SiteID == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *mealType.SiteID, Valid: true}
+}
@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type menuRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewMenuRepository(db *sql.DB) pkg.MenuRepository {
+	return &menuRepository{db: db, queries: gen.New(db)}
+}
+
+func (m *menuRepository) Insert(ctx context.Context, menu *pkg.Menu) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := m.queries.WithTx(tx)
+
+	inserted, err := queries.CreateMenu(ctx, gen.CreateMenuParams{MenuDate: pkg.Date(menu.Date), MealTypeID: menu.MealTypeID})
+	if isDuplicateEntry(err) {
+		return pkg.ErrMenuAlreadyExists
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownMealType
+	}
+	if err != nil {
+		return err
+	}
+	menu.ID, _ = inserted.LastInsertId()
+	if err := insertMenuDishes(ctx, queries, menu); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	stored, err := m.FindByID(ctx, menu.ID)
+	if err != nil {
+		return err
+	}
+	*menu = *stored
+	return nil
+}
+
+func (m *menuRepository) FindByID(ctx context.Context, id int64) (*pkg.Menu, error) {
+	menu, err := m.queries.GetMenuByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrMenuNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := m.load(ctx, menu)
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (m *menuRepository) FindByDate(ctx context.Context, date time.Time) ([]pkg.Menu, error) {
+	menus, err := m.queries.ListMenusByDate(ctx, pkg.Date(date))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Menu
+	for _, menu := range menus {
+		found, err := m.load(ctx, menu)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+func (m *menuRepository) FindForMeal(ctx context.Context, date time.Time, mealTypeID int64) (*pkg.Menu, error) {
+	id, err := m.queries.GetMenuIDForMeal(ctx, gen.GetMenuIDForMealParams{MenuDate: pkg.Date(date), MealTypeID: mealTypeID})
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrMenuNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.FindByID(ctx, id)
+}
+
+func (m *menuRepository) FindPublishedWithDish(ctx context.Context, dishID int64, from time.Time) ([]pkg.Menu, error) {
+	menus, err := m.queries.ListPublishedMenusWithDish(ctx, gen.ListPublishedMenusWithDishParams{DishID: dishID, MenuDate: pkg.Date(from)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Menu
+	for _, menu := range menus {
+		found, err := m.load(ctx, menu)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+// Update replaces the day, meal type and dishes of a menu. Whether it is
+// published is left alone.
+func (m *menuRepository) Update(ctx context.Context, menu *pkg.Menu) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := m.queries.WithTx(tx)
+
+	updated, err := queries.UpdateMenu(ctx, gen.UpdateMenuParams{ID: menu.ID, MenuDate: pkg.Date(menu.Date), MealTypeID: menu.MealTypeID})
+	if isDuplicateEntry(err) {
+		return pkg.ErrMenuAlreadyExists
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownMealType
+	}
+	if err != nil {
+		return err
+	}
+	// mysql reports zero affected rows when nothing changed, so only a
+	// missing row means the menu does not exist.
+	if n, _ := updated.RowsAffected(); n == 0 {
+		if _, err := queries.GetMenuByID(ctx, menu.ID); err == sql.ErrNoRows {
+			return pkg.ErrMenuNotFound
+		}
+	}
+	if err := queries.DeleteMenuDishes(ctx, menu.ID); err != nil {
+		return err
+	}
+	if err := insertMenuDishes(ctx, queries, menu); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	stored, err := m.FindByID(ctx, menu.ID)
+	if err != nil {
+		return err
+	}
+	*menu = *stored
+	return nil
+}
+
+func (m *menuRepository) SetPublished(ctx context.Context, id int64, published bool) error {
+	publishedAt := sql.NullTime{Time: time.Now(), Valid: published}
+	updated, err := m.queries.SetMenuPublished(ctx, gen.SetMenuPublishedParams{ID: id, Published: published, PublishedAt: publishedAt})
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n == 0 {
+		if _, err := m.queries.GetMenuByID(ctx, id); err == sql.ErrNoRows {
+			return pkg.ErrMenuNotFound
+		}
+	}
+	return nil
+}
+
+func (m *menuRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := m.queries.DeleteMenu(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrMenuNotFound
+	}
+	return nil
+}
+
+func insertMenuDishes(ctx context.Context, queries *gen.Queries, menu *pkg.Menu) error {
+	for i, dish := range menu.Dishes {
+		err := queries.CreateMenuDish(ctx, gen.CreateMenuDishParams{MenuID: menu.ID, DishID: dish.ID, Position: int64(i)})
+		if isMissingReference(err) {
+			return pkg.ErrUnknownDish
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load converts a row, fetching its dishes in the order they are served.
+func (m *menuRepository) load(ctx context.Context, menu gen.Menu) (pkg.Menu, error) {
+	found := pkg.Menu{ID: menu.ID, Date: menu.MenuDate, MealTypeID: menu.MealTypeID, Published: menu.Published, PublishedAt: menu.PublishedAt.Time, CreatedAt: menu.CreatedAt}
+
+	dishIDs, err := m.queries.ListMenuDishIDs(ctx, menu.ID)
+	if err != nil {
+		return found, err
+	}
+	for _, id := range dishIDs {
+		dish, err := m.queries.GetDishByID(ctx, id)
+		if err != nil {
+			return found, err
+		}
+		loaded, err := loadDish(ctx, m.queries, dish)
+		if err != nil {
+			return found, err
+		}
+		found.Dishes = append(found.Dishes, loaded)
+	}
+	return found, nil
+}
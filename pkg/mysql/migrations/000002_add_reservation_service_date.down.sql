@@ -0,0 +1,8 @@
+ALTER TABLE reservations
+    DROP KEY reservations_user_type_service_date,
+    DROP COLUMN service_date;
+
+INSERT INTO reservations
+SELECT * FROM reservation_duplicates;
+
+DROP TABLE IF EXISTS reservation_duplicates;
@@ -0,0 +1,27 @@
+-- reservation_time must not follow the row's last update, otherwise the
+-- derived service date drifts every time a reservation is touched.
+ALTER TABLE reservations
+    MODIFY reservation_time TIMESTAMP NOT NULL,
+    MODIFY created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- the unique key below only allows one reservation per slot. The newer
+-- reservations of a duplicated slot are moved aside rather than deleted, for
+-- an operator to decide which to keep. The down migration puts them back.
+CREATE TABLE IF NOT EXISTS reservation_duplicates LIKE reservations;
+
+INSERT INTO reservation_duplicates
+SELECT * FROM reservations newer
+WHERE EXISTS (
+    SELECT 1 FROM reservations older
+    WHERE older.user_id = newer.user_id
+        AND older.type = newer.type
+        AND DATE(older.reservation_time) = DATE(newer.reservation_time)
+        AND older.id < newer.id
+);
+
+DELETE FROM reservations
+WHERE id IN (SELECT id FROM reservation_duplicates);
+
+ALTER TABLE reservations
+    ADD COLUMN service_date DATE AS (DATE(reservation_time)) STORED,
+    ADD UNIQUE KEY reservations_user_type_service_date (user_id, type, service_date);
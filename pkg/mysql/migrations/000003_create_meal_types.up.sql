@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS meal_types (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(32) NOT NULL,
+    name text NOT NULL,
+    serving_start BIGINT NOT NULL,
+    serving_end BIGINT NOT NULL,
+    active_days BIGINT NOT NULL,
+    default_capacity BIGINT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY meal_types_code (code)
+);
+
+-- serving times are minutes since midnight and active_days is a bitmask with
+-- sunday as bit 0. ids 1-3 match the old hardcoded reservation types.
+INSERT INTO meal_types (id, code, name, serving_start, serving_end, active_days, default_capacity) VALUES
+    (1, 'breakfast', 'Breakfast', 420, 570, 127, 0),
+    (2, 'lunch', 'Lunch', 720, 870, 127, 0),
+    (3, 'dinner', 'Dinner', 1170, 1320, 127, 0);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE reservations ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
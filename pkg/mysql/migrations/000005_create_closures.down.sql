@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS closure_meal_types;
+
+DROP TABLE IF EXISTS closures;
+
+-- without a status, cancelled reservations would count as booked again and
+-- clash with those made since. They are moved aside rather than deleted, and
+-- the up migration puts them back.
+CREATE TABLE IF NOT EXISTS cancelled_reservations LIKE reservations;
+
+INSERT INTO cancelled_reservations (id, user_id, reservation_time, type, no_of_guests, created_at, version, status)
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, version, status
+FROM reservations WHERE status = 'cancelled';
+
+DELETE FROM reservations WHERE status = 'cancelled';
+
+ALTER TABLE reservations
+    DROP KEY reservations_user_type_active_service_date,
+    DROP COLUMN active_service_date,
+    DROP COLUMN status,
+    ADD UNIQUE KEY reservations_user_type_service_date (user_id, type, service_date);
@@ -0,0 +1,31 @@
+ALTER TABLE reservations
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active',
+    ADD COLUMN active_service_date DATE AS (IF(status = 'cancelled', NULL, service_date)) STORED,
+    DROP KEY reservations_user_type_service_date,
+    ADD UNIQUE KEY reservations_user_type_active_service_date (user_id, type, active_service_date);
+
+-- reservations cancelled before a down migration come back as cancelled.
+CREATE TABLE IF NOT EXISTS cancelled_reservations LIKE reservations;
+
+INSERT INTO reservations (id, user_id, reservation_time, type, no_of_guests, created_at, version, status)
+SELECT id, user_id, reservation_time, type, no_of_guests, created_at, version, 'cancelled'
+FROM cancelled_reservations;
+
+DROP TABLE IF EXISTS cancelled_reservations;
+
+CREATE TABLE IF NOT EXISTS closures (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason text NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY closures_dates (start_date, end_date)
+);
+
+-- a closure without rows here closes every meal of its days.
+CREATE TABLE IF NOT EXISTS closure_meal_types (
+    closure_id BIGINT NOT NULL,
+    meal_type_id BIGINT NOT NULL,
+    PRIMARY KEY (closure_id, meal_type_id),
+    FOREIGN KEY (closure_id) REFERENCES closures (id) ON DELETE CASCADE
+);
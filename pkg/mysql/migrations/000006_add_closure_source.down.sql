@@ -0,0 +1,3 @@
+ALTER TABLE closures
+    DROP KEY closures_source,
+    DROP COLUMN source_uid;
@@ -0,0 +1,5 @@
+-- source_uid is the iCalendar UID a closure was imported from. One event can
+-- repeat yearly, so each occurrence is keyed by its start date as well.
+ALTER TABLE closures
+    ADD COLUMN source_uid VARCHAR(255) NULL,
+    ADD UNIQUE KEY closures_source (source_uid, start_date);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN department VARCHAR(64) NOT NULL DEFAULT '';
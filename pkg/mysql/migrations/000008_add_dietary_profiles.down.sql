@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS reservation_allergens;
+ALTER TABLE reservations DROP COLUMN diet_override;
+DROP TABLE IF EXISTS user_allergens;
+ALTER TABLE users DROP COLUMN diet;
+DROP TABLE IF EXISTS allergens;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS allergens (
+    code VARCHAR(32) NOT NULL PRIMARY KEY,
+    name text NOT NULL
+);
+
+INSERT INTO allergens (code, name) VALUES
+    ('gluten', 'Gluten'),
+    ('milk', 'Milk'),
+    ('eggs', 'Eggs'),
+    ('peanuts', 'Peanuts'),
+    ('tree_nuts', 'Tree nuts'),
+    ('soy', 'Soy'),
+    ('fish', 'Fish'),
+    ('shellfish', 'Shellfish'),
+    ('sesame', 'Sesame');
+
+ALTER TABLE users ADD COLUMN diet VARCHAR(16) NOT NULL DEFAULT 'omnivore';
+
+CREATE TABLE IF NOT EXISTS user_allergens (
+    user_id BIGINT NOT NULL,
+    allergen_code VARCHAR(32) NOT NULL,
+    PRIMARY KEY (user_id, allergen_code),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (allergen_code) REFERENCES allergens (code)
+);
+
+-- a reservation with a diet_override ignores the user's profile, including
+-- user_allergens, in favour of its own reservation_allergens.
+ALTER TABLE reservations ADD COLUMN diet_override VARCHAR(16) NULL;
+
+CREATE TABLE IF NOT EXISTS reservation_allergens (
+    reservation_id BIGINT NOT NULL,
+    allergen_code VARCHAR(32) NOT NULL,
+    PRIMARY KEY (reservation_id, allergen_code),
+    FOREIGN KEY (reservation_id) REFERENCES reservations (id) ON DELETE CASCADE,
+    FOREIGN KEY (allergen_code) REFERENCES allergens (code)
+);
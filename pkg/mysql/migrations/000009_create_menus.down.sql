@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS menu_dishes;
+DROP TABLE IF EXISTS menus;
+DROP TABLE IF EXISTS dish_allergens;
+DROP TABLE IF EXISTS dish_diets;
+DROP TABLE IF EXISTS dishes;
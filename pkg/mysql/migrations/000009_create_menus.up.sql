@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS dishes (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name text NOT NULL,
+    description text NOT NULL,
+    calories BIGINT NOT NULL DEFAULT 0,
+    protein BIGINT NOT NULL DEFAULT 0,
+    carbs BIGINT NOT NULL DEFAULT 0,
+    fat BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dish_diets (
+    dish_id BIGINT NOT NULL,
+    diet VARCHAR(16) NOT NULL,
+    PRIMARY KEY (dish_id, diet),
+    FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS dish_allergens (
+    dish_id BIGINT NOT NULL,
+    allergen_code VARCHAR(32) NOT NULL,
+    PRIMARY KEY (dish_id, allergen_code),
+    FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE,
+    FOREIGN KEY (allergen_code) REFERENCES allergens (code)
+);
+
+CREATE TABLE IF NOT EXISTS menus (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    menu_date DATE NOT NULL,
+    meal_type_id BIGINT NOT NULL,
+    published BOOLEAN NOT NULL DEFAULT FALSE,
+    published_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY menus_date_meal_type (menu_date, meal_type_id),
+    FOREIGN KEY (meal_type_id) REFERENCES meal_types (id)
+);
+
+-- dishes on a menu cannot be deleted; position orders them as served.
+CREATE TABLE IF NOT EXISTS menu_dishes (
+    menu_id BIGINT NOT NULL,
+    dish_id BIGINT NOT NULL,
+    position BIGINT NOT NULL,
+    PRIMARY KEY (menu_id, dish_id),
+    FOREIGN KEY (menu_id) REFERENCES menus (id) ON DELETE CASCADE,
+    FOREIGN KEY (dish_id) REFERENCES dishes (id)
+);
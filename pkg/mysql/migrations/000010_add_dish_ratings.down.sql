@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dish_ratings;
+ALTER TABLE reservations DROP COLUMN checked_in_at;
@@ -0,0 +1,16 @@
+ALTER TABLE reservations ADD COLUMN checked_in_at TIMESTAMP NULL;
+
+-- a rating belongs to the reservation of the meal it was given for, which
+-- also ties it to the user who gave it.
+CREATE TABLE IF NOT EXISTS dish_ratings (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    reservation_id BIGINT NOT NULL,
+    dish_id BIGINT NOT NULL,
+    stars TINYINT NOT NULL,
+    comment text NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY dish_ratings_reservation_dish (reservation_id, dish_id),
+    FOREIGN KEY (reservation_id) REFERENCES reservations (id) ON DELETE CASCADE,
+    FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE
+);
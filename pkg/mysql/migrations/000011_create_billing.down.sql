@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS statement_lines;
+DROP TABLE IF EXISTS statements;
+DROP TABLE IF EXISTS subsidy_rules;
+DROP TABLE IF EXISTS meal_prices;
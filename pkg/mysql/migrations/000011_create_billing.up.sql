@@ -0,0 +1,52 @@
+-- amounts are integer minor units of the billing currency.
+CREATE TABLE IF NOT EXISTS meal_prices (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    meal_type_id BIGINT NOT NULL,
+    employee_price BIGINT NOT NULL,
+    guest_price BIGINT NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_to DATE NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meal_type_id) REFERENCES meal_types (id)
+);
+
+CREATE TABLE IF NOT EXISTS subsidy_rules (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    designation VARCHAR(64) NOT NULL DEFAULT '',
+    department VARCHAR(64) NOT NULL DEFAULT '',
+    percent BIGINT NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_to DATE NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- statements and their lines copy what they bill instead of referencing
+-- users and reservations, so that a finalized statement survives later
+-- changes to either.
+CREATE TABLE IF NOT EXISTS statements (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    month DATE NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'draft',
+    subtotal BIGINT NOT NULL,
+    subsidy BIGINT NOT NULL,
+    total BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finalized_at TIMESTAMP NULL,
+    UNIQUE KEY statements_user_month (user_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS statement_lines (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    statement_id BIGINT NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    service_date DATE NOT NULL,
+    meal_type_id BIGINT NOT NULL,
+    attended BOOLEAN NOT NULL,
+    guests BIGINT NOT NULL,
+    employee_price BIGINT NOT NULL,
+    guest_price BIGINT NOT NULL,
+    subsidy BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    FOREIGN KEY (statement_id) REFERENCES statements (id) ON DELETE CASCADE
+);
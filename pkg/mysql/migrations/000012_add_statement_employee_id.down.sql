@@ -0,0 +1 @@
+ALTER TABLE statements DROP COLUMN employee_id;
@@ -0,0 +1,3 @@
+-- the employee ID a statement is deducted under, kept as it was when the
+-- statement was generated so that payroll exports never change.
+ALTER TABLE statements ADD COLUMN employee_id text NOT NULL;
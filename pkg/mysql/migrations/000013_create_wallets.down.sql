@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS wallet_holds;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP TABLE IF EXISTS wallets;
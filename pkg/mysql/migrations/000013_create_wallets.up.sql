@@ -0,0 +1,40 @@
+-- a wallet row is only there to be locked while posting to it; balances are
+-- always summed from the ledger, which is never updated or deleted from.
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id BIGINT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL,
+    reservation_id BIGINT NULL,
+    description text NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY ledger_transactions_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES wallets (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    transaction_id BIGINT NOT NULL,
+    account VARCHAR(64) NOT NULL,
+    amount BIGINT NOT NULL,
+    KEY ledger_entries_account (account),
+    FOREIGN KEY (transaction_id) REFERENCES ledger_transactions (id)
+);
+
+CREATE TABLE IF NOT EXISTS wallet_holds (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    reservation_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'held',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    settled_at TIMESTAMP NULL,
+    KEY wallet_holds_reservation (reservation_id, status),
+    FOREIGN KEY (user_id) REFERENCES wallets (user_id)
+);
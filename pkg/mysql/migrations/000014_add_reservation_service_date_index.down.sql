@@ -0,0 +1,2 @@
+ALTER TABLE reservations
+    DROP KEY reservations_service_date;
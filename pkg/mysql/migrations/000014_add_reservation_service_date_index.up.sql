@@ -0,0 +1,3 @@
+-- reports scan reservations by service date over ranges of months.
+ALTER TABLE reservations
+    ADD KEY reservations_service_date (service_date);
@@ -0,0 +1,9 @@
+-- only a hash of the feed token is kept, so the feed URLs of every user
+-- cannot be read back from the database.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id BIGINT NOT NULL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY calendar_feeds_token_hash (token_hash),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
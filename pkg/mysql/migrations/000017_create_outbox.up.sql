@@ -0,0 +1,17 @@
+-- outbox holds the events of every change to users and reservations,
+-- written in the same transaction as the change, until they are published.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    data MEDIUMBLOB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error text NOT NULL,
+    published_at TIMESTAMP NULL,
+    UNIQUE KEY outbox_event (event_id),
+    KEY outbox_pending (published_at, aggregate_type, aggregate_id, id)
+);
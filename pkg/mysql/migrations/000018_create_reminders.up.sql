@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id BIGINT NOT NULL PRIMARY KEY,
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    meal_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+    booking_nudges BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- a row is written before a reminder is sent, so that a restarted scheduler
+-- does not send it again.
+CREATE TABLE IF NOT EXISTS sent_reminders (
+    kind VARCHAR(32) NOT NULL,
+    user_id BIGINT NOT NULL,
+    meal_type_id BIGINT NOT NULL,
+    service_date DATE NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, user_id, meal_type_id, service_date),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+ALTER TABLE reservations DROP FOREIGN KEY reservations_site;
+ALTER TABLE reservations
+    DROP KEY reservations_site_service_date,
+    DROP COLUMN site_id;
+
+ALTER TABLE meal_types DROP FOREIGN KEY meal_types_site;
+ALTER TABLE meal_types DROP COLUMN site_id;
+
+ALTER TABLE users DROP FOREIGN KEY users_site;
+ALTER TABLE users DROP COLUMN site_id;
+
+DROP TABLE IF EXISTS site_managers;
+DROP TABLE IF EXISTS sites;
@@ -0,0 +1,36 @@
+-- everything from before sites existed belongs to site 1.
+CREATE TABLE IF NOT EXISTS sites (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    address text NOT NULL,
+    default_capacity BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY sites_name (name)
+);
+
+INSERT INTO sites (id, name, timezone, address) VALUES (1, 'Main', 'UTC', '');
+
+-- a user managing any site only sees the sites they manage.
+CREATE TABLE IF NOT EXISTS site_managers (
+    site_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    PRIMARY KEY (site_id, user_id),
+    KEY site_managers_user (user_id),
+    FOREIGN KEY (site_id) REFERENCES sites (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE users
+    ADD COLUMN site_id BIGINT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT users_site FOREIGN KEY (site_id) REFERENCES sites (id);
+
+-- a meal type without a site is served at every site.
+ALTER TABLE meal_types
+    ADD COLUMN site_id BIGINT NULL,
+    ADD CONSTRAINT meal_types_site FOREIGN KEY (site_id) REFERENCES sites (id);
+
+ALTER TABLE reservations
+    ADD COLUMN site_id BIGINT NOT NULL DEFAULT 1,
+    ADD KEY reservations_site_service_date (site_id, service_date),
+    ADD CONSTRAINT reservations_site FOREIGN KEY (site_id) REFERENCES sites (id);
@@ -0,0 +1,2 @@
+ALTER TABLE reservations
+    MODIFY service_date DATE AS (DATE(reservation_time)) STORED;
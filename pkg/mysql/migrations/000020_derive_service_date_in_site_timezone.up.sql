@@ -0,0 +1,11 @@
+-- the service date of a reservation is the day of reservation_time in the
+-- time zone of its site, which a generated column cannot work out, so it is
+-- now written along with reservation_time. Times are stored in UTC.
+ALTER TABLE reservations
+    MODIFY service_date DATE NOT NULL;
+
+-- CONVERT_TZ needs the time zone tables to know named zones and gives NULL
+-- without them, in which case the date of the UTC time is kept.
+UPDATE reservations r
+JOIN sites s ON s.id = r.site_id
+SET r.service_date = COALESCE(DATE(CONVERT_TZ(r.reservation_time, '+00:00', s.timezone)), r.service_date);
@@ -0,0 +1,16 @@
+-- idempotency_keys remembers the requests made with an Idempotency-Key
+-- header and the responses to them, so that a retry gets the same response.
+-- key_hash covers the key along with the caller and route it was used on.
+-- owner is a token only the request that stored the row knows, so that a
+-- request that outlived its lease cannot touch the row of a retry.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key_hash CHAR(64) NOT NULL PRIMARY KEY,
+    fingerprint CHAR(64) NOT NULL,
+    owner CHAR(32) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    header BLOB NOT NULL,
+    body MEDIUMBLOB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    KEY idempotency_keys_expiry (expires_at)
+);
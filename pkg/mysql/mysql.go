@@ -3,8 +3,9 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
 	"github.com/pkg/errors"
@@ -12,11 +13,26 @@ import (
 
 const dbName = "messapp"
 
+// NewDB connects to dataSourceName with the time zone policy every query
+// relies on, whatever the data source name says: times are written and read
+// in UTC, so TIMESTAMP columns hold UTC, and dates scan into time.Time.
 func NewDB(ctx context.Context, dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("mysql", dataSourceName)
+	cfg, err := driver.ParseDSN(dataSourceName)
 	if err != nil {
 		return nil, err
 	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	if cfg.Params == nil {
+		cfg.Params = make(map[string]string)
+	}
+	cfg.Params["time_zone"] = "'+00:00'"
+
+	connector, err := driver.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
 
 	if err := db.PingContext(ctx); err != nil {
 		return nil, err
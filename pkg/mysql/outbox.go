@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type outboxRepository struct {
+	queries *gen.Queries
+}
+
+func NewOutboxRepository(db *sql.DB) pkg.OutboxRepository {
+	return &outboxRepository{queries: gen.New(db)}
+}
+
+// ClaimDue works like the claim of webhook deliveries. An aggregate whose
+// oldest event is leased is left out until that event is published, which
+// keeps its events in order across relays.
+func (o *outboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int64) ([]pkg.OutboxEvent, error) {
+	due, err := o.queries.ListDueOutboxEvents(ctx, gen.ListDueOutboxEventsParams{Now: now, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.OutboxEvent
+	for _, event := range due {
+		claimed, err := o.queries.ClaimOutboxEvent(ctx, gen.ClaimOutboxEventParams{LeaseUntil: leaseUntil, ID: event.ID, Now: now})
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := claimed.RowsAffected(); n == 0 {
+			continue
+		}
+		event.NextAttemptAt = leaseUntil
+		list = append(list, toOutboxEvent(event))
+	}
+	return list, nil
+}
+
+func (o *outboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	return o.queries.MarkOutboxEventPublished(ctx, gen.MarkOutboxEventPublishedParams{PublishedAt: sql.NullTime{Time: at, Valid: true}, ID: id})
+}
+
+func (o *outboxRepository) MarkFailed(ctx context.Context, event *pkg.OutboxEvent) error {
+	return o.queries.MarkOutboxEventFailed(ctx, gen.MarkOutboxEventFailedParams{Attempts: event.Attempts, NextAttemptAt: event.NextAttemptAt, LastError: event.LastError, ID: event.ID})
+}
+
+func (o *outboxRepository) DeletePublished(ctx context.Context, before time.Time, limit int64) (int64, error) {
+	deleted, err := o.queries.DeletePublishedOutboxEvents(ctx, gen.DeletePublishedOutboxEventsParams{PublishedAt: sql.NullTime{Time: before, Valid: true}, Limit: int32(limit)})
+	if err != nil {
+		return 0, err
+	}
+	return deleted.RowsAffected()
+}
+
+// insertEvent adds event to the outbox with queries, which should be bound
+// to the transaction of the change the event describes.
+func insertEvent(ctx context.Context, queries *gen.Queries, event pkg.Event) error {
+	return queries.CreateOutboxEvent(ctx, gen.CreateOutboxEventParams{EventID: event.ID, EventType: string(event.Type), AggregateType: event.AggregateType, AggregateID: event.AggregateID, Data: event.Data, OccurredAt: event.OccurredAt, NextAttemptAt: event.OccurredAt})
+}
+
+func toOutboxEvent(event gen.Outbox) pkg.OutboxEvent {
+	result := pkg.OutboxEvent{
+		ID:            event.ID,
+		Event:         pkg.Event{ID: event.EventID, Type: pkg.EventType(event.EventType), AggregateType: event.AggregateType, AggregateID: event.AggregateID, OccurredAt: event.OccurredAt, Data: event.Data},
+		Attempts:      event.Attempts,
+		NextAttemptAt: event.NextAttemptAt,
+		LastError:     event.LastError,
+	}
+	if event.PublishedAt.Valid {
+		publishedAt := event.PublishedAt.Time
+		result.PublishedAt = &publishedAt
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+-- name: ListAllergens :many
+SELECT * FROM allergens
+ORDER BY code;
+
+-- name: CreateAllergen :exec
+INSERT INTO allergens (
+    code, name
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteAllergen :execresult
+DELETE FROM allergens
+WHERE code = ?;
+
+-- name: ListUserAllergens :many
+SELECT allergen_code FROM user_allergens
+WHERE user_id = ?
+ORDER BY allergen_code;
+
+-- name: ListAllUserAllergens :many
+SELECT * FROM user_allergens
+ORDER BY user_id, allergen_code;
+
+-- name: CreateUserAllergen :exec
+INSERT INTO user_allergens (
+    user_id, allergen_code
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteUserAllergens :exec
+DELETE FROM user_allergens
+WHERE user_id = ?;
+
+-- name: ListReservationAllergens :many
+SELECT allergen_code FROM reservation_allergens
+WHERE reservation_id = ?
+ORDER BY allergen_code;
+
+-- name: CreateReservationAllergen :exec
+INSERT INTO reservation_allergens (
+    reservation_id, allergen_code
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteReservationAllergens :exec
+DELETE FROM reservation_allergens
+WHERE reservation_id = ?;
@@ -0,0 +1,82 @@
+-- name: ListMealPrices :many
+SELECT * FROM meal_prices
+ORDER BY meal_type_id, valid_from;
+
+-- name: CreateMealPrice :execresult
+INSERT INTO meal_prices (
+    meal_type_id, employee_price, guest_price, valid_from, valid_to
+) VALUES (
+    ?, ?, ?, ?, ?
+);
+
+-- name: DeleteMealPrice :execresult
+DELETE FROM meal_prices
+WHERE id = ?;
+
+-- name: ListSubsidyRules :many
+SELECT * FROM subsidy_rules
+ORDER BY id;
+
+-- name: CreateSubsidyRule :execresult
+INSERT INTO subsidy_rules (
+    designation, department, percent, valid_from, valid_to
+) VALUES (
+    ?, ?, ?, ?, ?
+);
+
+-- name: DeleteSubsidyRule :execresult
+DELETE FROM subsidy_rules
+WHERE id = ?;
+
+-- name: GetStatementByID :one
+SELECT * FROM statements
+WHERE id = ? LIMIT 1;
+
+-- name: GetStatementByUserMonth :one
+SELECT * FROM statements
+WHERE user_id = ? AND month = ? LIMIT 1;
+
+-- name: ListStatementsByMonth :many
+SELECT * FROM statements
+WHERE month = ?
+ORDER BY user_id;
+
+-- name: ListStatementsByUser :many
+SELECT * FROM statements
+WHERE user_id = ?
+ORDER BY month DESC;
+
+-- name: CreateStatement :execresult
+INSERT INTO statements (
+    user_id, employee_id, month, subtotal, subsidy, total
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateDraftStatement :execresult
+UPDATE statements SET employee_id = ?, subtotal = ?, subsidy = ?, total = ?, created_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'draft';
+
+-- name: FinalizeStatement :execresult
+UPDATE statements SET status = 'finalized', finalized_at = ?
+WHERE id = ? AND status = 'draft';
+
+-- name: DeleteDraftStatement :execresult
+DELETE FROM statements
+WHERE id = ? AND status = 'draft';
+
+-- name: ListStatementLines :many
+SELECT * FROM statement_lines
+WHERE statement_id = ?
+ORDER BY service_date, id;
+
+-- name: CreateStatementLine :exec
+INSERT INTO statement_lines (
+    statement_id, reservation_id, service_date, meal_type_id, attended, guests, employee_price, guest_price, subsidy, amount
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: DeleteStatementLines :exec
+DELETE FROM statement_lines
+WHERE statement_id = ?;
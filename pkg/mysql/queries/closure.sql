@@ -0,0 +1,48 @@
+-- name: GetClosureByID :one
+SELECT * FROM closures
+WHERE id = ? LIMIT 1;
+
+-- name: ListClosures :many
+SELECT * FROM closures
+ORDER BY start_date, id;
+
+-- name: ListClosureMealTypes :many
+SELECT * FROM closure_meal_types
+ORDER BY closure_id, meal_type_id;
+
+-- name: ListClosureMealTypesByClosureID :many
+SELECT meal_type_id FROM closure_meal_types
+WHERE closure_id = ?
+ORDER BY meal_type_id;
+
+-- name: GetCoveringClosureID :one
+SELECT c.id FROM closures c
+WHERE c.start_date <= sqlc.arg(date) AND c.end_date >= sqlc.arg(date)
+AND (
+    NOT EXISTS (SELECT 1 FROM closure_meal_types m WHERE m.closure_id = c.id)
+    OR EXISTS (SELECT 1 FROM closure_meal_types m WHERE m.closure_id = c.id AND m.meal_type_id = sqlc.arg(meal_type_id))
+)
+ORDER BY c.id
+LIMIT 1;
+
+-- name: GetClosureIDBySource :one
+SELECT id FROM closures
+WHERE source_uid = ? AND start_date = ? LIMIT 1;
+
+-- name: CreateClosure :execresult
+INSERT INTO closures (
+    start_date, end_date, reason, source_uid
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: CreateClosureMealType :exec
+INSERT INTO closure_meal_types (
+    closure_id, meal_type_id
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteClosure :execresult
+DELETE FROM closures
+WHERE id = ?;
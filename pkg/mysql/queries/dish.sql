@@ -0,0 +1,54 @@
+-- name: GetDishByID :one
+SELECT * FROM dishes
+WHERE id = ? LIMIT 1;
+
+-- name: ListDishes :many
+SELECT * FROM dishes
+ORDER BY name;
+
+-- name: CreateDish :execresult
+INSERT INTO dishes (
+    name, description, calories, protein, carbs, fat
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateDish :execresult
+UPDATE dishes SET name = ?, description = ?, calories = ?, protein = ?, carbs = ?, fat = ?
+WHERE id = ?;
+
+-- name: DeleteDish :execresult
+DELETE FROM dishes
+WHERE id = ?;
+
+-- name: ListDishDiets :many
+SELECT diet FROM dish_diets
+WHERE dish_id = ?
+ORDER BY diet;
+
+-- name: CreateDishDiet :exec
+INSERT INTO dish_diets (
+    dish_id, diet
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteDishDiets :exec
+DELETE FROM dish_diets
+WHERE dish_id = ?;
+
+-- name: ListDishAllergens :many
+SELECT allergen_code FROM dish_allergens
+WHERE dish_id = ?
+ORDER BY allergen_code;
+
+-- name: CreateDishAllergen :exec
+INSERT INTO dish_allergens (
+    dish_id, allergen_code
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteDishAllergens :exec
+DELETE FROM dish_allergens
+WHERE dish_id = ?;
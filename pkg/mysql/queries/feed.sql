@@ -0,0 +1,15 @@
+-- name: SaveCalendarFeed :exec
+INSERT INTO calendar_feeds (
+    user_id, token_hash, created_at
+) VALUES (
+    ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_at = VALUES(created_at);
+
+-- name: GetCalendarFeedByTokenHash :one
+SELECT * FROM calendar_feeds
+WHERE token_hash = ? LIMIT 1;
+
+-- name: DeleteCalendarFeed :execresult
+DELETE FROM calendar_feeds
+WHERE user_id = ?;
@@ -0,0 +1,31 @@
+-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (
+    key_hash, fingerprint, owner, header, body, created_at, expires_at
+) VALUES (
+    ?, ?, ?, '', '', ?, ?
+);
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key_hash = ? LIMIT 1;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys SET status_code = ?, header = ?, body = ?, expires_at = ?
+WHERE key_hash = ? AND owner = ?;
+
+-- name: RenewIdempotencyKey :exec
+UPDATE idempotency_keys SET expires_at = ?
+WHERE key_hash = ? AND owner = ? AND status_code = 0;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE key_hash = ? AND owner = ?;
+
+-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE key_hash = ? AND expires_at <= ?;
+
+-- name: DeleteExpiredIdempotencyKeys :execresult
+DELETE FROM idempotency_keys
+WHERE expires_at <= ?
+LIMIT ?;
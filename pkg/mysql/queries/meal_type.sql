@@ -0,0 +1,31 @@
+-- name: GetMealTypeByID :one
+SELECT * FROM meal_types
+WHERE id = ? LIMIT 1;
+
+-- name: ListMealTypes :many
+SELECT * FROM meal_types
+ORDER BY serving_start;
+
+-- name: CreateMealType :execresult
+INSERT INTO meal_types (
+    code, name, serving_start, serving_end, active_days, default_capacity, active, site_id
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateMealType :execresult
+UPDATE meal_types SET code = ?, name = ?, serving_start = ?, serving_end = ?, active_days = ?, default_capacity = ?, active = ?, site_id = ?
+WHERE id = ?;
+
+-- name: DeleteMealType :execresult
+DELETE FROM meal_types
+WHERE id = ?;
+
+-- name: CountReservationsByMealType :one
+SELECT COUNT(*) FROM reservations
+WHERE type = ?;
+
+-- name: LockMealType :one
+SELECT id FROM meal_types
+WHERE id = ? LIMIT 1
+FOR UPDATE;
@@ -0,0 +1,53 @@
+-- name: GetMenuByID :one
+SELECT * FROM menus
+WHERE id = ? LIMIT 1;
+
+-- name: GetMenuIDForMeal :one
+SELECT id FROM menus
+WHERE menu_date = ? AND meal_type_id = ? LIMIT 1;
+
+-- name: ListMenusByDate :many
+SELECT * FROM menus
+WHERE menu_date = ?
+ORDER BY meal_type_id;
+
+-- name: ListPublishedMenusWithDish :many
+SELECT m.* FROM menus m
+JOIN menu_dishes md ON md.menu_id = m.id
+WHERE md.dish_id = ? AND m.published = TRUE AND m.menu_date >= ?
+ORDER BY m.menu_date, m.meal_type_id;
+
+-- name: CreateMenu :execresult
+INSERT INTO menus (
+    menu_date, meal_type_id
+) VALUES (
+    ?, ?
+);
+
+-- name: UpdateMenu :execresult
+UPDATE menus SET menu_date = ?, meal_type_id = ?
+WHERE id = ?;
+
+-- name: SetMenuPublished :execresult
+UPDATE menus SET published = ?, published_at = ?
+WHERE id = ?;
+
+-- name: DeleteMenu :execresult
+DELETE FROM menus
+WHERE id = ?;
+
+-- name: ListMenuDishIDs :many
+SELECT dish_id FROM menu_dishes
+WHERE menu_id = ?
+ORDER BY position;
+
+-- name: CreateMenuDish :exec
+INSERT INTO menu_dishes (
+    menu_id, dish_id, position
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: DeleteMenuDishes :exec
+DELETE FROM menu_dishes
+WHERE menu_id = ?;
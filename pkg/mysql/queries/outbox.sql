@@ -0,0 +1,33 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (
+    event_id, event_type, aggregate_type, aggregate_id, data, occurred_at, next_attempt_at, last_error
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ''
+);
+
+-- name: ListDueOutboxEvents :many
+SELECT * FROM outbox o
+WHERE o.published_at IS NULL AND o.next_attempt_at <= sqlc.arg(now)
+    AND NOT EXISTS (
+        SELECT 1 FROM outbox p
+        WHERE p.published_at IS NULL AND p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id AND p.id < o.id
+    )
+ORDER BY o.id
+LIMIT ?;
+
+-- name: ClaimOutboxEvent :execresult
+UPDATE outbox SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id = sqlc.arg(id) AND published_at IS NULL AND next_attempt_at <= sqlc.arg(now);
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = '', published_at = ?
+WHERE id = ?;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox SET attempts = ?, next_attempt_at = ?, last_error = ?
+WHERE id = ?;
+
+-- name: DeletePublishedOutboxEvents :execresult
+DELETE FROM outbox
+WHERE published_at < ?
+LIMIT ?;
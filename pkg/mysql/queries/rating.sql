@@ -0,0 +1,49 @@
+-- name: ListRatingsByReservation :many
+SELECT dr.id, dr.reservation_id, r.user_id, dr.dish_id, dr.stars, dr.comment, dr.created_at, dr.updated_at
+FROM dish_ratings dr
+JOIN reservations r ON r.id = dr.reservation_id
+WHERE dr.reservation_id = ?
+ORDER BY dr.dish_id;
+
+-- name: ListRatingsByDish :many
+SELECT dr.id, dr.reservation_id, r.user_id, dr.dish_id, dr.stars, dr.comment, dr.created_at, dr.updated_at
+FROM dish_ratings dr
+JOIN reservations r ON r.id = dr.reservation_id
+WHERE dr.dish_id = ?
+ORDER BY dr.created_at DESC, dr.id DESC;
+
+-- name: CreateDishRating :execresult
+INSERT INTO dish_ratings (
+    reservation_id, dish_id, stars, comment, created_at, updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateDishRating :execresult
+UPDATE dish_ratings SET stars = ?, comment = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: DishRatingAverages :many
+SELECT dr.dish_id, d.name AS dish_name,
+    CASE sqlc.arg(period)
+        WHEN 'month' THEN DATE_SUB(r.service_date, INTERVAL DAYOFMONTH(r.service_date) - 1 DAY)
+        WHEN 'week' THEN DATE_SUB(r.service_date, INTERVAL WEEKDAY(r.service_date) DAY)
+        ELSE r.service_date
+    END AS period_start,
+    COUNT(*) AS ratings,
+    AVG(dr.stars) AS average
+FROM dish_ratings dr
+JOIN reservations r ON r.id = dr.reservation_id
+JOIN dishes d ON d.id = dr.dish_id
+WHERE r.service_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+GROUP BY dr.dish_id, dish_name, period_start
+ORDER BY dr.dish_id, period_start;
+
+-- name: LowestRatedMeals :many
+SELECT r.service_date, r.type AS meal_type_id, COUNT(*) AS ratings, AVG(dr.stars) AS average
+FROM dish_ratings dr
+JOIN reservations r ON r.id = dr.reservation_id
+WHERE r.service_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+GROUP BY r.service_date, r.type
+ORDER BY average, r.service_date, meal_type_id
+LIMIT ?;
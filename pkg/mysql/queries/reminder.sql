@@ -0,0 +1,26 @@
+-- name: SaveNotificationPreferences :exec
+INSERT INTO notification_preferences (
+    user_id, email, meal_reminders, booking_nudges, updated_at
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE email = VALUES(email), meal_reminders = VALUES(meal_reminders), booking_nudges = VALUES(booking_nudges), updated_at = VALUES(updated_at);
+
+-- name: GetNotificationPreferences :one
+SELECT * FROM notification_preferences
+WHERE user_id = ? LIMIT 1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+ORDER BY user_id;
+
+-- name: CreateSentReminder :exec
+INSERT INTO sent_reminders (
+    kind, user_id, meal_type_id, service_date, sent_at
+) VALUES (
+    ?, ?, ?, ?, ?
+);
+
+-- name: DeleteSentReminder :exec
+DELETE FROM sent_reminders
+WHERE kind = ? AND user_id = ? AND meal_type_id = ? AND service_date = ?;
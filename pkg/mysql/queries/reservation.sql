@@ -8,19 +8,83 @@ WHERE id = ? LIMIT 1;
 
 -- name: GetReservationsByDate :many
 SELECT * FROM reservations
-where reservation_time = ?;
+WHERE service_date = ?
+ORDER BY id;
+
+-- name: ListReservations :many
+SELECT * FROM reservations
+ORDER BY service_date, id;
 
 -- name: CreateReservation :execresult
 INSERT INTO reservations (
-    user_id, reservation_time, type, no_of_guests, created_at
+    user_id, reservation_time, type, no_of_guests, created_at, diet_override, site_id, service_date
 ) VALUES (
-    ?, ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?, ?, ?
 );
 
--- name: DeleteReservation :exec
+-- name: DeleteReservation :execresult
 DELETE FROM reservations
-where id = ?;
+WHERE id = sqlc.arg(id) AND (sqlc.arg(expected_version) = 0 OR version = sqlc.arg(expected_version));
+
+-- name: UpdateReservation :execresult
+UPDATE reservations SET user_id = sqlc.arg(user_id), reservation_time = sqlc.arg(reservation_time), type = sqlc.arg(type), no_of_guests = sqlc.arg(no_of_guests), diet_override = sqlc.arg(diet_override), site_id = sqlc.arg(site_id), service_date = sqlc.arg(service_date), version = version + 1
+WHERE id = sqlc.arg(id) AND (sqlc.arg(expected_version) = 0 OR version = sqlc.arg(expected_version));
+
+-- name: CountSlotPlates :one
+SELECT CAST(COALESCE(SUM(1 + no_of_guests), 0) AS SIGNED) FROM reservations
+WHERE service_date = ? AND type = ? AND site_id = ? AND status = 'active';
+
+-- name: GetReservationIDBySlot :one
+SELECT id FROM reservations
+WHERE user_id = ? AND type = ? AND active_service_date = ? LIMIT 1;
+
+-- name: ListActiveReservationsBetween :many
+SELECT * FROM reservations
+WHERE service_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date) AND status = 'active'
+ORDER BY service_date, id;
 
--- name: UpdateReservation :exec
-UPDATE reservations SET no_of_guests = ?
-WHERE id = ?;
\ No newline at end of file
+-- name: ListActiveReservationsForMeal :many
+SELECT * FROM reservations
+WHERE service_date = ? AND type = ? AND status = 'active'
+ORDER BY id;
+
+-- name: ListRegularDiners :many
+SELECT user_id FROM reservations
+WHERE type = sqlc.arg(type) AND status = 'active'
+    AND service_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+    AND DAYOFWEEK(service_date) = DAYOFWEEK(sqlc.arg(to_date))
+GROUP BY user_id
+HAVING COUNT(*) >= sqlc.arg(min_meals)
+ORDER BY user_id;
+
+-- name: CheckInReservation :execresult
+UPDATE reservations SET checked_in_at = ?, version = version + 1
+WHERE id = ? AND status = 'active' AND checked_in_at IS NULL;
+
+-- name: CancelReservation :exec
+UPDATE reservations SET status = 'cancelled', version = version + 1
+WHERE id = ?;
+
+-- name: Headcount :many
+SELECT r.service_date,
+    IF(sqlc.arg(by_meal), r.type, 0) AS meal_type_id,
+    IF(sqlc.arg(by_site), r.site_id, 0) AS site,
+    IF(sqlc.arg(by_department), COALESCE(u.department, ''), '') AS department_name,
+    IF(sqlc.arg(by_diet), COALESCE(r.diet_override, u.diet, 'omnivore'), '') AS diet_name,
+    IF(sqlc.arg(by_allergen), COALESCE(ra.allergen_code, ua.allergen_code, ''), '') AS allergen,
+    COUNT(*) AS employees,
+    CAST(SUM(r.no_of_guests) AS SIGNED) AS guests
+FROM reservations r
+LEFT JOIN users u ON u.id = r.user_id
+-- the allergen joins only fan rows out when grouping by allergen.
+LEFT JOIN reservation_allergens ra ON sqlc.arg(by_allergen) AND r.diet_override IS NOT NULL AND ra.reservation_id = r.id
+LEFT JOIN user_allergens ua ON sqlc.arg(by_allergen) AND r.diet_override IS NULL AND ua.user_id = r.user_id
+WHERE r.service_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date) AND r.status = 'active'
+    AND (sqlc.arg(sites) = '' OR FIND_IN_SET(r.site_id, sqlc.arg(sites)))
+GROUP BY r.service_date, meal_type_id, site, department_name, diet_name, allergen
+ORDER BY r.service_date, meal_type_id, site, department_name, diet_name, allergen;
+
+-- name: ListUpcomingReservationsByUser :many
+SELECT * FROM reservations
+WHERE user_id = sqlc.arg(user_id) AND service_date >= sqlc.arg(from_date)
+ORDER BY service_date, type;
@@ -0,0 +1,43 @@
+-- name: GetSiteByID :one
+SELECT * FROM sites
+WHERE id = ? LIMIT 1;
+
+-- name: ListSites :many
+SELECT * FROM sites
+ORDER BY name;
+
+-- name: CreateSite :execresult
+INSERT INTO sites (
+    name, timezone, address, default_capacity
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: UpdateSite :execresult
+UPDATE sites SET name = ?, timezone = ?, address = ?, default_capacity = ?
+WHERE id = ?;
+
+-- name: DeleteSite :execresult
+DELETE FROM sites
+WHERE id = ?;
+
+-- name: ListSiteManagers :many
+SELECT user_id FROM site_managers
+WHERE site_id = ?
+ORDER BY user_id;
+
+-- name: ListSitesManagedBy :many
+SELECT site_id FROM site_managers
+WHERE user_id = ?
+ORDER BY site_id;
+
+-- name: CreateSiteManager :exec
+INSERT INTO site_managers (
+    site_id, user_id
+) VALUES (
+    ?, ?
+);
+
+-- name: DeleteSiteManagers :exec
+DELETE FROM site_managers
+WHERE site_id = ?;
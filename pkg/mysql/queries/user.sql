@@ -12,15 +12,15 @@ ORDER BY name;
 
 -- name: CreateUser :execresult
 INSERT INTO users(
-    name, password, designation, employee_id
+    name, password, designation, department, employee_id, diet, site_id
 ) VALUES (
-    ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?, ?
 );
 
--- name: DeleteUser :exec
+-- name: DeleteUser :execresult
 DELETE FROM users
-where id = ?;
+WHERE id = sqlc.arg(id) AND (sqlc.arg(expected_version) = 0 OR version = sqlc.arg(expected_version));
 
--- name: UpdateUser :exec
-UPDATE users SET password = ?
-WHERE id = ?;
\ No newline at end of file
+-- name: UpdateUser :execresult
+UPDATE users SET name = sqlc.arg(name), password = sqlc.arg(password), designation = sqlc.arg(designation), department = sqlc.arg(department), employee_id = sqlc.arg(employee_id), diet = sqlc.arg(diet), site_id = sqlc.arg(site_id), version = version + 1
+WHERE id = sqlc.arg(id) AND (sqlc.arg(expected_version) = 0 OR version = sqlc.arg(expected_version));
\ No newline at end of file
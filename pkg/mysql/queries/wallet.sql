@@ -0,0 +1,66 @@
+-- name: CreateWallet :exec
+INSERT INTO wallets (
+    user_id
+) VALUES (
+    ?
+);
+
+-- name: GetWallet :one
+SELECT * FROM wallets
+WHERE user_id = ? LIMIT 1;
+
+-- name: LockWallet :one
+SELECT user_id FROM wallets
+WHERE user_id = ? LIMIT 1
+FOR UPDATE;
+
+-- name: GetAccountBalance :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS SIGNED) FROM ledger_entries
+WHERE account = ?;
+
+-- name: CreateLedgerTransaction :execresult
+INSERT INTO ledger_transactions (
+    user_id, kind, amount, reservation_id, description, created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (
+    transaction_id, account, amount
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: ListAccountMovements :many
+SELECT t.id, t.user_id, t.kind, t.amount, t.reservation_id, t.description, t.created_at, e.amount AS delta
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account = ?
+ORDER BY t.id;
+
+-- name: GetOpenHold :one
+SELECT * FROM wallet_holds
+WHERE reservation_id = ? AND status = 'held' LIMIT 1;
+
+-- name: ListHeldReservationIDs :many
+SELECT DISTINCT h.reservation_id FROM wallet_holds h
+JOIN reservations r ON r.id = h.reservation_id
+WHERE r.service_date BETWEEN sqlc.arg(first) AND sqlc.arg(last) AND h.status <> 'released'
+ORDER BY h.reservation_id;
+
+-- name: ListOpenHolds :many
+SELECT * FROM wallet_holds
+WHERE status = 'held'
+ORDER BY id;
+
+-- name: CreateWalletHold :execresult
+INSERT INTO wallet_holds (
+    user_id, reservation_id, amount, created_at
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: SettleWalletHold :exec
+UPDATE wallet_holds SET status = ?, settled_at = ?
+WHERE id = ?;
@@ -0,0 +1,49 @@
+-- name: CreateWebhook :execresult
+INSERT INTO webhooks (
+    url, event_types, secret, created_at
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = ? LIMIT 1;
+
+-- name: DeleteWebhook :execresult
+DELETE FROM webhooks
+WHERE id = ?;
+
+-- name: CreateWebhookDelivery :execresult
+INSERT INTO webhook_deliveries (
+    webhook_id, event_id, event_type, payload, next_attempt_at, last_error, created_at
+) VALUES (
+    ?, ?, ?, ?, ?, '', ?
+);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = ? LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?;
+
+-- name: ClaimWebhookDelivery :execresult
+UPDATE webhook_deliveries SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id = sqlc.arg(id) AND status = 'pending' AND next_attempt_at <= sqlc.arg(now);
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, last_error = ?, delivered_at = ?
+WHERE id = ?;
@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type ratingRepository struct {
+	queries *gen.Queries
+}
+
+func NewRatingRepository(db *sql.DB) pkg.RatingRepository {
+	return &ratingRepository{queries: gen.New(db)}
+}
+
+func (r *ratingRepository) Insert(ctx context.Context, rating *pkg.Rating) error {
+	now := time.Now()
+	inserted, err := r.queries.CreateDishRating(ctx, gen.CreateDishRatingParams{ReservationID: rating.ReservationID, DishID: rating.DishID, Stars: int8(rating.Stars), Comment: rating.Comment, CreatedAt: now, UpdatedAt: now})
+	if isDuplicateEntry(err) {
+		return pkg.ErrRatingAlreadyExists
+	}
+	if isMissingReference(err) {
+		return pkg.ErrDishNotFound
+	}
+	if err != nil {
+		return err
+	}
+	rating.ID, _ = inserted.LastInsertId()
+	rating.CreatedAt, rating.UpdatedAt = now, now
+	return nil
+}
+
+func (r *ratingRepository) FindByReservation(ctx context.Context, reservationID int64) ([]pkg.Rating, error) {
+	ratings, err := r.queries.ListRatingsByReservation(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Rating
+	for _, rating := range ratings {
+		list = append(list, pkg.Rating{ID: rating.ID, ReservationID: rating.ReservationID, UserID: rating.UserID, DishID: rating.DishID, Stars: int64(rating.Stars), Comment: rating.Comment, CreatedAt: rating.CreatedAt, UpdatedAt: rating.UpdatedAt})
+	}
+	return list, nil
+}
+
+func (r *ratingRepository) FindByDish(ctx context.Context, dishID int64) ([]pkg.Rating, error) {
+	ratings, err := r.queries.ListRatingsByDish(ctx, dishID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Rating
+	for _, rating := range ratings {
+		list = append(list, pkg.Rating{ID: rating.ID, ReservationID: rating.ReservationID, UserID: rating.UserID, DishID: rating.DishID, Stars: int64(rating.Stars), Comment: rating.Comment, CreatedAt: rating.CreatedAt, UpdatedAt: rating.UpdatedAt})
+	}
+	return list, nil
+}
+
+func (r *ratingRepository) Update(ctx context.Context, rating *pkg.Rating) error {
+	now := time.Now()
+	updated, err := r.queries.UpdateDishRating(ctx, gen.UpdateDishRatingParams{ID: rating.ID, Stars: int8(rating.Stars), Comment: rating.Comment, UpdatedAt: now})
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n == 0 {
+		return pkg.ErrRatingNotFound
+	}
+	rating.UpdatedAt = now
+	return nil
+}
+
+func (r *ratingRepository) DishAverages(ctx context.Context, from, to time.Time, period pkg.RatingPeriod) ([]pkg.DishRating, error) {
+	rows, err := r.queries.DishRatingAverages(ctx, gen.DishRatingAveragesParams{Period: string(period), FromDate: pkg.Date(from), ToDate: pkg.Date(to)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.DishRating
+	for _, row := range rows {
+		list = append(list, pkg.DishRating(row))
+	}
+	return list, nil
+}
+
+func (r *ratingRepository) LowestRatedMeals(ctx context.Context, from, to time.Time, limit int64) ([]pkg.MealRating, error) {
+	rows, err := r.queries.LowestRatedMeals(ctx, gen.LowestRatedMealsParams{FromDate: pkg.Date(from), ToDate: pkg.Date(to), Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.MealRating
+	for _, row := range rows {
+		list = append(list, pkg.MealRating(row))
+	}
+	return list, nil
+}
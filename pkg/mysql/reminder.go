@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type notificationPreferenceRepository struct {
+	queries *gen.Queries
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB) pkg.NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{queries: gen.New(db)}
+}
+
+func (n *notificationPreferenceRepository) Save(ctx context.Context, preferences *pkg.NotificationPreferences) error {
+	now := time.Now()
+	err := n.queries.SaveNotificationPreferences(ctx, gen.SaveNotificationPreferencesParams{UserID: preferences.UserID, Email: preferences.Email, MealReminders: preferences.MealReminders, BookingNudges: preferences.BookingNudges, UpdatedAt: now})
+	if isMissingReference(err) {
+		return pkg.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	preferences.UpdatedAt = now
+	return nil
+}
+
+func (n *notificationPreferenceRepository) FindByUser(ctx context.Context, userID int64) (*pkg.NotificationPreferences, error) {
+	preferences, err := n.queries.GetNotificationPreferences(ctx, userID)
+	if err == sql.ErrNoRows {
+		defaults := pkg.DefaultNotificationPreferences(userID)
+		return &defaults, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := toNotificationPreferences(preferences)
+	return &found, nil
+}
+
+func (n *notificationPreferenceRepository) FindAll(ctx context.Context) ([]pkg.NotificationPreferences, error) {
+	list, err := n.queries.ListNotificationPreferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []pkg.NotificationPreferences
+	for _, preferences := range list {
+		all = append(all, toNotificationPreferences(preferences))
+	}
+	return all, nil
+}
+
+func toNotificationPreferences(preferences gen.NotificationPreference) pkg.NotificationPreferences {
+	return pkg.NotificationPreferences{UserID: preferences.UserID, Email: preferences.Email, MealReminders: preferences.MealReminders, BookingNudges: preferences.BookingNudges, UpdatedAt: preferences.UpdatedAt}
+}
+
+type sentReminderRepository struct {
+	queries *gen.Queries
+}
+
+func NewSentReminderRepository(db *sql.DB) pkg.SentReminderRepository {
+	return &sentReminderRepository{queries: gen.New(db)}
+}
+
+func (s *sentReminderRepository) Claim(ctx context.Context, reminder pkg.SentReminder) (bool, error) {
+	err := s.queries.CreateSentReminder(ctx, gen.CreateSentReminderParams{Kind: string(reminder.Kind), UserID: reminder.UserID, MealTypeID: reminder.MealTypeID, ServiceDate: pkg.Date(reminder.ServiceDate), SentAt: time.Now()})
+	if isDuplicateEntry(err) {
+		return false, nil
+	}
+	if isMissingReference(err) {
+		return false, pkg.ErrUserNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *sentReminderRepository) Release(ctx context.Context, reminder pkg.SentReminder) error {
+	return s.queries.DeleteSentReminder(ctx, gen.DeleteSentReminderParams{Kind: string(reminder.Kind), UserID: reminder.UserID, MealTypeID: reminder.MealTypeID, ServiceDate: pkg.Date(reminder.ServiceDate)})
+}
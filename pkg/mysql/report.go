@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// Reports are read with plain queries rather than through sqlc, whose
+// generated code collects every row into a slice before returning.
+
+const departmentUsage = `
+SELECT DATE_SUB(r.service_date, INTERVAL DAYOFMONTH(r.service_date) - 1 DAY) AS month,
+    u.department,
+    COUNT(*) AS reservations,
+    COUNT(DISTINCT r.user_id) AS employees,
+    CAST(COALESCE(SUM(r.no_of_guests), 0) AS SIGNED) AS guests,
+    COUNT(r.checked_in_at) AS attended
+FROM reservations r
+JOIN users u ON u.id = r.user_id
+WHERE r.status = 'active' AND r.service_date BETWEEN ? AND ?
+    AND (? = '' OR FIND_IN_SET(r.site_id, ?))
+GROUP BY month, u.department
+ORDER BY month, u.department
+`
+
+const guestUsage = `
+SELECT u.id, u.employee_id, u.name, u.department,
+    COUNT(*) AS reservations,
+    CAST(SUM(r.no_of_guests) AS SIGNED) AS guests
+FROM reservations r
+JOIN users u ON u.id = r.user_id
+WHERE r.status = 'active' AND r.no_of_guests > 0 AND r.service_date BETWEEN ? AND ?
+    AND (? = '' OR FIND_IN_SET(r.site_id, ?))
+GROUP BY u.id, u.employee_id, u.name, u.department
+ORDER BY guests DESC, u.id
+`
+
+const noShowRates = `
+SELECT u.id, u.employee_id, u.name, u.department,
+    COUNT(*) AS reservations,
+    COUNT(r.checked_in_at) AS attended
+FROM reservations r
+JOIN users u ON u.id = r.user_id
+WHERE r.status = 'active' AND r.service_date BETWEEN ? AND ?
+    AND (? = '' OR FIND_IN_SET(r.site_id, ?))
+GROUP BY u.id, u.employee_id, u.name, u.department
+ORDER BY (COUNT(*) - COUNT(r.checked_in_at)) / COUNT(*) DESC, u.id
+`
+
+const mealTypeTrend = `
+SELECT IF(?,
+        DATE_SUB(r.service_date, INTERVAL WEEKDAY(r.service_date) DAY),
+        DATE_SUB(r.service_date, INTERVAL DAYOFMONTH(r.service_date) - 1 DAY)) AS period_start,
+    r.type, m.name,
+    COUNT(*) AS reservations,
+    CAST(COALESCE(SUM(r.no_of_guests), 0) AS SIGNED) AS guests,
+    COUNT(r.checked_in_at) AS attended
+FROM reservations r
+JOIN meal_types m ON m.id = r.type
+WHERE r.status = 'active' AND r.service_date BETWEEN ? AND ?
+    AND (? = '' OR FIND_IN_SET(r.site_id, ?))
+GROUP BY period_start, r.type, m.name
+ORDER BY period_start, r.type
+`
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) pkg.ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) DepartmentUsage(ctx context.Context, from, to time.Time, sites []int64, fn func(pkg.DepartmentUsage) error) error {
+	return r.each(ctx, func(rows *sql.Rows) error {
+		var row pkg.DepartmentUsage
+		if err := rows.Scan(&row.Month, &row.Department, &row.Reservations, &row.Employees, &row.Guests, &row.Attended); err != nil {
+			return err
+		}
+		return fn(row)
+	}, departmentUsage, pkg.Date(from), pkg.Date(to), siteList(sites), siteList(sites))
+}
+
+func (r *reportRepository) GuestUsage(ctx context.Context, from, to time.Time, sites []int64, fn func(pkg.GuestUsage) error) error {
+	return r.each(ctx, func(rows *sql.Rows) error {
+		var row pkg.GuestUsage
+		if err := rows.Scan(&row.UserID, &row.EmployeeID, &row.Name, &row.Department, &row.Reservations, &row.Guests); err != nil {
+			return err
+		}
+		return fn(row)
+	}, guestUsage, pkg.Date(from), pkg.Date(to), siteList(sites), siteList(sites))
+}
+
+func (r *reportRepository) NoShowRates(ctx context.Context, from, to time.Time, sites []int64, fn func(pkg.NoShowRate) error) error {
+	return r.each(ctx, func(rows *sql.Rows) error {
+		var row pkg.NoShowRate
+		if err := rows.Scan(&row.UserID, &row.EmployeeID, &row.Name, &row.Department, &row.Reservations, &row.Attended); err != nil {
+			return err
+		}
+		return fn(row)
+	}, noShowRates, pkg.Date(from), pkg.Date(to), siteList(sites), siteList(sites))
+}
+
+func (r *reportRepository) MealTypeTrend(ctx context.Context, from, to time.Time, weekly bool, sites []int64, fn func(pkg.MealTypeTrend) error) error {
+	return r.each(ctx, func(rows *sql.Rows) error {
+		var row pkg.MealTypeTrend
+		if err := rows.Scan(&row.PeriodStart, &row.MealTypeID, &row.MealType, &row.Reservations, &row.Guests, &row.Attended); err != nil {
+			return err
+		}
+		return fn(row)
+	}, mealTypeTrend, weekly, pkg.Date(from), pkg.Date(to), siteList(sites), siteList(sites))
+}
+
+// each runs query and calls scan for every row while the result is still
+// being read from the server.
+func (r *reportRepository) each(ctx context.Context, scan func(*sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
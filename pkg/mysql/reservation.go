@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"sort"
 	"time"
 
 	"github.com/markhaur/messapp-backend/pkg"
@@ -10,33 +11,170 @@ import (
 )
 
 type reservationRepository struct {
+	db      *sql.DB
 	queries *gen.Queries
 }
 
 func NewReservationRepository(db *sql.DB) pkg.ReservationRepository {
-	return &reservationRepository{queries: gen.New(db)}
+	return &reservationRepository{db: db, queries: gen.New(db)}
 }
 
-func (r *reservationRepository) Insert(ctx context.Context, reservation *pkg.Reservation) error {
-	inserted, err := r.queries.CreateReservation(ctx, gen.CreateReservationParams{UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: int64(reservation.Type), NoOfGuests: reservation.NoOfGuests, CreatedAt: time.Now()})
+func (r *reservationRepository) Insert(ctx context.Context, reservation *pkg.Reservation, hold *pkg.WalletHold) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	reservation.ID, _ = inserted.LastInsertId()
-	reservation.CreatedAt = reservation.CreatedAt.In(time.Local)
+	defer tx.Rollback()
+	queries := r.queries.WithTx(tx)
+
+	// TIMESTAMP columns keep whole seconds, so this is what reads back.
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	if err := insertReservation(ctx, queries, reservation, createdAt); err != nil {
+		return err
+	}
+	placed, err := holdFor(ctx, queries, hold, reservation.ID, createdAt)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	created(reservation, createdAt)
+	if hold != nil {
+		*hold = *placed
+	}
 	return nil
 }
 
-func (r *reservationRepository) FindAll(context.Context) ([]pkg.Reservation, error) {
-	return nil, nil
+// InsertAll counts the plates booked for a meal while holding a lock on its
+// meal type, so that concurrent batches cannot both take its last plates.
+func (r *reservationRepository) InsertAll(ctx context.Context, reservations []pkg.Reservation, holds []*pkg.WalletHold, capacities []int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	queries := r.queries.WithTx(tx)
+
+	// meal types are locked in the order of their IDs, which keeps batches
+	// that share them from deadlocking.
+	var mealTypeIDs []int64
+	locking := make(map[int64]bool)
+	for i, reservation := range reservations {
+		if capacities[i] > 0 && !locking[reservation.MealTypeID] {
+			locking[reservation.MealTypeID] = true
+			mealTypeIDs = append(mealTypeIDs, reservation.MealTypeID)
+		}
+	}
+	sort.Slice(mealTypeIDs, func(i, j int) bool { return mealTypeIDs[i] < mealTypeIDs[j] })
+	for _, id := range mealTypeIDs {
+		if _, err := queries.LockMealType(ctx, id); err != nil && err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	placed := make([]*pkg.WalletHold, len(holds))
+	for i := range reservations {
+		if capacities[i] > 0 {
+			booked, err := queries.CountSlotPlates(ctx, gen.CountSlotPlatesParams{ServiceDate: pkg.Date(reservations[i].ServiceDate), Type: reservations[i].MealTypeID, SiteID: reservations[i].SiteID})
+			if err != nil {
+				return i, err
+			}
+			if booked+1+reservations[i].NoOfGuests > capacities[i] {
+				return i, pkg.ErrMealFull
+			}
+		}
+		if err := insertReservation(ctx, queries, &reservations[i], createdAt); err != nil {
+			return i, err
+		}
+		if placed[i], err = holdFor(ctx, queries, holds[i], reservations[i].ID, createdAt); err != nil {
+			return i, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for i := range reservations {
+		created(&reservations[i], createdAt)
+		if holds[i] != nil {
+			*holds[i] = *placed[i]
+		}
+	}
+	return 0, nil
+}
+
+// insertReservation stores a new reservation along with its allergens and
+// the event announcing it.
+func insertReservation(ctx context.Context, queries *gen.Queries, reservation *pkg.Reservation, createdAt time.Time) error {
+	inserted, err := queries.CreateReservation(ctx, gen.CreateReservationParams{UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: reservation.MealTypeID, NoOfGuests: reservation.NoOfGuests, CreatedAt: createdAt, DietOverride: dietOverride(reservation), SiteID: reservation.SiteID, ServiceDate: pkg.Date(reservation.ServiceDate)})
+	if isDuplicateEntry(err) {
+		return duplicateError(ctx, queries, reservation)
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return err
+	}
+	reservation.ID, _ = inserted.LastInsertId()
+	if err := insertReservationAllergens(ctx, queries, reservation); err != nil {
+		return err
+	}
+	return insertReservationEvent(ctx, queries, pkg.EventReservationCreated, reservation.ID)
+}
+
+// holdFor places a copy of hold, unless nil, for the reservation with the
+// given ID and returns it.
+func holdFor(ctx context.Context, queries *gen.Queries, hold *pkg.WalletHold, reservationID int64, now time.Time) (*pkg.WalletHold, error) {
+	if hold == nil {
+		return nil, nil
+	}
+	placed := *hold
+	placed.ReservationID = reservationID
+	if err := holdFunds(ctx, queries, &placed, now); err != nil {
+		return nil, err
+	}
+	return &placed, nil
+}
+
+// created fills in what storing a reservation set, once it is committed.
+func created(reservation *pkg.Reservation, createdAt time.Time) {
+	reservation.CreatedAt = createdAt
+	reservation.Status = pkg.ReservationActive
+	reservation.Version = 1
+}
+
+func (r *reservationRepository) FindAll(ctx context.Context) ([]pkg.Reservation, error) {
+	reservations, err := r.queries.ListReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Reservation
+	for _, reservation := range reservations {
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
 }
 
 func (r *reservationRepository) FindByID(ctx context.Context, id int64) (*pkg.Reservation, error) {
 	reservation, err := r.queries.GetReservationByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := loadReservation(ctx, r.queries, reservation)
 	if err != nil {
 		return nil, err
 	}
-	return &pkg.Reservation{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: pkg.ReservationType(reservation.Type), NoOfGuests: reservation.NoOfGuests, CreatedAt: reservation.CreatedAt}, nil
+	return &found, nil
 }
 
 func (r *reservationRepository) FindByEmployeeID(ctx context.Context, employee_id int64) ([]pkg.Reservation, error) {
@@ -47,28 +185,289 @@ func (r *reservationRepository) FindByEmployeeID(ctx context.Context, employee_i
 
 	var list []pkg.Reservation
 	for _, reservation := range reservations {
-		list = append(list, pkg.Reservation{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: pkg.ReservationType(reservation.Type), NoOfGuests: reservation.NoOfGuests, CreatedAt: reservation.CreatedAt})
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
 	}
 	return list, nil
 }
 
 func (r *reservationRepository) FindByDate(ctx context.Context, date time.Time) ([]pkg.Reservation, error) {
-	reservations, err := r.queries.GetReservationsByDate(ctx, date)
+	reservations, err := r.queries.GetReservationsByDate(ctx, pkg.Date(date))
 	if err != nil {
 		return nil, err
 	}
 
 	var list []pkg.Reservation
 	for _, reservation := range reservations {
-		list = append(list, pkg.Reservation{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: pkg.ReservationType(reservation.Type), NoOfGuests: reservation.NoOfGuests, CreatedAt: reservation.CreatedAt})
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
 	}
 	return list, nil
 }
 
-func (r *reservationRepository) Update(ctx context.Context, reservation *pkg.Reservation) error {
-	return r.queries.UpdateReservation(ctx, gen.UpdateReservationParams{ID: reservation.ID, NoOfGuests: reservation.NoOfGuests})
+func (r *reservationRepository) FindActiveForMeal(ctx context.Context, date time.Time, mealTypeID int64) ([]pkg.Reservation, error) {
+	reservations, err := r.queries.ListActiveReservationsForMeal(ctx, gen.ListActiveReservationsForMealParams{ServiceDate: pkg.Date(date), Type: mealTypeID})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Reservation
+	for _, reservation := range reservations {
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
 }
 
-func (r *reservationRepository) DeleteByID(ctx context.Context, id int64) error {
-	return r.queries.DeleteReservation(ctx, id)
+func (r *reservationRepository) FindActiveBetween(ctx context.Context, from, to time.Time) ([]pkg.Reservation, error) {
+	reservations, err := r.queries.ListActiveReservationsBetween(ctx, gen.ListActiveReservationsBetweenParams{FromDate: pkg.Date(from), ToDate: pkg.Date(to)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Reservation
+	for _, reservation := range reservations {
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+func (r *reservationRepository) FindUpcomingByUser(ctx context.Context, userID int64, from time.Time) ([]pkg.Reservation, error) {
+	reservations, err := r.queries.ListUpcomingReservationsByUser(ctx, gen.ListUpcomingReservationsByUserParams{UserID: userID, FromDate: pkg.Date(from)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Reservation
+	for _, reservation := range reservations {
+		found, err := loadReservation(ctx, r.queries, reservation)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, found)
+	}
+	return list, nil
+}
+
+func (r *reservationRepository) FindRegularDiners(ctx context.Context, date time.Time, mealTypeID, weeks, min int64) ([]int64, error) {
+	to := pkg.Date(date).AddDate(0, 0, -7)
+	from := to.AddDate(0, 0, -7*int(weeks-1))
+	return r.queries.ListRegularDiners(ctx, gen.ListRegularDinersParams{Type: mealTypeID, FromDate: from, ToDate: to, MinMeals: min})
+}
+
+// Update writes every field of reservation and reloads it, so the caller
+// sees the new version. The version check and bump happen in the same
+// statement, which keeps concurrent writers from overwriting each other.
+func (r *reservationRepository) Update(ctx context.Context, reservation *pkg.Reservation, hold *pkg.WalletHold) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := r.queries.WithTx(tx)
+
+	updated, err := queries.UpdateReservation(ctx, gen.UpdateReservationParams{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: reservation.MealTypeID, NoOfGuests: reservation.NoOfGuests, DietOverride: dietOverride(reservation), SiteID: reservation.SiteID, ServiceDate: pkg.Date(reservation.ServiceDate), ExpectedVersion: reservation.Version})
+	if isDuplicateEntry(err) {
+		return duplicateError(ctx, queries, reservation)
+	}
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n == 0 {
+		return r.missingOrModified(ctx, reservation.ID)
+	}
+	if err := queries.DeleteReservationAllergens(ctx, reservation.ID); err != nil {
+		return err
+	}
+	if err := insertReservationAllergens(ctx, queries, reservation); err != nil {
+		return err
+	}
+	if err := insertReservationEvent(ctx, queries, pkg.EventReservationUpdated, reservation.ID); err != nil {
+		return err
+	}
+	placed, err := holdFor(ctx, queries, hold, reservation.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if hold != nil {
+		*hold = *placed
+	}
+
+	stored, err := r.FindByID(ctx, reservation.ID)
+	if err != nil {
+		return err
+	}
+	*reservation = *stored
+	return nil
+}
+
+func (r *reservationRepository) CheckIn(ctx context.Context, id int64, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := r.queries.WithTx(tx)
+
+	updated, err := queries.CheckInReservation(ctx, gen.CheckInReservationParams{ID: id, CheckedInAt: sql.NullTime{Time: at, Valid: true}})
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n > 0 {
+		if err := insertReservationEvent(ctx, queries, pkg.EventReservationCheckedIn, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	reservation, err := queries.GetReservationByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return pkg.ErrReservationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if reservation.Status != string(pkg.ReservationActive) {
+		return pkg.ErrReservationNotActive
+	}
+	return nil
+}
+
+// DeleteByID records the reservation as it was before it went, marked
+// cancelled, in the event of its removal.
+func (r *reservationRepository) DeleteByID(ctx context.Context, id, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := r.queries.WithTx(tx)
+
+	stored, err := queries.GetReservationByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return pkg.ErrReservationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	removed, err := loadReservation(ctx, queries, stored)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := queries.DeleteReservation(ctx, gen.DeleteReservationParams{ID: id, ExpectedVersion: version})
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return r.missingOrModified(ctx, id)
+	}
+	removed.Status = pkg.ReservationCancelled
+	if err := insertEvent(ctx, queries, pkg.NewReservationEvent(pkg.EventReservationCancelled, removed)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// missingOrModified explains why a versioned write matched no rows.
+func (r *reservationRepository) missingOrModified(ctx context.Context, id int64) error {
+	if _, err := r.queries.GetReservationByID(ctx, id); err == sql.ErrNoRows {
+		return pkg.ErrReservationNotFound
+	}
+	return pkg.ErrReservationModified
+}
+
+// insertReservationEvent adds an event with the reservation as queries
+// sees it to the outbox.
+func insertReservationEvent(ctx context.Context, queries *gen.Queries, eventType pkg.EventType, id int64) error {
+	stored, err := queries.GetReservationByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	reservation, err := loadReservation(ctx, queries, stored)
+	if err != nil {
+		return err
+	}
+	return insertEvent(ctx, queries, pkg.NewReservationEvent(eventType, reservation))
+}
+
+// duplicateError looks up the reservation already holding the slot that
+// reservation collided with.
+func duplicateError(ctx context.Context, queries *gen.Queries, reservation *pkg.Reservation) error {
+	id, err := queries.GetReservationIDBySlot(ctx, gen.GetReservationIDBySlotParams{UserID: reservation.UserID, Type: reservation.MealTypeID, ActiveServiceDate: sql.NullTime{Time: pkg.Date(reservation.ServiceDate), Valid: true}})
+	if err != nil {
+		return pkg.ErrReservationAlreadyExists
+	}
+	return pkg.ErrDuplicateReservation{ExistingID: id}
+}
+
+func (r *reservationRepository) Headcount(ctx context.Context, from, to time.Time, groupBy pkg.HeadcountGrouping, sites []int64) ([]pkg.Headcount, error) {
+	rows, err := r.queries.Headcount(ctx, gen.HeadcountParams{ByMeal: groupBy.Meal, BySite: groupBy.Site, ByDepartment: groupBy.Department, ByDiet: groupBy.Diet, ByAllergen: groupBy.Allergen, FromDate: pkg.Date(from), ToDate: pkg.Date(to), Sites: siteList(sites)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Headcount
+	for _, row := range rows {
+		list = append(list, pkg.Headcount{ServiceDate: row.ServiceDate, MealTypeID: row.MealTypeID, SiteID: row.Site, Department: row.DepartmentName, Diet: pkg.Diet(row.DietName), Allergen: row.Allergen, Employees: row.Employees, Guests: row.Guests})
+	}
+	return list, nil
+}
+
+// dietOverride is the stored form of a reservation's dietary override; a
+// NULL diet_override means the user's own profile applies.
+func dietOverride(reservation *pkg.Reservation) sql.NullString {
+	if reservation.Dietary == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(reservation.Dietary.Diet), Valid: true}
+}
+
+func insertReservationAllergens(ctx context.Context, queries *gen.Queries, reservation *pkg.Reservation) error {
+	if reservation.Dietary == nil {
+		return nil
+	}
+	for _, code := range reservation.Dietary.Allergens {
+		if err := queries.CreateReservationAllergen(ctx, gen.CreateReservationAllergenParams{ReservationID: reservation.ID, AllergenCode: code}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadReservation converts a row, fetching the allergens of its dietary
+// override if it has one.
+func loadReservation(ctx context.Context, queries *gen.Queries, reservation gen.Reservation) (pkg.Reservation, error) {
+	found := pkg.Reservation{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, MealTypeID: reservation.Type, NoOfGuests: reservation.NoOfGuests, SiteID: reservation.SiteID, ServiceDate: reservation.ServiceDate, Status: pkg.ReservationStatus(reservation.Status), CreatedAt: reservation.CreatedAt, Version: reservation.Version}
+	if reservation.CheckedInAt.Valid {
+		found.CheckedInAt = &reservation.CheckedInAt.Time
+	}
+	if !reservation.DietOverride.Valid {
+		return found, nil
+	}
+	allergens, err := queries.ListReservationAllergens(ctx, reservation.ID)
+	if err != nil {
+		return found, err
+	}
+	found.Dietary = &pkg.DietaryProfile{Diet: pkg.Diet(reservation.DietOverride.String), Allergens: allergens}
+	return found, nil
 }
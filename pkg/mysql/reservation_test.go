@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// testDB connects to the database in MESSAPP_TEST_DB_SOURCE and migrates it,
+// skipping the test when there is none.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	source := os.Getenv("MESSAPP_TEST_DB_SOURCE")
+	if source == "" {
+		t.Skip("MESSAPP_TEST_DB_SOURCE is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	db, err := NewDB(ctx, source)
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate("file://migrations", db); err != nil {
+		t.Fatalf("could not migrate: %v", err)
+	}
+	return db
+}
+
+// TestFindByDateAcrossDST books meals at a Berlin site just before and after
+// local midnight on the days its clocks change, where the UTC day and the
+// local day differ, and finds them by their local service date.
+func TestFindByDateAcrossDST(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("could not load time zone: %v", err)
+	}
+
+	// names are unique to the run, so that it can share a database with
+	// earlier ones.
+	run := time.Now().UnixNano()
+	site := pkg.Site{Name: fmt.Sprintf("Berlin %d", run), Timezone: berlin.String()}
+	if err := NewSiteRepository(db).Insert(ctx, &site); err != nil {
+		t.Fatalf("could not insert site: %v", err)
+	}
+	mealType := pkg.MealType{Code: fmt.Sprintf("late-%d", run), Name: "Late snack", ServingStart: 0, ServingEnd: 60, ActiveDays: pkg.AllWeekdays, Active: true}
+	if err := NewMealTypeRepository(db).Insert(ctx, &mealType); err != nil {
+		t.Fatalf("could not insert meal type: %v", err)
+	}
+	userRepository := NewUserRepository(db)
+	newUser := func(name string) int64 {
+		user := pkg.User{Name: name, Password: "secret", EmployeeID: fmt.Sprintf("%s-%d", name, run), SiteID: site.ID, Dietary: pkg.DietaryProfile{Diet: pkg.DietOmnivore}}
+		if err := userRepository.Insert(ctx, &user); err != nil {
+			t.Fatalf("could not insert user: %v", err)
+		}
+		return user.ID
+	}
+	before, after := newUser("before"), newUser("after")
+
+	reservations := NewReservationRepository(db)
+	book := func(userID int64, at time.Time) {
+		reservation := pkg.Reservation{UserID: userID, ReservationTime: at, MealTypeID: mealType.ID, SiteID: site.ID, ServiceDate: pkg.ServiceDate(at, berlin)}
+		if err := reservations.Insert(ctx, &reservation, nil); err != nil {
+			t.Fatalf("could not insert reservation at %v: %v", at, err)
+		}
+	}
+
+	// 23:30 on the day before and 00:30 on the day itself, local time.
+	book(before, time.Date(2026, 3, 28, 22, 30, 0, 0, time.UTC))
+	book(after, time.Date(2026, 3, 28, 23, 30, 0, 0, time.UTC))
+	book(before, time.Date(2026, 3, 29, 21, 30, 0, 0, time.UTC))
+	book(after, time.Date(2026, 3, 29, 22, 30, 0, 0, time.UTC))
+	book(before, time.Date(2026, 10, 24, 21, 30, 0, 0, time.UTC))
+	book(after, time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC))
+	book(before, time.Date(2026, 10, 25, 22, 30, 0, 0, time.UTC))
+	book(after, time.Date(2026, 10, 25, 23, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		date time.Time
+		want map[int64]time.Time
+	}{
+		{time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC), map[int64]time.Time{before: time.Date(2026, 3, 28, 22, 30, 0, 0, time.UTC)}},
+		{time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC), map[int64]time.Time{after: time.Date(2026, 3, 28, 23, 30, 0, 0, time.UTC), before: time.Date(2026, 3, 29, 21, 30, 0, 0, time.UTC)}},
+		{time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC), map[int64]time.Time{after: time.Date(2026, 3, 29, 22, 30, 0, 0, time.UTC)}},
+		{time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC), map[int64]time.Time{before: time.Date(2026, 10, 24, 21, 30, 0, 0, time.UTC)}},
+		{time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), map[int64]time.Time{after: time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC), before: time.Date(2026, 10, 25, 22, 30, 0, 0, time.UTC)}},
+		{time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC), map[int64]time.Time{after: time.Date(2026, 10, 25, 23, 30, 0, 0, time.UTC)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date.Format("2006-01-02"), func(t *testing.T) {
+			list, err := reservations.FindByDate(ctx, tt.date)
+			if err != nil {
+				t.Fatalf("FindByDate() error = %v", err)
+			}
+			got := make(map[int64]time.Time)
+			for _, reservation := range list {
+				if reservation.UserID != before && reservation.UserID != after {
+					continue
+				}
+				got[reservation.UserID] = reservation.ReservationTime
+				if !reservation.ServiceDate.Equal(tt.date) {
+					t.Errorf("reservation of user %d has ServiceDate %v, want %v", reservation.UserID, reservation.ServiceDate, tt.date)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("FindByDate() = %v, want %v", got, tt.want)
+			}
+			for userID, at := range tt.want {
+				if !got[userID].Equal(at) {
+					t.Errorf("reservation of user %d at %v, want %v", userID, got[userID], at)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type siteRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewSiteRepository(db *sql.DB) pkg.SiteRepository {
+	return &siteRepository{db: db, queries: gen.New(db)}
+}
+
+func (s *siteRepository) Insert(ctx context.Context, site *pkg.Site) error {
+	inserted, err := s.queries.CreateSite(ctx, gen.CreateSiteParams{Name: site.Name, Timezone: site.Timezone, Address: site.Address, DefaultCapacity: site.DefaultCapacity})
+	if isDuplicateEntry(err) {
+		return pkg.ErrSiteAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	site.ID, _ = inserted.LastInsertId()
+	return nil
+}
+
+func (s *siteRepository) FindAll(ctx context.Context) ([]pkg.Site, error) {
+	sites, err := s.queries.ListSites(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Site
+	for _, site := range sites {
+		list = append(list, toSite(site))
+	}
+	return list, nil
+}
+
+func (s *siteRepository) FindByID(ctx context.Context, id int64) (*pkg.Site, error) {
+	site, err := s.queries.GetSiteByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrSiteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := toSite(site)
+	return &found, nil
+}
+
+func (s *siteRepository) Update(ctx context.Context, site *pkg.Site) error {
+	updated, err := s.queries.UpdateSite(ctx, gen.UpdateSiteParams{ID: site.ID, Name: site.Name, Timezone: site.Timezone, Address: site.Address, DefaultCapacity: site.DefaultCapacity})
+	if isDuplicateEntry(err) {
+		return pkg.ErrSiteAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	// like meal types, an update changing nothing affects no rows.
+	if n, _ := updated.RowsAffected(); n == 0 {
+		if _, err := s.queries.GetSiteByID(ctx, site.ID); err == sql.ErrNoRows {
+			return pkg.ErrSiteNotFound
+		}
+	}
+	return nil
+}
+
+func (s *siteRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := s.queries.DeleteSite(ctx, id)
+	if isRowReferenced(err) {
+		return pkg.ErrSiteInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrSiteNotFound
+	}
+	return nil
+}
+
+func (s *siteRepository) FindManagers(ctx context.Context, siteID int64) ([]int64, error) {
+	return s.queries.ListSiteManagers(ctx, siteID)
+}
+
+func (s *siteRepository) SetManagers(ctx context.Context, siteID int64, userIDs []int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := s.queries.WithTx(tx)
+
+	if _, err := queries.GetSiteByID(ctx, siteID); err == sql.ErrNoRows {
+		return pkg.ErrSiteNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := queries.DeleteSiteManagers(ctx, siteID); err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		err := queries.CreateSiteManager(ctx, gen.CreateSiteManagerParams{SiteID: siteID, UserID: userID})
+		if isMissingReference(err) {
+			return pkg.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *siteRepository) FindManagedBy(ctx context.Context, userID int64) ([]int64, error) {
+	return s.queries.ListSitesManagedBy(ctx, userID)
+}
+
+func toSite(site gen.Site) pkg.Site {
+	return pkg.Site{ID: site.ID, Name: site.Name, Timezone: site.Timezone, Address: site.Address, DefaultCapacity: site.DefaultCapacity, CreatedAt: site.CreatedAt}
+}
+
+// siteList is the form queries take a set of sites in, a comma separated
+// list for FIND_IN_SET. Every site is passed as an empty list.
+func siteList(sites []int64) string {
+	ids := make([]string, 0, len(sites))
+	for _, id := range sites {
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(ids, ",")
+}
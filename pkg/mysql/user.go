@@ -9,19 +9,45 @@ import (
 )
 
 type userRepository struct {
+	db      *sql.DB
 	queries *gen.Queries
 }
 
 func NewUserRepository(db *sql.DB) pkg.UserRepository {
-	return &userRepository{queries: gen.New(db)}
+	return &userRepository{db: db, queries: gen.New(db)}
 }
 
 func (u *userRepository) Insert(ctx context.Context, user *pkg.User) error {
-	inserted, err := u.queries.CreateUser(ctx, gen.CreateUserParams{Name: user.Name, Password: user.Password, Designation: user.Designation, EmployeeID: user.EmployeeID})
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := u.queries.WithTx(tx)
+
+	inserted, err := queries.CreateUser(ctx, gen.CreateUserParams{Name: user.Name, Password: user.Password, Designation: user.Designation, Department: user.Department, EmployeeID: user.EmployeeID, Diet: string(user.Dietary.Diet), SiteID: user.SiteID})
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
 	if err != nil {
 		return err
 	}
 	user.ID, _ = inserted.LastInsertId()
+	if err := insertUserAllergens(ctx, queries, user.ID, user.Dietary.Allergens); err != nil {
+		return err
+	}
+	created, err := findUser(ctx, queries, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, queries, pkg.NewUserEvent(pkg.EventUserCreated, *created)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	user.Version = 1
 	return nil
 }
 
@@ -30,20 +56,25 @@ func (u *userRepository) FindAll(ctx context.Context) ([]pkg.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	allergens, err := u.queries.ListAllUserAllergens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[int64][]string)
+	for _, a := range allergens {
+		byUser[a.UserID] = append(byUser[a.UserID], a.AllergenCode)
+	}
 
 	var list []pkg.User
 	for _, user := range users {
-		list = append(list, pkg.User{ID: user.ID, Name: user.Name, Password: user.Password, Designation: user.Designation, EmployeeID: user.EmployeeID, CreatedAt: user.CreatedAt})
+		list = append(list, toUser(user, byUser[user.ID]))
 	}
 	return list, nil
 }
 
 func (u *userRepository) FindByID(ctx context.Context, id int64) (*pkg.User, error) {
-	user, err := u.queries.GetUserByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	return &pkg.User{ID: user.ID, Name: user.Name, Password: user.Password, Designation: user.Designation, EmployeeID: user.EmployeeID, CreatedAt: user.CreatedAt}, nil
+	return findUser(ctx, u.queries, id)
 }
 
 func (u *userRepository) FindByEmployeeID(ctx context.Context, employee_id string) (*pkg.User, error) {
@@ -51,13 +82,112 @@ func (u *userRepository) FindByEmployeeID(ctx context.Context, employee_id strin
 	if err != nil {
 		return nil, err
 	}
-	return &pkg.User{ID: user.ID, Name: user.Name, Password: user.Password, Designation: user.Designation, EmployeeID: user.EmployeeID, CreatedAt: user.CreatedAt}, nil
+	allergens, err := u.queries.ListUserAllergens(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	found := toUser(user, allergens)
+	return &found, nil
 }
 
+// Update writes every field of user and reloads it, so the caller sees the
+// new version. The version check and bump happen in the same statement.
 func (u *userRepository) Update(ctx context.Context, user *pkg.User) error {
-	return u.queries.UpdateUser(ctx, gen.UpdateUserParams{ID: user.ID, Password: user.Password})
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := u.queries.WithTx(tx)
+
+	updated, err := queries.UpdateUser(ctx, gen.UpdateUserParams{ID: user.ID, Name: user.Name, Password: user.Password, Designation: user.Designation, Department: user.Department, EmployeeID: user.EmployeeID, Diet: string(user.Dietary.Diet), SiteID: user.SiteID, ExpectedVersion: user.Version})
+	if isMissingReference(err) {
+		return pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := updated.RowsAffected(); n == 0 {
+		return u.missingOrModified(ctx, user.ID)
+	}
+	if err := queries.DeleteUserAllergens(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := insertUserAllergens(ctx, queries, user.ID, user.Dietary.Allergens); err != nil {
+		return err
+	}
+	stored, err := findUser(ctx, queries, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, queries, pkg.NewUserEvent(pkg.EventUserUpdated, *stored)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*user = *stored
+	return nil
+}
+
+func (u *userRepository) DeleteByID(ctx context.Context, id, version int64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := u.queries.WithTx(tx)
+
+	removed, err := findUser(ctx, queries, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := queries.DeleteUser(ctx, gen.DeleteUserParams{ID: id, ExpectedVersion: version})
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return u.missingOrModified(ctx, id)
+	}
+	if err := insertEvent(ctx, queries, pkg.NewUserEvent(pkg.EventUserRemoved, *removed)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// missingOrModified explains why a versioned write matched no rows.
+func (u *userRepository) missingOrModified(ctx context.Context, id int64) error {
+	if _, err := u.queries.GetUserByID(ctx, id); err == sql.ErrNoRows {
+		return pkg.ErrUserNotFound
+	}
+	return pkg.ErrUserModified
+}
+
+func findUser(ctx context.Context, queries *gen.Queries, id int64) (*pkg.User, error) {
+	user, err := queries.GetUserByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	allergens, err := queries.ListUserAllergens(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	found := toUser(user, allergens)
+	return &found, nil
+}
+
+func insertUserAllergens(ctx context.Context, queries *gen.Queries, userID int64, allergens []string) error {
+	for _, code := range allergens {
+		if err := queries.CreateUserAllergen(ctx, gen.CreateUserAllergenParams{UserID: userID, AllergenCode: code}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (u *userRepository) DeleteByID(ctx context.Context, id int64) error {
-	return u.queries.DeleteUser(ctx, id)
+func toUser(user gen.User, allergens []string) pkg.User {
+	return pkg.User{ID: user.ID, Name: user.Name, Password: user.Password, Designation: user.Designation, Department: user.Department, EmployeeID: user.EmployeeID, SiteID: user.SiteID, Dietary: pkg.DietaryProfile{Diet: pkg.Diet(user.Diet), Allergens: allergens}, CreatedAt: user.CreatedAt, Version: user.Version}
 }
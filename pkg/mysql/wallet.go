@@ -0,0 +1,275 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type walletRepository struct {
+	db      *sql.DB
+	queries *gen.Queries
+}
+
+func NewWalletRepository(db *sql.DB) pkg.WalletRepository {
+	return &walletRepository{db: db, queries: gen.New(db)}
+}
+
+func (w *walletRepository) Open(ctx context.Context, userID int64) (*pkg.Wallet, error) {
+	err := w.queries.CreateWallet(ctx, userID)
+	if isDuplicateEntry(err) {
+		return nil, pkg.ErrWalletAlreadyExists
+	}
+	if isMissingReference(err) {
+		return nil, pkg.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.FindByUser(ctx, userID)
+}
+
+func (w *walletRepository) FindByUser(ctx context.Context, userID int64) (*pkg.Wallet, error) {
+	wallet, err := w.queries.GetWallet(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance, err := w.queries.GetAccountBalance(ctx, pkg.WalletAccount(userID))
+	if err != nil {
+		return nil, err
+	}
+	held, err := w.queries.GetAccountBalance(ctx, pkg.HoldAccount(userID))
+	if err != nil {
+		return nil, err
+	}
+	return &pkg.Wallet{UserID: wallet.UserID, Balance: pkg.Money(balance), Held: pkg.Money(held), CreatedAt: wallet.CreatedAt}, nil
+}
+
+// Post holds the lock on the wallet row from reading the balance until the
+// transaction is committed, so concurrent postings cannot both spend the
+// same money.
+func (w *walletRepository) Post(ctx context.Context, transaction *pkg.LedgerTransaction) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := w.queries.WithTx(tx)
+
+	if err := lockWallet(ctx, queries, transaction.UserID); err != nil {
+		return err
+	}
+	if err := checkFunds(ctx, queries, transaction.UserID, transaction.WalletDelta()); err != nil {
+		return err
+	}
+	if err := postTransaction(ctx, queries, transaction); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (w *walletRepository) Hold(ctx context.Context, hold *pkg.WalletHold) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	placed := *hold
+	if err := holdFunds(ctx, w.queries.WithTx(tx), &placed, time.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*hold = placed
+	return nil
+}
+
+// holdFunds places hold within the transaction of queries, as Hold does.
+func holdFunds(ctx context.Context, queries *gen.Queries, hold *pkg.WalletHold, now time.Time) error {
+	if err := lockWallet(ctx, queries, hold.UserID); err != nil {
+		return err
+	}
+
+	existing, err := queries.GetOpenHold(ctx, hold.ReservationID)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case existing.Amount == int64(hold.Amount) && existing.UserID == hold.UserID:
+		*hold = toWalletHold(existing)
+		return nil
+	default:
+		if err := settleHold(ctx, queries, existing, false); err != nil {
+			return err
+		}
+	}
+
+	if err := checkFunds(ctx, queries, hold.UserID, -hold.Amount); err != nil {
+		return err
+	}
+	inserted, err := queries.CreateWalletHold(ctx, gen.CreateWalletHoldParams{UserID: hold.UserID, ReservationID: hold.ReservationID, Amount: int64(hold.Amount), CreatedAt: now})
+	if err != nil {
+		return err
+	}
+	reservationID := hold.ReservationID
+	if err := postTransaction(ctx, queries, &pkg.LedgerTransaction{UserID: hold.UserID, Kind: pkg.TransactionHold, Amount: hold.Amount, ReservationID: &reservationID, CreatedAt: now}); err != nil {
+		return err
+	}
+	hold.ID, _ = inserted.LastInsertId()
+	hold.Status = pkg.HoldOpen
+	hold.CreatedAt = now
+	return nil
+}
+
+func (w *walletRepository) Settle(ctx context.Context, reservationID int64, capture bool) error {
+	hold, err := w.queries.GetOpenHold(ctx, reservationID)
+	if err == sql.ErrNoRows {
+		return pkg.ErrHoldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := w.queries.WithTx(tx)
+
+	if err := lockWallet(ctx, queries, hold.UserID); err != nil {
+		return err
+	}
+	// the hold may have been settled while waiting for the lock
+	hold, err = queries.GetOpenHold(ctx, reservationID)
+	if err == sql.ErrNoRows {
+		return pkg.ErrHoldNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := settleHold(ctx, queries, hold, capture); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (w *walletRepository) FindOpenHolds(ctx context.Context) ([]pkg.WalletHold, error) {
+	holds, err := w.queries.ListOpenHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.WalletHold
+	for _, hold := range holds {
+		list = append(list, toWalletHold(hold))
+	}
+	return list, nil
+}
+
+func (w *walletRepository) FindHeldReservations(ctx context.Context, first, last time.Time) ([]int64, error) {
+	return w.queries.ListHeldReservationIDs(ctx, gen.ListHeldReservationIDsParams{First: pkg.Date(first), Last: pkg.Date(last)})
+}
+
+func (w *walletRepository) Movements(ctx context.Context, userID int64) ([]pkg.WalletMovement, error) {
+	rows, err := w.queries.ListAccountMovements(ctx, pkg.WalletAccount(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.WalletMovement
+	var balance pkg.Money
+	for _, row := range rows {
+		balance += pkg.Money(row.Delta)
+		list = append(list, pkg.WalletMovement{
+			Transaction: pkg.LedgerTransaction{ID: row.ID, UserID: row.UserID, Kind: pkg.TransactionKind(row.Kind), Amount: pkg.Money(row.Amount), ReservationID: nullInt64(row.ReservationID), Description: row.Description, CreatedAt: row.CreatedAt},
+			Amount:      pkg.Money(row.Delta),
+			Balance:     balance,
+		})
+	}
+	return list, nil
+}
+
+func lockWallet(ctx context.Context, queries *gen.Queries, userID int64) error {
+	_, err := queries.LockWallet(ctx, userID)
+	if err == sql.ErrNoRows {
+		return pkg.ErrWalletNotFound
+	}
+	return err
+}
+
+// checkFunds fails when changing the available balance by delta would make
+// it negative. The wallet must be locked by the caller.
+func checkFunds(ctx context.Context, queries *gen.Queries, userID int64, delta pkg.Money) error {
+	if delta >= 0 {
+		return nil
+	}
+	balance, err := queries.GetAccountBalance(ctx, pkg.WalletAccount(userID))
+	if err != nil {
+		return err
+	}
+	if pkg.Money(balance)+delta < 0 {
+		return pkg.ErrInsufficientFunds
+	}
+	return nil
+}
+
+func postTransaction(ctx context.Context, queries *gen.Queries, transaction *pkg.LedgerTransaction) error {
+	if transaction.CreatedAt.IsZero() {
+		transaction.CreatedAt = time.Now()
+	}
+	var reservationID sql.NullInt64
+	if transaction.ReservationID != nil {
+		reservationID = sql.NullInt64{Int64: *transaction.ReservationID, Valid: true}
+	}
+	inserted, err := queries.CreateLedgerTransaction(ctx, gen.CreateLedgerTransactionParams{UserID: transaction.UserID, Kind: string(transaction.Kind), Amount: int64(transaction.Amount), ReservationID: reservationID, Description: transaction.Description, CreatedAt: transaction.CreatedAt})
+	if err != nil {
+		return err
+	}
+	transaction.ID, _ = inserted.LastInsertId()
+	for _, entry := range transaction.Entries() {
+		if err := queries.CreateLedgerEntry(ctx, gen.CreateLedgerEntryParams{TransactionID: transaction.ID, Account: entry.Account, Amount: int64(entry.Amount)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleHold moves the held amount to revenue or back into the wallet and
+// closes the hold.
+func settleHold(ctx context.Context, queries *gen.Queries, hold gen.WalletHold, capture bool) error {
+	kind, status := pkg.TransactionRelease, pkg.HoldReleased
+	if capture {
+		kind, status = pkg.TransactionCapture, pkg.HoldCaptured
+	}
+	now := time.Now()
+	reservationID := hold.ReservationID
+	if err := postTransaction(ctx, queries, &pkg.LedgerTransaction{UserID: hold.UserID, Kind: kind, Amount: pkg.Money(hold.Amount), ReservationID: &reservationID, CreatedAt: now}); err != nil {
+		return err
+	}
+	return queries.SettleWalletHold(ctx, gen.SettleWalletHoldParams{Status: string(status), SettledAt: sql.NullTime{Time: now, Valid: true}, ID: hold.ID})
+}
+
+func toWalletHold(hold gen.WalletHold) pkg.WalletHold {
+	result := pkg.WalletHold{ID: hold.ID, UserID: hold.UserID, ReservationID: hold.ReservationID, Amount: pkg.Money(hold.Amount), Status: pkg.HoldStatus(hold.Status), CreatedAt: hold.CreatedAt}
+	if hold.SettledAt.Valid {
+		settledAt := hold.SettledAt.Time
+		result.SettledAt = &settledAt
+	}
+	return result
+}
+
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
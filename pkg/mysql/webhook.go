@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mysql/gen"
+)
+
+type webhookRepository struct {
+	queries *gen.Queries
+}
+
+func NewWebhookRepository(db *sql.DB) pkg.WebhookRepository {
+	return &webhookRepository{queries: gen.New(db)}
+}
+
+func (w *webhookRepository) Insert(ctx context.Context, webhook *pkg.Webhook) error {
+	now := time.Now()
+	inserted, err := w.queries.CreateWebhook(ctx, gen.CreateWebhookParams{Url: webhook.URL, EventTypes: joinEventTypes(webhook.EventTypes), Secret: webhook.Secret, CreatedAt: now})
+	if err != nil {
+		return err
+	}
+	webhook.ID, _ = inserted.LastInsertId()
+	webhook.CreatedAt = now
+	return nil
+}
+
+func (w *webhookRepository) FindAll(ctx context.Context) ([]pkg.Webhook, error) {
+	webhooks, err := w.queries.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.Webhook
+	for _, webhook := range webhooks {
+		list = append(list, toWebhook(webhook))
+	}
+	return list, nil
+}
+
+func (w *webhookRepository) FindByID(ctx context.Context, id int64) (*pkg.Webhook, error) {
+	webhook, err := w.queries.GetWebhook(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := toWebhook(webhook)
+	return &found, nil
+}
+
+func (w *webhookRepository) DeleteByID(ctx context.Context, id int64) error {
+	deleted, err := w.queries.DeleteWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := deleted.RowsAffected(); n == 0 {
+		return pkg.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (w *webhookRepository) InsertDelivery(ctx context.Context, delivery *pkg.WebhookDelivery) error {
+	now := time.Now()
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = now
+	}
+	inserted, err := w.queries.CreateWebhookDelivery(ctx, gen.CreateWebhookDeliveryParams{WebhookID: delivery.WebhookID, EventID: delivery.EventID, EventType: string(delivery.EventType), Payload: delivery.Payload, NextAttemptAt: delivery.NextAttemptAt, CreatedAt: now})
+	if isMissingReference(err) {
+		return pkg.ErrWebhookNotFound
+	}
+	if err != nil {
+		return err
+	}
+	delivery.ID, _ = inserted.LastInsertId()
+	delivery.Status = pkg.DeliveryPending
+	delivery.CreatedAt = now
+	return nil
+}
+
+func (w *webhookRepository) FindDelivery(ctx context.Context, id int64) (*pkg.WebhookDelivery, error) {
+	delivery, err := w.queries.GetWebhookDelivery(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, pkg.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := toWebhookDelivery(delivery)
+	return &found, nil
+}
+
+func (w *webhookRepository) FindDeliveries(ctx context.Context, webhookID, limit int64) ([]pkg.WebhookDelivery, error) {
+	deliveries, err := w.queries.ListWebhookDeliveries(ctx, gen.ListWebhookDeliveriesParams{WebhookID: webhookID, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.WebhookDelivery
+	for _, delivery := range deliveries {
+		list = append(list, toWebhookDelivery(delivery))
+	}
+	return list, nil
+}
+
+// ClaimDue only keeps the deliveries it managed to push back itself, so a
+// delivery claimed by another dispatcher in between is skipped.
+func (w *webhookRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int64) ([]pkg.WebhookDelivery, error) {
+	due, err := w.queries.ListDueWebhookDeliveries(ctx, gen.ListDueWebhookDeliveriesParams{NextAttemptAt: now, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []pkg.WebhookDelivery
+	for _, delivery := range due {
+		claimed, err := w.queries.ClaimWebhookDelivery(ctx, gen.ClaimWebhookDeliveryParams{LeaseUntil: leaseUntil, ID: delivery.ID, Now: now})
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := claimed.RowsAffected(); n == 0 {
+			continue
+		}
+		delivery.NextAttemptAt = leaseUntil
+		list = append(list, toWebhookDelivery(delivery))
+	}
+	return list, nil
+}
+
+func (w *webhookRepository) UpdateDelivery(ctx context.Context, delivery *pkg.WebhookDelivery) error {
+	var deliveredAt sql.NullTime
+	if delivery.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: *delivery.DeliveredAt, Valid: true}
+	}
+	return w.queries.UpdateWebhookDelivery(ctx, gen.UpdateWebhookDeliveryParams{Status: string(delivery.Status), Attempts: delivery.Attempts, NextAttemptAt: delivery.NextAttemptAt, ResponseStatus: delivery.ResponseStatus, LastError: delivery.LastError, DeliveredAt: deliveredAt, ID: delivery.ID})
+}
+
+func toWebhook(webhook gen.Webhook) pkg.Webhook {
+	result := pkg.Webhook{ID: webhook.ID, URL: webhook.Url, Secret: webhook.Secret, CreatedAt: webhook.CreatedAt}
+	for _, t := range strings.Split(webhook.EventTypes, ",") {
+		if t != "" {
+			result.EventTypes = append(result.EventTypes, pkg.EventType(t))
+		}
+	}
+	return result
+}
+
+func joinEventTypes(types []pkg.EventType) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = string(t)
+	}
+	return strings.Join(parts, ",")
+}
+
+func toWebhookDelivery(delivery gen.WebhookDelivery) pkg.WebhookDelivery {
+	result := pkg.WebhookDelivery{ID: delivery.ID, WebhookID: delivery.WebhookID, EventID: delivery.EventID, EventType: pkg.EventType(delivery.EventType), Payload: delivery.Payload, Status: pkg.DeliveryStatus(delivery.Status), Attempts: delivery.Attempts, NextAttemptAt: delivery.NextAttemptAt, ResponseStatus: delivery.ResponseStatus, LastError: delivery.LastError, CreatedAt: delivery.CreatedAt}
+	if delivery.DeliveredAt.Valid {
+		deliveredAt := delivery.DeliveredAt.Time
+		result.DeliveredAt = &deliveredAt
+	}
+	return result
+}
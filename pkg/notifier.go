@@ -0,0 +1,13 @@
+package pkg
+
+import "context"
+
+type Notification struct {
+	UserID  int64
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Notify(context.Context, Notification) error
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// SMTPConfig is where and as whom email is sent. Username and Password are
+// only used when Username is set; the server must then offer TLS, unless it
+// runs on localhost.
+type SMTPConfig struct {
+	Address  string
+	Username string
+	Password string
+	From     string
+}
+
+// NewEmailNotifier returns a Notifier that mails every notification to the
+// address in the preferences of its user. Notifications of users without an
+// address go to fallback instead.
+func NewEmailNotifier(config SMTPConfig, preferences pkg.NotificationPreferenceRepository, fallback pkg.Notifier) pkg.Notifier {
+	return &emailNotifier{config: config, preferences: preferences, fallback: fallback}
+}
+
+type emailNotifier struct {
+	config      SMTPConfig
+	preferences pkg.NotificationPreferenceRepository
+	fallback    pkg.Notifier
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, notification pkg.Notification) error {
+	preferences, err := n.preferences.FindByUser(ctx, notification.UserID)
+	if err != nil {
+		return fmt.Errorf("could not find notification preferences: %v", err)
+	}
+	if preferences.Email == "" {
+		return n.fallback.Notify(ctx, notification)
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		host, _, _ := net.SplitHostPort(n.config.Address)
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, host)
+	}
+	return smtp.SendMail(n.config.Address, auth, n.config.From, []string{preferences.Email}, message(n.config.From, preferences.Email, notification))
+}
+
+// message builds a plain text email. The subject loses any line breaks, so
+// that it cannot add headers of its own.
+func message(from, to string, notification pkg.Notification) []byte {
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.Subject)
+	body := strings.ReplaceAll(strings.ReplaceAll(notification.Body, "\r\n", "\n"), "\n", "\r\n")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
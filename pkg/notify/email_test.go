@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// mail is what a fake SMTP server received in one session.
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer accepts a single SMTP session on a local port and sends what it
+// received on the returned channel once the client quits.
+func smtpServer(t *testing.T) (string, <-chan mail) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan mail, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		c := textproto.NewConn(conn)
+
+		var m mail
+		c.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO":
+				c.PrintfLine("250-localhost")
+				c.PrintfLine("250 8BITMIME")
+			case "MAIL":
+				m.from = address(line)
+				c.PrintfLine("250 OK")
+			case "RCPT":
+				m.to = append(m.to, address(line))
+				c.PrintfLine("250 OK")
+			case "DATA":
+				c.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(c.DotReader())
+				if err != nil {
+					return
+				}
+				m.data = string(data)
+				c.PrintfLine("250 OK")
+			case "QUIT":
+				c.PrintfLine("221 Bye")
+				received <- m
+				return
+			default:
+				c.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+// address returns the address between the angle brackets of a MAIL or RCPT
+// command.
+func address(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+type preferences map[int64]pkg.NotificationPreferences
+
+func (p preferences) Save(_ context.Context, preferences *pkg.NotificationPreferences) error {
+	p[preferences.UserID] = *preferences
+	return nil
+}
+
+func (p preferences) FindByUser(_ context.Context, userID int64) (*pkg.NotificationPreferences, error) {
+	if preferences, ok := p[userID]; ok {
+		return &preferences, nil
+	}
+	preferences := pkg.DefaultNotificationPreferences(userID)
+	return &preferences, nil
+}
+
+func (p preferences) FindAll(context.Context) ([]pkg.NotificationPreferences, error) {
+	var list []pkg.NotificationPreferences
+	for _, preferences := range p {
+		list = append(list, preferences)
+	}
+	return list, nil
+}
+
+// notifications records what it is asked to notify.
+type notifications []pkg.Notification
+
+func (n *notifications) Notify(_ context.Context, notification pkg.Notification) error {
+	*n = append(*n, notification)
+	return nil
+}
+
+func TestEmailNotifierSendsMail(t *testing.T) {
+	addr, received := smtpServer(t)
+	fallback := &notifications{}
+	notifier := NewEmailNotifier(
+		SMTPConfig{Address: addr, From: "mess@example.com"},
+		preferences{7: {UserID: 7, Email: "ana@example.com"}},
+		fallback,
+	)
+
+	notification := pkg.Notification{UserID: 7, Subject: "Lunch\r\nBcc: eve@example.com", Body: "Served from 12:00.\nEnjoy."}
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	m := <-received
+	if m.from != "mess@example.com" {
+		t.Errorf("MAIL FROM = %q, want %q", m.from, "mess@example.com")
+	}
+	if len(m.to) != 1 || m.to[0] != "ana@example.com" {
+		t.Errorf("RCPT TO = %q, want [ana@example.com]", m.to)
+	}
+
+	header, body := m.data, ""
+	if i := strings.Index(m.data, "\n\n"); i >= 0 {
+		header, body = m.data[:i], m.data[i+2:]
+	}
+	for _, want := range []string{"From: mess@example.com", "To: ana@example.com", "Subject: Lunch  Bcc: eve@example.com", "Content-Type: text/plain; charset=utf-8"} {
+		if !strings.Contains(header, want+"\n") {
+			t.Errorf("header lacks %q:\n%s", want, header)
+		}
+	}
+	if strings.Contains(header, "\nBcc:") {
+		t.Errorf("subject added a header of its own:\n%s", header)
+	}
+	if body != "Served from 12:00.\nEnjoy.\n" {
+		t.Errorf("body = %q, want %q", body, "Served from 12:00.\nEnjoy.\n")
+	}
+	if len(*fallback) != 0 {
+		t.Errorf("fallback got %d notifications, want 0", len(*fallback))
+	}
+}
+
+func TestEmailNotifierFallsBackWithoutAddress(t *testing.T) {
+	fallback := &notifications{}
+	notifier := NewEmailNotifier(SMTPConfig{Address: "127.0.0.1:1", From: "mess@example.com"}, preferences{}, fallback)
+
+	notification := pkg.Notification{UserID: 8, Subject: "Dinner", Body: "Served from 19:00."}
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(*fallback) != 1 || (*fallback)[0] != notification {
+		t.Errorf("fallback got %v, want [%v]", *fallback, notification)
+	}
+}
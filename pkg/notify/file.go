@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// NewFileNotifier returns a Notifier that appends every notification to the
+// file at path as a line of JSON, creating the file if needed. It stands in
+// for a real delivery channel on a single machine.
+func NewFileNotifier(path string) pkg.Notifier {
+	return &fileNotifier{path: path}
+}
+
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (n *fileNotifier) Notify(_ context.Context, notification pkg.Notification) error {
+	line, err := json.Marshal(struct {
+		Time    time.Time `json:"time"`
+		UserID  int64     `json:"user_id"`
+		Subject string    `json:"subject"`
+		Body    string    `json:"body"`
+	}{time.Now(), notification.UserID, notification.Subject, notification.Body})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
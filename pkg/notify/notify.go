@@ -0,0 +1,51 @@
+// Package notify delivers notifications to users over the configured channels.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// Middleware describes a Notifier Middleware
+type Middleware func(pkg.Notifier) pkg.Notifier
+
+// NewLogNotifier returns a Notifier that only writes notifications to logger,
+// which is enough for local development and as a fallback channel.
+func NewLogNotifier(logger log.Logger) pkg.Notifier {
+	return &logNotifier{logger}
+}
+
+type logNotifier struct {
+	logger log.Logger
+}
+
+func (n *logNotifier) Notify(_ context.Context, notification pkg.Notification) error {
+	return n.logger.Log(
+		"channel", "log",
+		"user_id", notification.UserID,
+		"subject", notification.Subject,
+		"body", notification.Body)
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(n pkg.Notifier) pkg.Notifier { return &loggingMiddleware{logger, n} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	pkg.Notifier
+}
+
+func (n *loggingMiddleware) Notify(ctx context.Context, notification pkg.Notification) (err error) {
+	defer func(begin time.Time) {
+		n.logger.Log(
+			"method", "notify",
+			"user_id", notification.UserID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return n.Notifier.Notify(ctx, notification)
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxEvent is an event stored along with the change it describes, until
+// it is published. LastError describes the latest failed attempt.
+type OutboxEvent struct {
+	ID            int64
+	Event         Event
+	Attempts      int64
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+}
+
+// OutboxRepository reads the outbox. Events are written to it by the
+// repositories of users and reservations, in the transaction of each change.
+type OutboxRepository interface {
+	// ClaimDue returns up to limit unpublished events that are due at now
+	// and have no unpublished event of the same aggregate before them, each
+	// pushed back to leaseUntil so that no other relay picks it up meanwhile.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int64) ([]OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	// MarkFailed records the attempts, next attempt and last error of event.
+	MarkFailed(context.Context, *OutboxEvent) error
+	// DeletePublished removes up to limit events published before before and
+	// returns how many it removed.
+	DeletePublished(ctx context.Context, before time.Time, limit int64) (int64, error)
+}
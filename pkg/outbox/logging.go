@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Relay(ctx context.Context) (published int, err error) {
+	defer func(begin time.Time) {
+		if published == 0 && err == nil {
+			// an idle relay would otherwise log every interval.
+			return
+		}
+		s.logger.Log(
+			"method", "relay",
+			"published", published,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Relay(ctx)
+}
+
+func (s *loggingMiddleware) Cleanup(ctx context.Context) (removed int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "cleanup",
+			"removed", removed,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Cleanup(ctx)
+}
@@ -0,0 +1,144 @@
+// Package outbox publishes the events stored in the outbox, in the order of
+// each aggregate, to the configured sinks.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Relay publishes the due events to every sink and returns how many it
+	// published. An event is published again, to every sink, until all of
+	// them took it, so sinks see an event at least once and should tell
+	// repeats apart by its ID.
+	Relay(context.Context) (int, error)
+	// Cleanup removes the events published longer than the retention ago
+	// and returns how many it removed.
+	Cleanup(context.Context) (int64, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+const (
+	// FirstRetryDelay is the wait after the first failed attempt at an
+	// event. It doubles with every attempt after that, up to MaxRetryDelay.
+	// Events are never given up on, as those after them wait for them.
+	FirstRetryDelay = time.Second
+	MaxRetryDelay   = 5 * time.Minute
+
+	relayBatch   = 100
+	relayRounds  = 10
+	relayLease   = time.Minute
+	cleanupBatch = 1000
+)
+
+type service struct {
+	repository pkg.OutboxRepository
+	sinks      []pkg.EventPublisher
+	retention  time.Duration
+}
+
+// NewService keeps published events for retention before Cleanup removes
+// them.
+func NewService(repository pkg.OutboxRepository, retention time.Duration, sinks ...pkg.EventPublisher) Service {
+	return &service{repository: repository, sinks: sinks, retention: retention}
+}
+
+func (s *service) Relay(ctx context.Context) (int, error) {
+	published := 0
+	// every round takes at most the oldest event of each aggregate, so a
+	// busy aggregate takes a few rounds to catch up.
+	for round := 0; round < relayRounds; round++ {
+		now := time.Now()
+		due, err := s.repository.ClaimDue(ctx, now, now.Add(relayLease), relayBatch)
+		if err != nil {
+			return published, fmt.Errorf("could not claim events: %v", err)
+		}
+		if len(due) == 0 {
+			break
+		}
+
+		for i := range due {
+			event := &due[i]
+			if err := s.publish(ctx, event.Event); err != nil {
+				event.Attempts++
+				event.LastError = err.Error()
+				event.NextAttemptAt = time.Now().Add(retryDelay(event.Attempts))
+				if err := s.repository.MarkFailed(ctx, event); err != nil {
+					return published, fmt.Errorf("could not record failed event: %v", err)
+				}
+				continue
+			}
+			if err := s.repository.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+				return published, fmt.Errorf("could not mark event published: %v", err)
+			}
+			published++
+		}
+	}
+	return published, nil
+}
+
+func (s *service) Cleanup(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-s.retention)
+	var removed int64
+	for {
+		n, err := s.repository.DeletePublished(ctx, before, cleanupBatch)
+		removed += n
+		if err != nil {
+			return removed, fmt.Errorf("could not remove published events: %v", err)
+		}
+		if n < cleanupBatch {
+			return removed, nil
+		}
+	}
+}
+
+func (s *service) publish(ctx context.Context, event pkg.Event) error {
+	for _, sink := range s.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run relays events every interval and cleans up every cleanupInterval
+// until ctx is done. Failures are passed to report rather than stopping the
+// loop.
+func Run(ctx context.Context, service Service, interval, cleanupInterval time.Duration, report func(error)) {
+	relay := time.NewTicker(interval)
+	defer relay.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+	for {
+		if _, err := service.Relay(ctx); err != nil && ctx.Err() == nil {
+			report(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-relay.C:
+		case <-cleanup.C:
+			if _, err := service.Cleanup(ctx); err != nil && ctx.Err() == nil {
+				report(err)
+			}
+		}
+	}
+}
+
+// retryDelay is how long to wait after the given number of failed attempts.
+func retryDelay(attempts int64) time.Duration {
+	if attempts > 20 {
+		return MaxRetryDelay
+	}
+	delay := FirstRetryDelay << uint(attempts-1)
+	if delay > MaxRetryDelay {
+		return MaxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// NewLogSink returns a sink that only writes events to logger, which is
+// enough to follow them during local development.
+func NewLogSink(logger log.Logger) pkg.EventPublisher {
+	return &logSink{logger}
+}
+
+type logSink struct {
+	logger log.Logger
+}
+
+func (s *logSink) Publish(_ context.Context, event pkg.Event) error {
+	return s.logger.Log(
+		"sink", "log",
+		"event_id", event.ID,
+		"event_type", event.Type,
+		"aggregate", event.AggregateType,
+		"aggregate_id", event.AggregateID,
+		"data", string(event.Data))
+}
+
+// NewFileSink returns a sink that appends every event to the file at path as
+// a line of JSON, creating the file if needed.
+func NewFileSink(path string) pkg.EventPublisher {
+	return &fileSink{path: path}
+}
+
+type fileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *fileSink) Publish(_ context.Context, event pkg.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
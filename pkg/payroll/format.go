@@ -0,0 +1,193 @@
+package payroll
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// Deduction is what to deduct from the salary of one employee for a month.
+// Amounts are in minor units.
+type Deduction struct {
+	EmployeeID string
+	Month      time.Time
+	Meals      int64
+	Subtotal   pkg.Money
+	Subsidy    pkg.Money
+	Total      pkg.Money
+}
+
+type Format string
+
+const (
+	FormatCSV        Format = "csv"
+	FormatFixedWidth Format = "fixed"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatFixedWidth:
+		return FormatFixedWidth, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", s)
+}
+
+// Field is a column of a fixed-width record. Text fields are left aligned
+// and padded with spaces, numbers right aligned and padded with zeros.
+type Field struct {
+	Name  string
+	Width int
+}
+
+// Layout lists the columns of a fixed-width record in order.
+type Layout []Field
+
+var DefaultLayout = Layout{{"employee_id", 16}, {"month", 6}, {"meals", 4}, {"subtotal", 12}, {"subsidy", 12}, {"total", 12}}
+
+// fieldNames are the fields a layout may use.
+var fieldNames = []string{"employee_id", "month", "meals", "subtotal", "subsidy", "total"}
+
+// ParseLayout reads a layout such as "employee_id:16,month:6,total:12". An
+// empty spec gives DefaultLayout.
+func ParseLayout(spec string) (Layout, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultLayout, nil
+	}
+
+	var layout Layout
+	for _, part := range strings.Split(spec, ",") {
+		name, width, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("layout field %q must be name:width", part)
+		}
+		if !known(name) {
+			return nil, fmt.Errorf("unknown layout field %q, want one of %v", name, fieldNames)
+		}
+		n, err := strconv.Atoi(width)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("layout field %q has invalid width %q", name, width)
+		}
+		layout = append(layout, Field{Name: name, Width: n})
+	}
+	return layout, nil
+}
+
+func known(name string) bool {
+	for _, n := range fieldNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrFieldOverflow means a value does not fit the width of its field.
+type ErrFieldOverflow struct {
+	Field string
+	Value string
+	Width int
+}
+
+func (e ErrFieldOverflow) Error() string {
+	return fmt.Sprintf("%s %q does not fit in %d characters", e.Field, e.Value, e.Width)
+}
+
+// WriteCSV writes a header, one row per deduction and the trailer.
+func WriteCSV(w io.Writer, deductions []Deduction) error {
+	var body bytes.Buffer
+	cw := csv.NewWriter(&body)
+	cw.Write([]string{"employee_id", "month", "meals", "subtotal", "subsidy", "total"})
+	for _, d := range deductions {
+		cw.Write([]string{d.EmployeeID, d.Month.Format("2006-01"), strconv.FormatInt(d.Meals, 10), formatMoney(d.Subtotal), formatMoney(d.Subsidy), formatMoney(d.Total)})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+
+	count, total, sum := trailer(body.Bytes(), deductions)
+	cw = csv.NewWriter(&body)
+	cw.Write([]string{"TRAILER", strconv.Itoa(count), formatMoney(total), sum})
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// WriteFixedWidth writes one record per deduction laid out by layout, then
+// a trailer record of "T", the count in 8 digits, the total in 15 and the
+// checksum.
+func WriteFixedWidth(w io.Writer, layout Layout, deductions []Deduction) error {
+	var body bytes.Buffer
+	for _, d := range deductions {
+		for _, f := range layout {
+			value, err := fixedValue(f, d)
+			if err != nil {
+				return err
+			}
+			body.WriteString(value)
+		}
+		body.WriteString("\n")
+	}
+
+	count, total, sum := trailer(body.Bytes(), deductions)
+	fmt.Fprintf(&body, "T%08d%015d%s\n", count, total, sum)
+	_, err := body.WriteTo(w)
+	return err
+}
+
+func fixedValue(f Field, d Deduction) (string, error) {
+	var value string
+	numeric := true
+	switch f.Name {
+	case "employee_id":
+		value, numeric = d.EmployeeID, false
+	case "month":
+		value = d.Month.Format("200601")
+	case "meals":
+		value = strconv.FormatInt(d.Meals, 10)
+	case "subtotal":
+		value = formatMoney(d.Subtotal)
+	case "subsidy":
+		value = formatMoney(d.Subsidy)
+	case "total":
+		value = formatMoney(d.Total)
+	}
+
+	if len(value) > f.Width {
+		return "", ErrFieldOverflow{Field: f.Name, Value: value, Width: f.Width}
+	}
+	if numeric {
+		sign := ""
+		if strings.HasPrefix(value, "-") {
+			sign, value = "-", value[1:]
+		}
+		return sign + strings.Repeat("0", f.Width-len(sign)-len(value)) + value, nil
+	}
+	return value + strings.Repeat(" ", f.Width-len(value)), nil
+}
+
+// trailer summarises body for the last record of both formats: the record
+// count, the sum of the totals and the hex SHA-256 of every byte before it.
+func trailer(body []byte, deductions []Deduction) (int, pkg.Money, string) {
+	var total pkg.Money
+	for _, d := range deductions {
+		total += d.Total
+	}
+	sum := sha256.Sum256(body)
+	return len(deductions), total, hex.EncodeToString(sum[:])
+}
+
+func formatMoney(m pkg.Money) string { return strconv.FormatInt(int64(m), 10) }
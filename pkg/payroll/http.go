@@ -0,0 +1,133 @@
+package payroll
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleExport http.Handler
+	handleExport = s.handleExport()
+	handleExport = httpLoggingMiddleware(logger, "handleExport")(handleExport)
+
+	router := way.NewRouter()
+
+	router.Handle("GET", "/payroll/v1/export/:month", handleExport)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	monthLayout      = "2006-01"
+)
+
+var (
+	ErrInvalidMonth     = errors.New("month in path must be YYYY-MM")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type server struct {
+	service Service
+}
+
+// handleExport serves the export of a month as a download, in the format
+// named by ?format=csv|fixed.
+func (s *server) handleExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, err := time.Parse(monthLayout, way.Param(r.Context(), "month"))
+		if err != nil {
+			writeError(w, ErrInvalidMonth)
+			return
+		}
+		format, err := ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			writeError(w, ErrInvalidQuery)
+			return
+		}
+
+		// rendered in full first, so that a failure still gets a JSON error.
+		var body bytes.Buffer
+		if err := s.service.Export(r.Context(), &body, month, format); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		contentType, ext := "text/csv; charset=utf-8", "csv"
+		if format == FormatFixedWidth {
+			contentType, ext = "text/plain; charset=utf-8", "txt"
+		}
+		w.Header().Set(contentTypeKey, contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "payroll-"+month.Format(monthLayout)+"."+ext))
+		w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+		w.WriteHeader(http.StatusOK)
+		body.WriteTo(w)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, ErrNoStatements:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrMonthNotFinalized:
+		w.WriteHeader(http.StatusConflict)
+	case ErrInvalidMonth, ErrInvalidQuery:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrFieldOverflow:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["field"] = e.Field
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package payroll
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Export(ctx context.Context, w io.Writer, month time.Time, format Format) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "export",
+			"month", month,
+			"format", format,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Export(ctx, w, month, format)
+}
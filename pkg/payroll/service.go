@@ -0,0 +1,88 @@
+// Package payroll exports the finalized statements of a month as salary
+// deductions for the payroll system.
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Export writes the deductions of the month of month in format. It only
+	// reads finalized statements, so the same month always exports to the
+	// same bytes.
+	Export(ctx context.Context, w io.Writer, month time.Time, format Format) error
+}
+
+var (
+	ErrNoStatements      = errors.New("month has no statements")
+	ErrMonthNotFinalized = errors.New("month has statements that are not finalized")
+)
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	statements pkg.StatementRepository
+	layout     Layout
+}
+
+func NewService(statements pkg.StatementRepository, layout Layout) Service {
+	return &service{statements: statements, layout: layout}
+}
+
+func (s *service) Export(ctx context.Context, w io.Writer, month time.Time, format Format) error {
+	deductions, err := s.deductions(ctx, month)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatFixedWidth:
+		return WriteFixedWidth(w, s.layout, deductions)
+	default:
+		return WriteCSV(w, deductions)
+	}
+}
+
+// deductions totals the statements of a month per employee ID, ordered by
+// it.
+func (s *service) deductions(ctx context.Context, month time.Time) ([]Deduction, error) {
+	first := pkg.Date(month).AddDate(0, 0, 1-month.Day())
+	statements, err := s.statements.FindByMonth(ctx, first)
+	if err != nil {
+		return nil, fmt.Errorf("could not list statements: %v", err)
+	}
+	if len(statements) == 0 {
+		return nil, ErrNoStatements
+	}
+
+	byEmployee := make(map[string]*Deduction)
+	for _, statement := range statements {
+		if statement.Status != pkg.StatementFinalized {
+			return nil, ErrMonthNotFinalized
+		}
+		d, ok := byEmployee[statement.EmployeeID]
+		if !ok {
+			d = &Deduction{EmployeeID: statement.EmployeeID, Month: first}
+			byEmployee[statement.EmployeeID] = d
+		}
+		d.Meals += int64(len(statement.Lines))
+		d.Subtotal += statement.Subtotal
+		d.Subsidy += statement.Subsidy
+		d.Total += statement.Total
+	}
+
+	list := make([]Deduction, 0, len(byEmployee))
+	for _, d := range byEmployee {
+		list = append(list, *d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].EmployeeID < list[j].EmployeeID })
+	return list, nil
+}
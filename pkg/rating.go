@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrRatingNotFound      = errors.New("rating not found")
+	ErrRatingAlreadyExists = errors.New("rating already exists")
+	ErrRatingClosed        = errors.New("rating can no longer be changed")
+	ErrNotCheckedIn        = errors.New("reservation is not checked in")
+	ErrDishNotServed       = errors.New("dish was not on the menu of the meal")
+	ErrNotReservationOwner = errors.New("reservation belongs to another user")
+)
+
+// RatingEditWindow is how long after it was first given a rating can still
+// be changed.
+const RatingEditWindow = 48 * time.Hour
+
+// Rating is the stars a user gave a dish of a meal they attended. UserID is
+// that of the reservation and is only shown to admins.
+type Rating struct {
+	ID            int64
+	ReservationID int64
+	UserID        int64
+	DishID        int64
+	Stars         int64
+	Comment       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Editable reports whether the rating can still be changed at now.
+func (r Rating) Editable(now time.Time) bool {
+	return now.Sub(r.CreatedAt) <= RatingEditWindow
+}
+
+// RatingPeriod is the length of the buckets dish averages are computed over.
+type RatingPeriod string
+
+const (
+	RatingDaily   RatingPeriod = "day"
+	RatingWeekly  RatingPeriod = "week"
+	RatingMonthly RatingPeriod = "month"
+)
+
+var RatingPeriods = []RatingPeriod{RatingDaily, RatingWeekly, RatingMonthly}
+
+func ParseRatingPeriod(s string) (RatingPeriod, error) {
+	if s == "" {
+		return RatingWeekly, nil
+	}
+	for _, p := range RatingPeriods {
+		if RatingPeriod(s) == p {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rating period %q", s)
+}
+
+// DishRating is the average rating of a dish over the period starting at
+// PeriodStart. Weeks start on Monday.
+type DishRating struct {
+	DishID      int64
+	DishName    string
+	PeriodStart time.Time
+	Ratings     int64
+	Average     float64
+}
+
+// MealRating is the average rating over every dish of one meal.
+type MealRating struct {
+	ServiceDate time.Time
+	MealTypeID  int64
+	Ratings     int64
+	Average     float64
+}
+
+type RatingRepository interface {
+	Insert(context.Context, *Rating) error
+	FindByReservation(ctx context.Context, reservationID int64) ([]Rating, error)
+	FindByDish(ctx context.Context, dishID int64) ([]Rating, error)
+	Update(context.Context, *Rating) error
+	DishAverages(ctx context.Context, from, to time.Time, period RatingPeriod) ([]DishRating, error)
+	LowestRatedMeals(ctx context.Context, from, to time.Time, limit int64) ([]MealRating, error)
+}
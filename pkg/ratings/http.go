@@ -0,0 +1,342 @@
+package ratings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+// NewServer serves ratings. Only the users in admins see who gave which
+// rating; everybody else gets them anonymously.
+func NewServer(service Service, admins []int64, logger log.Logger) http.Handler {
+	s := server{service: service, admins: make(map[int64]bool, len(admins))}
+	for _, id := range admins {
+		s.admins[id] = true
+	}
+
+	var handleRate http.Handler
+	handleRate = s.handleRate()
+	handleRate = httpLoggingMiddleware(logger, "handleRate")(handleRate)
+
+	var handleDishRatings http.Handler
+	handleDishRatings = s.handleDishRatings()
+	handleDishRatings = httpLoggingMiddleware(logger, "handleDishRatings")(handleDishRatings)
+
+	var handleDishAverages http.Handler
+	handleDishAverages = s.handleDishAverages()
+	handleDishAverages = httpLoggingMiddleware(logger, "handleDishAverages")(handleDishAverages)
+
+	var handleLowestRatedMeals http.Handler
+	handleLowestRatedMeals = s.handleLowestRatedMeals()
+	handleLowestRatedMeals = httpLoggingMiddleware(logger, "handleLowestRatedMeals")(handleLowestRatedMeals)
+
+	router := way.NewRouter()
+
+	router.Handle("PUT", "/ratings/v1/reservation/:id/dish/:dish_id", handleRate)
+	router.Handle("GET", "/ratings/v1/dish/:id/ratings", handleDishRatings)
+	router.Handle("GET", "/ratings/v1/dishes/averages", handleDishAverages)
+	router.Handle("GET", "/ratings/v1/meals/lowest", handleLowestRatedMeals)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	// userIDKey carries the ID of the calling user. The service does no
+	// authentication of its own and trusts the gateway in front of it to
+	// set this header.
+	userIDKey         = "X-User-ID"
+	dateLayout        = "2006-01-02"
+	defaultMealsLimit = 5
+	maxMealsLimit     = 50
+)
+
+var (
+	ErrNonNumericID     = errors.New("id in path must be numeric")
+	ErrMissingUserID    = fmt.Errorf("%s header is required", userIDKey)
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+	admins  map[int64]bool
+}
+
+// ratingResponse leaves out who gave the rating unless the caller is an
+// admin.
+type ratingResponse struct {
+	ID            int64     `json:"id"`
+	ReservationID *int64    `json:"reservation_id,omitempty"`
+	UserID        *int64    `json:"user_id,omitempty"`
+	DishID        int64     `json:"dish_id"`
+	Stars         int64     `json:"stars"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	EditableUntil time.Time `json:"editable_until"`
+}
+
+func newRatingResponse(rating pkg.Rating, identify bool) ratingResponse {
+	resp := ratingResponse{ID: rating.ID, DishID: rating.DishID, Stars: rating.Stars, Comment: rating.Comment, CreatedAt: rating.CreatedAt, UpdatedAt: rating.UpdatedAt, EditableUntil: rating.CreatedAt.Add(pkg.RatingEditWindow)}
+	if identify {
+		resp.ReservationID, resp.UserID = &rating.ReservationID, &rating.UserID
+	}
+	return resp
+}
+
+func (s *server) handleRate() http.HandlerFunc {
+	type request struct {
+		Stars   int64  `json:"stars"`
+		Comment string `json:"comment"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		reservationID, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+		dishID, err := strconv.ParseInt(way.Param(r.Context(), "dish_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+		userID, ok := callerID(r)
+		if !ok {
+			writeError(w, ErrMissingUserID)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		rating, isCreated, err := s.service.Rate(r.Context(), userID, pkg.Rating{ReservationID: reservationID, DishID: dishID, Stars: req.Stars, Comment: req.Comment})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		status := http.StatusOK
+		if isCreated {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, newRatingResponse(*rating, true))
+	}
+}
+
+func (s *server) handleDishRatings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dishID, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		list, err := s.service.DishRatings(r.Context(), dishID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		userID, _ := callerID(r)
+		identify := s.admins[userID]
+		resp := make([]ratingResponse, 0, len(list))
+		for _, rating := range list {
+			resp = append(resp, newRatingResponse(rating, identify))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleDishAverages() http.HandlerFunc {
+	type dishAverage struct {
+		DishID      int64   `json:"dish_id"`
+		Dish        string  `json:"dish"`
+		PeriodStart string  `json:"period_start"`
+		Ratings     int64   `json:"ratings"`
+		Average     float64 `json:"average"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		to := pkg.Date(time.Now())
+		if v := query.Get("to"); v != "" {
+			var err error
+			if to, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		from := to.AddDate(0, -3, 0)
+		if v := query.Get("from"); v != "" {
+			var err error
+			if from, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		period, err := pkg.ParseRatingPeriod(query.Get("period"))
+		if err != nil {
+			writeError(w, ErrInvalidQuery)
+			return
+		}
+
+		list, err := s.service.DishAverages(r.Context(), from, to, period)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]dishAverage, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, dishAverage{DishID: v.DishID, Dish: v.DishName, PeriodStart: v.PeriodStart.Format(dateLayout), Ratings: v.Ratings, Average: v.Average})
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleLowestRatedMeals() http.HandlerFunc {
+	type mealRating struct {
+		ServiceDate string  `json:"service_date"`
+		MealTypeID  int64   `json:"meal_type_id"`
+		Ratings     int64   `json:"ratings"`
+		Average     float64 `json:"average"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		week := pkg.Date(time.Now())
+		if v := query.Get("week"); v != "" {
+			var err error
+			if week, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		limit := int64(defaultMealsLimit)
+		if v := query.Get("limit"); v != "" {
+			var err error
+			if limit, err = strconv.ParseInt(v, 10, 64); err != nil || limit < 1 || limit > maxMealsLimit {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		list, err := s.service.LowestRatedMeals(r.Context(), week, limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]mealRating, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, mealRating{ServiceDate: v.ServiceDate.Format(dateLayout), MealTypeID: v.MealTypeID, Ratings: v.Ratings, Average: v.Average})
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// callerID returns the user named by the X-User-ID header, if any.
+func callerID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.Header.Get(userIDKey), 10, 64)
+	return id, err == nil && id > 0
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrReservationNotFound, pkg.ErrDishNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrMissingUserID:
+		w.WriteHeader(http.StatusUnauthorized)
+	case pkg.ErrNotReservationOwner:
+		w.WriteHeader(http.StatusForbidden)
+	case pkg.ErrNotCheckedIn, pkg.ErrRatingClosed, pkg.ErrRatingAlreadyExists:
+		w.WriteHeader(http.StatusConflict)
+	case ErrNonNumericID, ErrInvalidQuery, ErrInvalidRange, pkg.ErrDishNotServed:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ratings
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Rate(ctx context.Context, userID int64, rating pkg.Rating) (_ *pkg.Rating, isCreated bool, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "rate",
+			"user_id", userID,
+			"reservation_id", rating.ReservationID,
+			"dish_id", rating.DishID,
+			"created", isCreated,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Rate(ctx, userID, rating)
+}
+
+func (s *loggingMiddleware) DishRatings(ctx context.Context, dishID int64) (list []pkg.Rating, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "dish_ratings",
+			"dish_id", dishID,
+			"ratings", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.DishRatings(ctx, dishID)
+}
+
+func (s *loggingMiddleware) DishAverages(ctx context.Context, from, to time.Time, period pkg.RatingPeriod) (list []pkg.DishRating, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "dish_averages",
+			"from", from,
+			"to", to,
+			"period", period,
+			"rows", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.DishAverages(ctx, from, to, period)
+}
+
+func (s *loggingMiddleware) LowestRatedMeals(ctx context.Context, date time.Time, limit int64) (list []pkg.MealRating, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "lowest_rated_meals",
+			"date", date,
+			"limit", limit,
+			"meals", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.LowestRatedMeals(ctx, date, limit)
+}
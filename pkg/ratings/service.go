@@ -0,0 +1,131 @@
+package ratings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Rate records the rating userID gives a dish of the meal of one of
+	// their reservations, or changes it within pkg.RatingEditWindow. It
+	// reports whether the rating was created.
+	Rate(ctx context.Context, userID int64, rating pkg.Rating) (*pkg.Rating, bool, error)
+	DishRatings(ctx context.Context, dishID int64) ([]pkg.Rating, error)
+	// DishAverages returns the average rating of every rated dish for each
+	// period between from and to, both inclusive.
+	DishAverages(ctx context.Context, from, to time.Time, period pkg.RatingPeriod) ([]pkg.DishRating, error)
+	// LowestRatedMeals returns up to limit meals of the Monday to Sunday
+	// week of date, worst first.
+	LowestRatedMeals(ctx context.Context, date time.Time, limit int64) ([]pkg.MealRating, error)
+}
+
+var ErrInvalidRange = errors.New("range ends before it starts")
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	ratings      pkg.RatingRepository
+	reservations pkg.ReservationRepository
+	menus        pkg.MenuRepository
+}
+
+func NewService(ratings pkg.RatingRepository, reservations pkg.ReservationRepository, menus pkg.MenuRepository) Service {
+	return &service{ratings: ratings, reservations: reservations, menus: menus}
+}
+
+func (s *service) Rate(ctx context.Context, userID int64, rating pkg.Rating) (*pkg.Rating, bool, error) {
+	reservation, err := s.reservations.FindByID(ctx, rating.ReservationID)
+	if err == pkg.ErrReservationNotFound {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not find reservation: %v", err)
+	}
+	if reservation.UserID != userID {
+		return nil, false, pkg.ErrNotReservationOwner
+	}
+	if reservation.CheckedInAt == nil {
+		return nil, false, pkg.ErrNotCheckedIn
+	}
+	if err := s.checkServed(ctx, *reservation, rating.DishID); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.ratings.FindByReservation(ctx, rating.ReservationID)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not find ratings: %v", err)
+	}
+	for _, e := range existing {
+		if e.DishID != rating.DishID {
+			continue
+		}
+		if !e.Editable(time.Now()) {
+			return nil, false, pkg.ErrRatingClosed
+		}
+		e.Stars, e.Comment = rating.Stars, rating.Comment
+		if err := s.ratings.Update(ctx, &e); err != nil {
+			return nil, false, fmt.Errorf("could not update rating: %v", err)
+		}
+		return &e, false, nil
+	}
+
+	rating.UserID = reservation.UserID
+	if err := s.ratings.Insert(ctx, &rating); err != nil {
+		if err == pkg.ErrRatingAlreadyExists || err == pkg.ErrDishNotFound {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("could not save rating: %v", err)
+	}
+	return &rating, true, nil
+}
+
+// checkServed makes sure the dish was on the menu of the reservation's meal.
+func (s *service) checkServed(ctx context.Context, reservation pkg.Reservation, dishID int64) error {
+	menu, err := s.menus.FindForMeal(ctx, reservation.ServiceDate, reservation.MealTypeID)
+	if err == pkg.ErrMenuNotFound {
+		return pkg.ErrDishNotServed
+	}
+	if err != nil {
+		return fmt.Errorf("could not find menu: %v", err)
+	}
+	for _, dish := range menu.Dishes {
+		if dish.ID == dishID {
+			return nil
+		}
+	}
+	return pkg.ErrDishNotServed
+}
+
+func (s *service) DishRatings(ctx context.Context, dishID int64) ([]pkg.Rating, error) {
+	list, err := s.ratings.FindByDish(ctx, dishID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list ratings: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) DishAverages(ctx context.Context, from, to time.Time, period pkg.RatingPeriod) ([]pkg.DishRating, error) {
+	if to.Before(from) {
+		return nil, ErrInvalidRange
+	}
+	list, err := s.ratings.DishAverages(ctx, from, to, period)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute dish averages: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) LowestRatedMeals(ctx context.Context, date time.Time, limit int64) ([]pkg.MealRating, error) {
+	day := pkg.Date(date)
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	list, err := s.ratings.LowestRatedMeals(ctx, monday, monday.AddDate(0, 0, 6), limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not list lowest rated meals: %v", err)
+	}
+	return list, nil
+}
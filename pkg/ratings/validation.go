@@ -0,0 +1,35 @@
+package ratings
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+const maxCommentLength = 1000
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) Rate(ctx context.Context, userID int64, rating pkg.Rating) (*pkg.Rating, bool, error) {
+	var verr pkg.ValidationError
+
+	if rating.Stars < 1 || rating.Stars > 5 {
+		verr.Add("stars", "out_of_range", "stars must be between 1 and 5")
+	}
+	if utf8.RuneCountInString(rating.Comment) > maxCommentLength {
+		verr.Add("comment", "too_long", fmt.Sprintf("comment must be at most %d characters", maxCommentLength))
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, false, err
+	}
+	return s.Service.Rate(ctx, userID, rating)
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationPreferences are what a user wants to be told about and where.
+// Notifications go to Email when it is set and to the fallback channel
+// otherwise.
+type NotificationPreferences struct {
+	UserID        int64
+	Email         string
+	MealReminders bool
+	BookingNudges bool
+	UpdatedAt     time.Time
+}
+
+// DefaultNotificationPreferences apply to users who never saved any.
+func DefaultNotificationPreferences(userID int64) NotificationPreferences {
+	return NotificationPreferences{UserID: userID, MealReminders: true, BookingNudges: true}
+}
+
+type NotificationPreferenceRepository interface {
+	// Save inserts or replaces the preferences of a user.
+	Save(context.Context, *NotificationPreferences) error
+	// FindByUser returns the defaults for users who never saved any.
+	FindByUser(ctx context.Context, userID int64) (*NotificationPreferences, error)
+	// FindAll only returns the preferences that were saved.
+	FindAll(context.Context) ([]NotificationPreferences, error)
+}
+
+type ReminderKind string
+
+const (
+	// ReminderMeal reminds a user of a meal they booked.
+	ReminderMeal ReminderKind = "meal_reminder"
+	// ReminderBookingNudge tells a user who usually eats a meal that they
+	// have not booked it yet.
+	ReminderBookingNudge ReminderKind = "booking_nudge"
+)
+
+// SentReminder identifies a reminder of one kind sent to a user about one
+// meal, which is never sent twice.
+type SentReminder struct {
+	Kind        ReminderKind
+	UserID      int64
+	MealTypeID  int64
+	ServiceDate time.Time
+}
+
+type SentReminderRepository interface {
+	// Claim records reminder as sent and reports false if it already was.
+	Claim(context.Context, SentReminder) (bool, error)
+	// Release forgets a claimed reminder that could not be sent after all.
+	Release(context.Context, SentReminder) error
+}
@@ -0,0 +1,220 @@
+package reminders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handlePreferences http.Handler
+	handlePreferences = s.handlePreferences()
+	handlePreferences = httpLoggingMiddleware(logger, "handlePreferences")(handlePreferences)
+
+	var handleSavePreferences http.Handler
+	handleSavePreferences = s.handleSavePreferences()
+	handleSavePreferences = httpLoggingMiddleware(logger, "handleSavePreferences")(handleSavePreferences)
+
+	var handleSend http.Handler
+	handleSend = s.handleSend()
+	handleSend = httpLoggingMiddleware(logger, "handleSend")(handleSend)
+
+	router := way.NewRouter()
+
+	router.Handle("GET", "/reminders/v1/preferences/:user_id", handlePreferences)
+	router.Handle("PUT", "/reminders/v1/preferences/:user_id", handleSavePreferences)
+	router.Handle("POST", "/reminders/v1/send", handleSend)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrNonNumericID     = errors.New("id in path must be numeric")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type preferencesResponse struct {
+	UserID        int64      `json:"user_id"`
+	Email         string     `json:"email"`
+	MealReminders bool       `json:"meal_reminders"`
+	BookingNudges bool       `json:"booking_nudges"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+// newPreferencesResponse has no updated_at for preferences that were never
+// saved.
+func newPreferencesResponse(preferences pkg.NotificationPreferences) preferencesResponse {
+	resp := preferencesResponse{UserID: preferences.UserID, Email: preferences.Email, MealReminders: preferences.MealReminders, BookingNudges: preferences.BookingNudges}
+	if !preferences.UpdatedAt.IsZero() {
+		updatedAt := preferences.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
+
+func (s *server) handlePreferences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(way.Param(r.Context(), "user_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		preferences, err := s.service.Preferences(r.Context(), userID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPreferencesResponse(*preferences))
+	}
+}
+
+func (s *server) handleSavePreferences() http.HandlerFunc {
+	type request struct {
+		Email         string `json:"email"`
+		MealReminders *bool  `json:"meal_reminders"`
+		BookingNudges *bool  `json:"booking_nudges"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(way.Param(r.Context(), "user_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		// a missing opt-in keeps its default, which is on.
+		preferences := pkg.DefaultNotificationPreferences(userID)
+		preferences.Email = req.Email
+		if req.MealReminders != nil {
+			preferences.MealReminders = *req.MealReminders
+		}
+		if req.BookingNudges != nil {
+			preferences.BookingNudges = *req.BookingNudges
+		}
+		saved, err := s.service.SavePreferences(r.Context(), preferences)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPreferencesResponse(*saved))
+	}
+}
+
+func (s *server) handleSend() http.HandlerFunc {
+	type response struct {
+		Sent int `json:"sent"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sent, err := s.service.Send(r.Context(), time.Now())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response{sent})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrUserNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrNonNumericID:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
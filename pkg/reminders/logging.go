@@ -0,0 +1,57 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Preferences(ctx context.Context, userID int64) (_ *pkg.NotificationPreferences, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "preferences",
+			"user_id", userID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Preferences(ctx, userID)
+}
+
+func (s *loggingMiddleware) SavePreferences(ctx context.Context, preferences pkg.NotificationPreferences) (_ *pkg.NotificationPreferences, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save_preferences",
+			"user_id", preferences.UserID,
+			"meal_reminders", preferences.MealReminders,
+			"booking_nudges", preferences.BookingNudges,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SavePreferences(ctx, preferences)
+}
+
+func (s *loggingMiddleware) Send(ctx context.Context, now time.Time) (sent int, err error) {
+	defer func(begin time.Time) {
+		if sent == 0 && err == nil {
+			// an idle scheduler would otherwise log every interval.
+			return
+		}
+		s.logger.Log(
+			"method", "send",
+			"sent", sent,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Send(ctx, now)
+}
@@ -0,0 +1,256 @@
+// Package reminders tells users about the meals they booked and nudges
+// those who usually eat a meal but did not book it.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Preferences returns the notification preferences of a user, the
+	// defaults if they never saved any.
+	Preferences(ctx context.Context, userID int64) (*pkg.NotificationPreferences, error)
+	SavePreferences(context.Context, pkg.NotificationPreferences) (*pkg.NotificationPreferences, error)
+	// Send sends every reminder and nudge due at now that was not sent
+	// before and returns how many it sent.
+	Send(ctx context.Context, now time.Time) (int, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+// Schedule says when reminders go out. Booking a meal closes when it starts
+// being served, so both leads count back from the start of the serving
+// window. A user usually eats a meal when they booked it on the same weekday
+// in at least RegularMeals of the RegularWeeks before.
+type Schedule struct {
+	ReminderLead time.Duration
+	NudgeLead    time.Duration
+	RegularWeeks int64
+	RegularMeals int64
+}
+
+type service struct {
+	schedule     Schedule
+	preferences  pkg.NotificationPreferenceRepository
+	sent         pkg.SentReminderRepository
+	reservations pkg.ReservationRepository
+	mealTypes    pkg.MealTypeRepository
+	closures     pkg.ClosureRepository
+	users        pkg.UserRepository
+	sites        pkg.SiteRepository
+	notifier     pkg.Notifier
+}
+
+func NewService(schedule Schedule, preferences pkg.NotificationPreferenceRepository, sent pkg.SentReminderRepository, reservations pkg.ReservationRepository, mealTypes pkg.MealTypeRepository, closures pkg.ClosureRepository, users pkg.UserRepository, sites pkg.SiteRepository, notifier pkg.Notifier) Service {
+	return &service{schedule: schedule, preferences: preferences, sent: sent, reservations: reservations, mealTypes: mealTypes, closures: closures, users: users, sites: sites, notifier: notifier}
+}
+
+func (s *service) Preferences(ctx context.Context, userID int64) (*pkg.NotificationPreferences, error) {
+	if _, err := s.users.FindByID(ctx, userID); err != nil {
+		if err == pkg.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	preferences, err := s.preferences.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+func (s *service) SavePreferences(ctx context.Context, preferences pkg.NotificationPreferences) (*pkg.NotificationPreferences, error) {
+	if err := s.preferences.Save(ctx, &preferences); err != nil {
+		if err == pkg.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save notification preferences: %v", err)
+	}
+	return &preferences, nil
+}
+
+// Send looks at the meals of today and tomorrow at every site, in the time
+// zone of the site, as an early meal can be due for a nudge the evening
+// before. A reminder that fails to be sent is tried again on the next call,
+// until its meal starts.
+func (s *service) Send(ctx context.Context, now time.Time) (int, error) {
+	sites, err := s.sites.FindAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list sites: %v", err)
+	}
+	mealTypes, err := s.mealTypes.FindAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list meal types: %v", err)
+	}
+	saved, err := s.preferences.FindAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list notification preferences: %v", err)
+	}
+	preferences := make(map[int64]pkg.NotificationPreferences, len(saved))
+	for _, p := range saved {
+		preferences[p.UserID] = p
+	}
+	preferencesOf := func(userID int64) pkg.NotificationPreferences {
+		if p, ok := preferences[userID]; ok {
+			return p
+		}
+		return pkg.DefaultNotificationPreferences(userID)
+	}
+
+	sent := 0
+	var failed error
+	for _, site := range sites {
+		loc, err := site.Location()
+		if err != nil {
+			return sent, fmt.Errorf("could not load time zone of site %d: %v", site.ID, err)
+		}
+		today := pkg.LocalDate(now.In(loc), loc)
+		for _, day := range []time.Time{today, today.AddDate(0, 0, 1)} {
+			for _, mealType := range mealTypes {
+				if !mealType.ServedAt(site.ID) || !mealType.ServedOn(day.Weekday()) {
+					continue
+				}
+				start := mealType.ServingStart.On(day)
+				if !now.Before(start) {
+					continue
+				}
+
+				booked, err := s.reservations.FindActiveForMeal(ctx, day, mealType.ID)
+				if err != nil {
+					return sent, fmt.Errorf("could not list reservations: %v", err)
+				}
+
+				if !now.Before(start.Add(-s.schedule.ReminderLead)) {
+					for _, reservation := range booked {
+						if reservation.SiteID != site.ID || !preferencesOf(reservation.UserID).MealReminders {
+							continue
+						}
+						ok, err := s.send(ctx, pkg.SentReminder{Kind: pkg.ReminderMeal, UserID: reservation.UserID, MealTypeID: mealType.ID, ServiceDate: day}, reminder(mealType, day))
+						if err != nil && failed == nil {
+							failed = err
+						}
+						if ok {
+							sent++
+						}
+					}
+				}
+
+				if !now.Before(start.Add(-s.schedule.NudgeLead)) {
+					n, err := s.nudge(ctx, site, mealType, day, booked, preferencesOf)
+					sent += n
+					if err != nil && failed == nil {
+						failed = err
+					}
+				}
+			}
+		}
+	}
+	return sent, failed
+}
+
+// nudge reminds the regulars of a meal whose own site is site. Having booked
+// it at any site counts.
+func (s *service) nudge(ctx context.Context, site pkg.Site, mealType pkg.MealType, day time.Time, booked []pkg.Reservation, preferencesOf func(int64) pkg.NotificationPreferences) (int, error) {
+	// nobody can book a meal the mess is closed for.
+	_, err := s.closures.FindCovering(ctx, day, mealType.ID)
+	if err == nil {
+		return 0, nil
+	}
+	if err != pkg.ErrClosureNotFound {
+		return 0, fmt.Errorf("could not check closures: %v", err)
+	}
+
+	regulars, err := s.reservations.FindRegularDiners(ctx, day, mealType.ID, s.schedule.RegularWeeks, s.schedule.RegularMeals)
+	if err != nil {
+		return 0, fmt.Errorf("could not list regular diners: %v", err)
+	}
+	hasBooked := make(map[int64]bool, len(booked))
+	for _, reservation := range booked {
+		hasBooked[reservation.UserID] = true
+	}
+
+	sent := 0
+	var failed error
+	for _, userID := range regulars {
+		if hasBooked[userID] || !preferencesOf(userID).BookingNudges {
+			continue
+		}
+		user, err := s.users.FindByID(ctx, userID)
+		if err == pkg.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			return sent, fmt.Errorf("could not find user: %v", err)
+		}
+		if user.SiteID != site.ID {
+			continue
+		}
+		ok, err := s.send(ctx, pkg.SentReminder{Kind: pkg.ReminderBookingNudge, UserID: userID, MealTypeID: mealType.ID, ServiceDate: day}, bookingNudge(mealType, day))
+		if err != nil && failed == nil {
+			failed = err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, failed
+}
+
+// send claims reminder before notifying, so that it goes out at most once
+// even if the scheduler stops right after. It reports whether it was sent
+// now.
+func (s *service) send(ctx context.Context, reminder pkg.SentReminder, notification pkg.Notification) (bool, error) {
+	claimed, err := s.sent.Claim(ctx, reminder)
+	if err == pkg.ErrUserNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not claim reminder: %v", err)
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	notification.UserID = reminder.UserID
+	if err := s.notifier.Notify(ctx, notification); err != nil {
+		s.sent.Release(ctx, reminder)
+		return false, fmt.Errorf("could not send reminder: %v", err)
+	}
+	return true, nil
+}
+
+func reminder(mealType pkg.MealType, day time.Time) pkg.Notification {
+	return pkg.Notification{
+		Subject: fmt.Sprintf("Reminder: %s at %s", mealType.Name, mealType.ServingStart),
+		Body:    fmt.Sprintf("You booked %s for %s. It is served from %s to %s.", mealType.Name, day.Format("Mon Jan 2 2006"), mealType.ServingStart, mealType.ServingEnd),
+	}
+}
+
+func bookingNudge(mealType pkg.MealType, day time.Time) pkg.Notification {
+	return pkg.Notification{
+		Subject: fmt.Sprintf("Booking for %s closes soon", mealType.Name),
+		Body:    fmt.Sprintf("You usually have %s on %ss but have not booked it for %s yet. Booking closes at %s.", mealType.Name, day.Weekday(), day.Format("Mon Jan 2 2006"), mealType.ServingStart),
+	}
+}
+
+// Run sends due reminders every interval until ctx is done. Failures are
+// passed to report rather than stopping the loop.
+func Run(ctx context.Context, service Service, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := service.Send(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			report(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
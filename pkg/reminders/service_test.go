@@ -0,0 +1,101 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// sentReminders stands in for the sent_reminders table, which outlives the
+// services using it.
+type sentReminders map[pkg.SentReminder]bool
+
+func (s sentReminders) Claim(_ context.Context, reminder pkg.SentReminder) (bool, error) {
+	if s[reminder] {
+		return false, nil
+	}
+	s[reminder] = true
+	return true, nil
+}
+
+func (s sentReminders) Release(_ context.Context, reminder pkg.SentReminder) error {
+	delete(s, reminder)
+	return nil
+}
+
+// notifier records what it notifies, or fails with err.
+type notifier struct {
+	sent []pkg.Notification
+	err  error
+}
+
+func (n *notifier) Notify(_ context.Context, notification pkg.Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func newTestService(sent pkg.SentReminderRepository, notifier pkg.Notifier) *service {
+	return NewService(Schedule{}, nil, sent, nil, nil, nil, nil, nil, notifier).(*service)
+}
+
+var lunchReminder = pkg.SentReminder{Kind: pkg.ReminderMeal, UserID: 7, MealTypeID: 2, ServiceDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}
+
+func TestSendOnceAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	table := sentReminders{}
+	notification := pkg.Notification{Subject: "Reminder: Lunch at 12:00"}
+
+	first := &notifier{}
+	ok, err := newTestService(table, first).send(ctx, lunchReminder, notification)
+	if err != nil || !ok {
+		t.Fatalf("send() = %v, %v, want true, nil", ok, err)
+	}
+	if len(first.sent) != 1 || first.sent[0].UserID != lunchReminder.UserID {
+		t.Fatalf("notifier got %v, want one notification to user %d", first.sent, lunchReminder.UserID)
+	}
+
+	// a scheduler started after the first one stopped finds the claim.
+	second := &notifier{}
+	ok, err = newTestService(table, second).send(ctx, lunchReminder, notification)
+	if err != nil || ok {
+		t.Errorf("send() after a restart = %v, %v, want false, nil", ok, err)
+	}
+	if len(second.sent) != 0 {
+		t.Errorf("notifier got %d notifications after a restart, want 0", len(second.sent))
+	}
+
+	// other reminders of the same meal still go out.
+	nudge := lunchReminder
+	nudge.Kind = pkg.ReminderBookingNudge
+	if ok, err := newTestService(table, second).send(ctx, nudge, notification); err != nil || !ok {
+		t.Errorf("send() of a nudge = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestSendReleasesUnsentReminders(t *testing.T) {
+	ctx := context.Background()
+	table := sentReminders{}
+	notification := pkg.Notification{Subject: "Reminder: Lunch at 12:00"}
+
+	ok, err := newTestService(table, &notifier{err: errors.New("connection refused")}).send(ctx, lunchReminder, notification)
+	if err == nil || ok {
+		t.Fatalf("send() = %v, %v, want false and an error", ok, err)
+	}
+	if table[lunchReminder] {
+		t.Fatalf("reminder still claimed after it failed to be sent")
+	}
+
+	retry := &notifier{}
+	if ok, err := newTestService(table, retry).send(ctx, lunchReminder, notification); err != nil || !ok {
+		t.Errorf("send() on retry = %v, %v, want true, nil", ok, err)
+	}
+	if len(retry.sent) != 1 {
+		t.Errorf("notifier got %d notifications on retry, want 1", len(retry.sent))
+	}
+}
@@ -0,0 +1,34 @@
+package reminders
+
+import (
+	"context"
+	"net/mail"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) SavePreferences(ctx context.Context, preferences pkg.NotificationPreferences) (*pkg.NotificationPreferences, error) {
+	var verr pkg.ValidationError
+
+	// an empty email leaves the user without email notifications.
+	if preferences.Email != "" {
+		if len(preferences.Email) > 254 {
+			verr.Add("email", "too_long", "email must be at most 254 characters")
+		} else if address, err := mail.ParseAddress(preferences.Email); err != nil || address.Address != preferences.Email {
+			verr.Add("email", "invalid", "email must be a plain address like name@example.com")
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.SavePreferences(ctx, preferences)
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// DepartmentUsage counts the meals booked by one department in a month.
+// Attended are the reservations that were checked in.
+type DepartmentUsage struct {
+	Month        time.Time
+	Department   string
+	Reservations int64
+	Employees    int64
+	Guests       int64
+	Attended     int64
+}
+
+// GuestUsage is how many guests one employee brought along.
+type GuestUsage struct {
+	UserID       int64
+	EmployeeID   string
+	Name         string
+	Department   string
+	Reservations int64
+	Guests       int64
+}
+
+// NoShowRate compares the meals an employee booked to those they came to.
+type NoShowRate struct {
+	UserID       int64
+	EmployeeID   string
+	Name         string
+	Department   string
+	Reservations int64
+	Attended     int64
+}
+
+func (r NoShowRate) NoShows() int64 { return r.Reservations - r.Attended }
+
+// Rate is the share of reservations that were not attended, from 0 to 1.
+func (r NoShowRate) Rate() float64 {
+	if r.Reservations == 0 {
+		return 0
+	}
+	return float64(r.NoShows()) / float64(r.Reservations)
+}
+
+// MealTypeTrend counts the bookings of one meal type over the week or
+// month starting at PeriodStart.
+type MealTypeTrend struct {
+	PeriodStart  time.Time
+	MealTypeID   int64
+	MealType     string
+	Reservations int64
+	Guests       int64
+	Attended     int64
+}
+
+// ReportRepository reads reports over the active reservations at sites, nil
+// for every site, served from from to to, both inclusive. Rows are passed to
+// fn as they are read rather than collected, stopping at the first error fn
+// returns.
+type ReportRepository interface {
+	DepartmentUsage(ctx context.Context, from, to time.Time, sites []int64, fn func(DepartmentUsage) error) error
+	GuestUsage(ctx context.Context, from, to time.Time, sites []int64, fn func(GuestUsage) error) error
+	NoShowRates(ctx context.Context, from, to time.Time, sites []int64, fn func(NoShowRate) error) error
+	// MealTypeTrend buckets by week, starting on Monday, when weekly is set
+	// and by month otherwise.
+	MealTypeTrend(ctx context.Context, from, to time.Time, weekly bool, sites []int64, fn func(MealTypeTrend) error) error
+}
@@ -0,0 +1,222 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleList http.Handler
+	handleList = s.handleList()
+	handleList = httpLoggingMiddleware(logger, "handleList")(handleList)
+
+	var handleExport http.Handler
+	handleExport = s.handleExport()
+	handleExport = httpLoggingMiddleware(logger, "handleExport")(handleExport)
+
+	router := way.NewRouter()
+
+	router.Handle("GET", "/reports/v1/reports", handleList)
+	router.Handle("GET", "/reports/v1/report/:name", handleExport)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	dateLayout       = "2006-01-02"
+	// userIDKey carries the ID of the calling user, which limits managers to
+	// the reports of their own sites.
+	userIDKey = "X-User-ID"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type server struct {
+	service Service
+}
+
+func (s *server) handleList() http.HandlerFunc {
+	type report struct {
+		Name    string   `json:"name"`
+		Title   string   `json:"title"`
+		Columns []string `json:"columns"`
+		Formats []Format `json:"formats"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list := s.service.Reports(r.Context())
+
+		resp := make([]report, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, report{Name: v.Name, Title: v.Title, Columns: v.Columns, Formats: Formats})
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleExport downloads a report for ?from= to ?to=, last month by default.
+// The format is ?format=csv|xlsx or, without it, negotiated from Accept.
+// ?interval=week|month buckets the meal type trend and ?site_id= narrows it
+// to one site.
+func (s *server) handleExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		now := time.Now()
+		params := Params{From: time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)}
+		params.To = params.From.AddDate(0, 1, -1)
+		var err error
+		if v := query.Get("from"); v != "" {
+			if params.From, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		if v := query.Get("to"); v != "" {
+			if params.To, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		if v := query.Get("site_id"); v != "" {
+			if params.Site.SiteID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		if id, ok := callerID(r); ok {
+			params.Site.ViewerID = id
+		}
+		if params.Interval, err = ParseInterval(query.Get("interval")); err != nil {
+			writeError(w, ErrInvalidQuery)
+			return
+		}
+		format := FormatFromAccept(r.Header.Get("Accept"))
+		if v := query.Get("format"); v != "" {
+			if format, err = ParseFormat(v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		name := way.Param(r.Context(), "name")
+		out := &streamWriter{ResponseWriter: w, header: func(h http.Header) {
+			h.Set(contentTypeKey, format.ContentType())
+			h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s_%s_%s.%s", name, params.From.Format(dateLayout), params.To.Format(dateLayout), format)))
+		}}
+		if err := s.service.Export(r.Context(), out, name, params, format); err != nil && !out.started {
+			writeError(w, err)
+		}
+		// once rows went out the status can no longer change, so the client
+		// is left with a truncated download and the error with the logs.
+	}
+}
+
+// callerID returns the user named by the X-User-ID header, if any.
+func callerID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.Header.Get(userIDKey), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// streamWriter only commits to a successful response once the first bytes
+// of the report are written, so errors until then can still be reported.
+type streamWriter struct {
+	http.ResponseWriter
+	header  func(http.Header)
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.header(s.ResponseWriter.Header())
+		s.ResponseWriter.WriteHeader(http.StatusOK)
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, ErrReportNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrInvalidQuery:
+		w.WriteHeader(http.StatusBadRequest)
+	case pkg.ErrSiteForbidden:
+		w.WriteHeader(http.StatusForbidden)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
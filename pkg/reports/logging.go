@@ -0,0 +1,33 @@
+package reports
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Export(ctx context.Context, w io.Writer, name string, params Params, format Format) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "export",
+			"report", name,
+			"from", params.From,
+			"to", params.To,
+			"site_id", params.Site.SiteID,
+			"format", format,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Export(ctx, w, name, params, format)
+}
@@ -0,0 +1,154 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// Report describes one of the reports that can be exported.
+type Report struct {
+	Name    string
+	Title   string
+	Columns []string
+}
+
+const (
+	ReportDepartmentUsage = "department-usage"
+	ReportGuestUsage      = "guest-usage"
+	ReportNoShows         = "no-shows"
+	ReportMealTypeTrend   = "meal-type-trend"
+)
+
+var Reports = []Report{
+	{ReportDepartmentUsage, "Usage by department", []string{"month", "department", "reservations", "employees", "guests", "attended", "meals"}},
+	{ReportGuestUsage, "Guests by host", []string{"user_id", "employee_id", "name", "department", "reservations", "guests"}},
+	{ReportNoShows, "No-show rate", []string{"user_id", "employee_id", "name", "department", "reservations", "attended", "no_shows", "no_show_rate"}},
+	{ReportMealTypeTrend, "Meal type trend", []string{"period_start", "meal_type_id", "meal_type", "reservations", "guests", "attended"}},
+}
+
+// Interval is the length of the periods the meal type trend is bucketed by.
+type Interval string
+
+const (
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+)
+
+func ParseInterval(s string) (Interval, error) {
+	switch Interval(s) {
+	case "", IntervalMonth:
+		return IntervalMonth, nil
+	case IntervalWeek:
+		return IntervalWeek, nil
+	}
+	return "", fmt.Errorf("unknown interval %q", s)
+}
+
+// Params narrows a report to the meals served from From to To, both
+// inclusive, at the sites Site lets through. Interval only applies to the
+// meal type trend.
+type Params struct {
+	From     time.Time
+	To       time.Time
+	Site     pkg.SiteFilter
+	Interval Interval
+}
+
+var ErrReportNotFound = errors.New("report not found")
+
+type Service interface {
+	Reports(context.Context) []Report
+	// Export writes the named report to w in format. Rows are written while
+	// they are read, so a failure part way leaves w with a partial report.
+	Export(ctx context.Context, w io.Writer, name string, params Params, format Format) error
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository pkg.ReportRepository
+	sites      pkg.SiteRepository
+}
+
+func NewService(repository pkg.ReportRepository, sites pkg.SiteRepository) Service {
+	return &service{repository: repository, sites: sites}
+}
+
+func (s *service) Reports(ctx context.Context) []Report {
+	return Reports
+}
+
+func (s *service) Export(ctx context.Context, w io.Writer, name string, params Params, format Format) error {
+	var report *Report
+	for i := range Reports {
+		if Reports[i].Name == name {
+			report = &Reports[i]
+		}
+	}
+	if report == nil {
+		return ErrReportNotFound
+	}
+	sites, err := pkg.ResolveSites(ctx, s.sites, params.Site)
+	if err == pkg.ErrSiteForbidden {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not find managed sites: %v", err)
+	}
+
+	out, err := NewTableWriter(w, format, report.Title)
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(report.Columns))
+	for i, column := range report.Columns {
+		header[i] = column
+	}
+	if err := out.WriteRow(header...); err != nil {
+		return fmt.Errorf("could not write report: %v", err)
+	}
+	if err := s.write(ctx, out, name, params, sites); err != nil {
+		return fmt.Errorf("could not write report: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("could not write report: %v", err)
+	}
+	return nil
+}
+
+func (s *service) write(ctx context.Context, out TableWriter, name string, params Params, sites []int64) error {
+	from, to := pkg.Date(params.From), pkg.Date(params.To)
+	switch name {
+	case ReportDepartmentUsage:
+		return s.repository.DepartmentUsage(ctx, from, to, sites, func(row pkg.DepartmentUsage) error {
+			return out.WriteRow(row.Month.Format("2006-01"), row.Department, row.Reservations, row.Employees, row.Guests, row.Attended, row.Reservations+row.Guests)
+		})
+	case ReportGuestUsage:
+		return s.repository.GuestUsage(ctx, from, to, sites, func(row pkg.GuestUsage) error {
+			return out.WriteRow(row.UserID, row.EmployeeID, row.Name, row.Department, row.Reservations, row.Guests)
+		})
+	case ReportNoShows:
+		// only meals that were already served can have been missed.
+		if yesterday := pkg.Date(time.Now()).AddDate(0, 0, -1); to.After(yesterday) {
+			to = yesterday
+		}
+		if to.Before(from) {
+			return nil
+		}
+		return s.repository.NoShowRates(ctx, from, to, sites, func(row pkg.NoShowRate) error {
+			return out.WriteRow(row.UserID, row.EmployeeID, row.Name, row.Department, row.Reservations, row.Attended, row.NoShows(), math.Round(row.Rate()*10000)/10000)
+		})
+	case ReportMealTypeTrend:
+		return s.repository.MealTypeTrend(ctx, from, to, params.Interval == IntervalWeek, sites, func(row pkg.MealTypeTrend) error {
+			return out.WriteRow(row.PeriodStart.Format("2006-01-02"), row.MealTypeID, row.MealType, row.Reservations, row.Guests, row.Attended)
+		})
+	}
+	return ErrReportNotFound
+}
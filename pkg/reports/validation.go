@@ -0,0 +1,34 @@
+package reports
+
+import (
+	"context"
+	"io"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) Export(ctx context.Context, w io.Writer, name string, params Params, format Format) error {
+	var verr pkg.ValidationError
+
+	if params.From.IsZero() {
+		verr.Add("from", "required", "from is required")
+	}
+	if params.To.IsZero() {
+		verr.Add("to", "required", "to is required")
+	} else if pkg.Date(params.To).Before(pkg.Date(params.From)) {
+		verr.Add("to", "before_start", "to must not be before from")
+	}
+
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	return s.Service.Export(ctx, w, name, params, format)
+}
@@ -9,39 +9,131 @@ import (
 var (
 	ErrReservationNotFound      = errors.New("reservation not found")
 	ErrReservationAlreadyExists = errors.New("reservation already exists")
+	ErrReservationModified      = errors.New("reservation was modified concurrently")
+	ErrReservationNotActive     = errors.New("reservation is not active")
+	ErrBookingClosed            = errors.New("booking has closed for that meal")
+	ErrMealFull                 = errors.New("meal is fully booked")
 )
 
-type ReservationType int
+// ErrDuplicateReservation is ErrReservationAlreadyExists carrying the ID of
+// the reservation that already holds the user's meal for that day.
+type ErrDuplicateReservation struct{ ExistingID int64 }
+
+func (e ErrDuplicateReservation) Error() string { return ErrReservationAlreadyExists.Error() }
+
+func (e ErrDuplicateReservation) Is(target error) bool { return target == ErrReservationAlreadyExists }
+
+type ReservationStatus string
 
 const (
-	Breakfast ReservationType = iota + 1
-	Lunch
-	Dinner
+	ReservationActive    ReservationStatus = "active"
+	ReservationCancelled ReservationStatus = "cancelled"
 )
 
-func (r ReservationType) String() string {
-	types := [...]string{"breakfast", "lunch", "dinner"}
-	if r < Breakfast || r > Dinner {
-		return ""
-	}
-	return types[r-1]
-}
-
 type Reservation struct {
 	ID              int64
 	UserID          int64
 	ReservationTime time.Time
-	Type            ReservationType
+	MealTypeID      int64
 	NoOfGuests      int64
-	CreatedAt       time.Time
+	// SiteID is where the meal is eaten, the user's own site unless the
+	// booking says otherwise.
+	SiteID int64
+	// ServiceDate is the day the meal is served on, ReservationTime in the
+	// time zone of the site. It is worked out when the reservation is saved.
+	ServiceDate time.Time
+	// Dietary overrides the user's dietary profile for this meal when set.
+	Dietary *DietaryProfile
+	Status  ReservationStatus
+	// CheckedInAt is when the user was let in for the meal, nil until then.
+	CheckedInAt *time.Time
+	CreatedAt   time.Time
+	// Version is bumped on every update. When passed to Update or
+	// DeleteByID a non-zero Version must match the stored one.
+	Version int64
+}
+
+// DietaryProfileOf returns the profile the kitchen should cater for: the
+// reservation's override if it has one, otherwise that of user.
+func (r Reservation) DietaryProfileOf(user User) DietaryProfile {
+	if r.Dietary != nil {
+		return *r.Dietary
+	}
+	return user.Dietary
+}
+
+// Headcount is the number of plates booked for one group of a service day.
+// MealTypeID, SiteID, Department, Diet and Allergen are only set when
+// grouping by them. Grouped by allergen, a reservation counts once for each
+// allergen of its effective dietary profile and under an empty Allergen when
+// it has none.
+type Headcount struct {
+	ServiceDate time.Time
+	MealTypeID  int64
+	SiteID      int64
+	Department  string
+	Diet        Diet
+	Allergen    string
+	Employees   int64
+	Guests      int64
+}
+
+// HeadcountGrouping picks the dimensions, besides the service date, that
+// headcounts are broken down by.
+type HeadcountGrouping struct {
+	Meal       bool
+	Site       bool
+	Department bool
+	Diet       bool
+	Allergen   bool
+}
+
+// HeadcountPublisher tells the watchers of live headcounts that reservations
+// for the service dates changed.
+type HeadcountPublisher interface {
+	PublishHeadcount(ctx context.Context, dates ...time.Time)
 }
 
 type ReservationRepository interface {
-	Insert(context.Context, *Reservation) error
+	// Insert stores a reservation and, unless hold is nil, places hold for it
+	// in the same transaction, failing with ErrInsufficientFunds if its
+	// wallet cannot cover it.
+	Insert(ctx context.Context, reservation *Reservation, hold *WalletHold) error
+	// InsertAll stores reservations in one transaction, either all of them
+	// or, if one cannot be stored, none. The index of that one is returned
+	// along with its error. The hold at the index of a reservation, unless
+	// nil, is placed for it in the same transaction, failing with
+	// ErrInsufficientFunds if its wallet cannot cover it. The capacity at the
+	// index of a reservation, unless zero, is the number of plates its meal
+	// takes at its site, and booking past it fails with ErrMealFull.
+	InsertAll(ctx context.Context, reservations []Reservation, holds []*WalletHold, capacities []int64) (int, error)
 	FindAll(context.Context) ([]Reservation, error)
 	FindByID(context.Context, int64) (*Reservation, error)
 	FindByEmployeeID(context.Context, int64) ([]Reservation, error)
+	// FindByDate returns every reservation served on the calendar day of
+	// date, which is the day in the time zone of each site.
 	FindByDate(context.Context, time.Time) ([]Reservation, error)
-	Update(context.Context, *Reservation) error
-	DeleteByID(context.Context, int64) error
+	// FindActiveForMeal returns the active reservations of a meal type on the
+	// day of date.
+	FindActiveForMeal(ctx context.Context, date time.Time, mealTypeID int64) ([]Reservation, error)
+	// FindActiveBetween returns the active reservations of every service date
+	// from from to to, both inclusive.
+	FindActiveBetween(ctx context.Context, from, to time.Time) ([]Reservation, error)
+	// FindUpcomingByUser returns the reservations of a user, cancelled ones
+	// included, from the service date of from onwards.
+	FindUpcomingByUser(ctx context.Context, userID int64, from time.Time) ([]Reservation, error)
+	// FindRegularDiners returns the users who had an active reservation of a
+	// meal type on the weekday of date in at least min of the weeks before.
+	FindRegularDiners(ctx context.Context, date time.Time, mealTypeID, weeks, min int64) ([]int64, error)
+	// Update stores the changes to a reservation and, unless hold is nil,
+	// replaces its hold with hold in the same transaction, as Insert does.
+	Update(ctx context.Context, reservation *Reservation, hold *WalletHold) error
+	// CheckIn records that the user of an active reservation was let in at
+	// at. Checking in again keeps the first time.
+	CheckIn(ctx context.Context, id int64, at time.Time) error
+	DeleteByID(ctx context.Context, id, version int64) error
+	// Headcount counts the active reservations and their guests at sites,
+	// nil for every site, for every service date from from to to, both
+	// inclusive.
+	Headcount(ctx context.Context, from, to time.Time, groupBy HeadcountGrouping, sites []int64) ([]Headcount, error)
 }
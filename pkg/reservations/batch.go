@@ -0,0 +1,368 @@
+package reservations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// BatchMode decides what happens to the rest of a batch when one of its
+// reservations cannot be booked.
+type BatchMode string
+
+const (
+	// BatchAllOrNothing books every reservation of a batch or none of them.
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort books whichever reservations of a batch it can.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+const (
+	maxBatchSize = 100
+	maxRangeDays = 31
+)
+
+var (
+	ErrUnknownBatchMode = errors.New("unknown batch mode")
+	// ErrBatchAborted is the result of a reservation that could have been
+	// booked but was not, as another one of its all or nothing batch failed.
+	ErrBatchAborted = errors.New("not booked because another reservation in the batch failed")
+)
+
+// BatchResult is the outcome of booking one reservation of a batch, either
+// the booked reservation or why it was not booked.
+type BatchResult struct {
+	Reservation *pkg.Reservation
+	Err         error
+}
+
+// BookingRange asks for the meal types to be booked on every day from From to
+// To, both inclusive, that they are served on.
+type BookingRange struct {
+	UserID      int64
+	SiteID      int64
+	From        time.Time
+	To          time.Time
+	MealTypeIDs []int64
+	NoOfGuests  int64
+	Dietary     *pkg.DietaryProfile
+}
+
+func (s *service) ExpandRange(ctx context.Context, booking BookingRange) ([]pkg.Reservation, error) {
+	if booking.SiteID == 0 {
+		user, err := s.users.FindByID(ctx, booking.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("could not find user: %v", err)
+		}
+		booking.SiteID = user.SiteID
+	}
+	loc, err := s.siteLocation(ctx, booking.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	mealTypes := make([]*pkg.MealType, 0, len(booking.MealTypeIDs))
+	for _, id := range booking.MealTypeIDs {
+		mealType, err := s.mealTypes.FindByID(ctx, id)
+		if err == pkg.ErrMealTypeNotFound {
+			return nil, pkg.ErrUnknownMealType
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not find meal type: %v", err)
+		}
+		mealTypes = append(mealTypes, mealType)
+	}
+
+	// meals that are not served or have already started are skipped rather
+	// than failed, so the rest of a week can be booked in the middle of it.
+	now := time.Now()
+	var list []pkg.Reservation
+	for date := pkg.Date(booking.From); !date.After(pkg.Date(booking.To)); date = date.AddDate(0, 0, 1) {
+		for _, mealType := range mealTypes {
+			start := mealType.ServingStart.On(pkg.LocalDate(date, loc))
+			if !mealType.ServedOn(date.Weekday()) || !mealType.ServedAt(booking.SiteID) || !start.After(now) {
+				continue
+			}
+			list = append(list, pkg.Reservation{
+				UserID:          booking.UserID,
+				ReservationTime: start,
+				MealTypeID:      mealType.ID,
+				SiteID:          booking.SiteID,
+				NoOfGuests:      booking.NoOfGuests,
+				Dietary:         booking.Dietary,
+			})
+		}
+	}
+	return list, nil
+}
+
+func (s *service) SaveBatch(ctx context.Context, mode BatchMode, reservations []pkg.Reservation) ([]BatchResult, error) {
+	b := &batch{service: s, knownSites: make(map[int64]*pkg.Site), knownMealTypes: make(map[int64]*pkg.MealType), plates: make(map[slot]int64), booking: make(map[booker]bool)}
+	switch mode {
+	case BatchAllOrNothing:
+		b.reserve = true
+		return b.saveAll(ctx, reservations)
+	case BatchBestEffort:
+		return b.saveEach(ctx, reservations), nil
+	default:
+		return nil, ErrUnknownBatchMode
+	}
+}
+
+// slot is a meal served at a site on a service date, which the capacity of
+// the meal is shared within.
+type slot struct {
+	date       time.Time
+	mealTypeID int64
+	siteID     int64
+}
+
+// booker is a user booking a slot, which they can only book once.
+type booker struct {
+	slot
+	userID int64
+}
+
+// batch checks the reservations of a batch against each other as well as
+// against what is already booked.
+type batch struct {
+	*service
+	knownSites     map[int64]*pkg.Site
+	knownMealTypes map[int64]*pkg.MealType
+	// plates are those booked for a slot, counting the reservations of the
+	// batch booked so far.
+	plates map[slot]int64
+	// booking holds the users with a reservation of the batch in a slot.
+	booking map[booker]bool
+	// reserve counts the plates of a reservation as booked as soon as it
+	// passes its checks, for batches that are booked all at once.
+	reserve bool
+}
+
+// saveAll books reservations and holds their costs in a single transaction
+// once they all pass their checks.
+func (b *batch) saveAll(ctx context.Context, reservations []pkg.Reservation) ([]BatchResult, error) {
+	results := make([]BatchResult, len(reservations))
+	failed := -1
+	for i := range reservations {
+		if err := b.check(ctx, &reservations[i]); err != nil {
+			if !isBookingError(err) {
+				return nil, err
+			}
+			results[i].Err = err
+			failed = i
+		}
+	}
+	if failed >= 0 {
+		return aborted(results), nil
+	}
+
+	holds := make([]*pkg.WalletHold, len(reservations))
+	capacities := make([]int64, len(reservations))
+	for i, reservation := range reservations {
+		capacity, err := b.capacity(ctx, reservation)
+		if err != nil {
+			return nil, err
+		}
+		capacities[i] = capacity
+		hold, err := b.payments.Quote(ctx, reservation)
+		if _, ok := err.(pkg.ErrPriceMissing); ok {
+			results[i].Err = err
+			return aborted(results), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		holds[i] = hold
+	}
+
+	// capacities are checked again as the batch is booked, since other
+	// bookings may have taken the plates counted by check.
+	if i, err := b.repository.InsertAll(ctx, reservations, holds, capacities); err != nil {
+		if !errors.Is(err, pkg.ErrReservationAlreadyExists) && err != pkg.ErrUnknownSite && err != pkg.ErrInsufficientFunds && err != pkg.ErrMealFull {
+			return nil, fmt.Errorf("could not save reservations: %v", err)
+		}
+		results[i].Err = err
+		return aborted(results), nil
+	}
+
+	dates := make([]time.Time, 0, len(reservations))
+	for i := range reservations {
+		results[i].Reservation = &reservations[i]
+		dates = append(dates, reservations[i].ServiceDate)
+	}
+	b.PublishHeadcount(ctx, dates...)
+	return results, nil
+}
+
+// saveEach books reservations one at a time, each in its own transaction,
+// leaving out those that fail.
+func (b *batch) saveEach(ctx context.Context, reservations []pkg.Reservation) []BatchResult {
+	results := make([]BatchResult, len(reservations))
+	var dates []time.Time
+	for i := range reservations {
+		reservation := &reservations[i]
+		if err := b.check(ctx, reservation); err != nil {
+			results[i].Err = err
+			continue
+		}
+		capacity, err := b.capacity(ctx, *reservation)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		hold, err := b.payments.Quote(ctx, *reservation)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if _, err := b.repository.InsertAll(ctx, reservations[i:i+1], []*pkg.WalletHold{hold}, []int64{capacity}); err != nil {
+			if !errors.Is(err, pkg.ErrReservationAlreadyExists) && err != pkg.ErrUnknownSite && err != pkg.ErrInsufficientFunds && err != pkg.ErrMealFull {
+				err = fmt.Errorf("could not save reservation: %v", err)
+			}
+			results[i].Err = err
+			continue
+		}
+		b.plates[slotOf(*reservation)] += plates(*reservation)
+		results[i].Reservation = reservation
+		dates = append(dates, reservation.ServiceDate)
+	}
+	b.PublishHeadcount(ctx, dates...)
+	return results
+}
+
+// check runs the checks of Save on a reservation and makes sure it is booked
+// before its meal starts and that its meal has room for it.
+func (b *batch) check(ctx context.Context, reservation *pkg.Reservation) error {
+	if err := b.place(ctx, reservation); err != nil {
+		return err
+	}
+	if err := b.checkMealType(ctx, *reservation); err != nil {
+		return err
+	}
+	if err := b.checkClosures(ctx, *reservation); err != nil {
+		return err
+	}
+
+	mealType, err := b.mealType(ctx, reservation.MealTypeID)
+	if err != nil {
+		return err
+	}
+	site, err := b.site(ctx, reservation.SiteID)
+	if err != nil {
+		return err
+	}
+	loc, err := site.Location()
+	if err != nil {
+		return fmt.Errorf("could not load time zone of site %d: %v", site.ID, err)
+	}
+	if !time.Now().Before(mealType.ServingStart.On(pkg.LocalDate(reservation.ServiceDate, loc))) {
+		return pkg.ErrBookingClosed
+	}
+
+	key := slotOf(*reservation)
+	if b.booking[booker{key, reservation.UserID}] {
+		return pkg.ErrReservationAlreadyExists
+	}
+	booked, err := b.booked(ctx, key)
+	if err != nil {
+		return err
+	}
+	if capacity := mealType.CapacityAt(*site); capacity > 0 && booked+plates(*reservation) > capacity {
+		return pkg.ErrMealFull
+	}
+	if b.reserve {
+		b.booking[booker{key, reservation.UserID}] = true
+		b.plates[key] += plates(*reservation)
+	}
+	return nil
+}
+
+// booked returns the plates booked for a slot, counting them once per batch.
+func (b *batch) booked(ctx context.Context, key slot) (int64, error) {
+	if n, ok := b.plates[key]; ok {
+		return n, nil
+	}
+	list, err := b.repository.Headcount(ctx, key.date, key.date, headcountGrouping, []int64{key.siteID})
+	if err != nil {
+		return 0, fmt.Errorf("could not count reservations: %v", err)
+	}
+	for _, headcount := range list {
+		if headcount.MealTypeID == key.mealTypeID && headcount.SiteID == key.siteID {
+			b.plates[key] = headcount.Employees + headcount.Guests
+		}
+	}
+	return b.plates[key], nil
+}
+
+// capacity is the number of plates the meal of a reservation takes at its
+// site, zero meaning there is no limit.
+func (b *batch) capacity(ctx context.Context, reservation pkg.Reservation) (int64, error) {
+	mealType, err := b.mealType(ctx, reservation.MealTypeID)
+	if err != nil {
+		return 0, err
+	}
+	site, err := b.site(ctx, reservation.SiteID)
+	if err != nil {
+		return 0, err
+	}
+	return mealType.CapacityAt(*site), nil
+}
+
+func (b *batch) mealType(ctx context.Context, id int64) (*pkg.MealType, error) {
+	if mealType, ok := b.knownMealTypes[id]; ok {
+		return mealType, nil
+	}
+	mealType, err := b.mealTypes.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not find meal type: %v", err)
+	}
+	b.knownMealTypes[id] = mealType
+	return mealType, nil
+}
+
+func (b *batch) site(ctx context.Context, id int64) (*pkg.Site, error) {
+	if site, ok := b.knownSites[id]; ok {
+		return site, nil
+	}
+	site, err := b.sites.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not find site: %v", err)
+	}
+	b.knownSites[id] = site
+	return site, nil
+}
+
+func slotOf(reservation pkg.Reservation) slot {
+	return slot{date: reservation.ServiceDate, mealTypeID: reservation.MealTypeID, siteID: reservation.SiteID}
+}
+
+// plates counts the employee a reservation is for along with their guests.
+func plates(reservation pkg.Reservation) int64 { return 1 + reservation.NoOfGuests }
+
+// aborted fails every result of an all or nothing batch that has not failed
+// on its own.
+func aborted(results []BatchResult) []BatchResult {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchAborted
+		}
+	}
+	return results
+}
+
+// isBookingError reports whether err is about the reservation itself rather
+// than the service failing to check it.
+func isBookingError(err error) bool {
+	if _, ok := err.(pkg.ErrClosed); ok {
+		return true
+	}
+	switch err {
+	case pkg.ErrUnknownSite, pkg.ErrUnknownMealType, pkg.ErrMealTypeNotServed, pkg.ErrMealTypeNotAtSite, pkg.ErrBookingClosed, pkg.ErrMealFull, pkg.ErrReservationAlreadyExists:
+		return true
+	}
+	return false
+}
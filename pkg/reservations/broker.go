@@ -0,0 +1,169 @@
+package reservations
+
+import (
+	"sync"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// HeadcountUpdate is the headcount of a service date, by meal and site, right
+// after a reservation for it changed. IDs grow with every update, whatever
+// its date.
+type HeadcountUpdate struct {
+	ID         int64
+	Date       time.Time
+	Headcounts []pkg.Headcount
+	// uncounted marks a change that nobody was watching, which was not
+	// counted and cannot be replayed.
+	uncounted bool
+}
+
+// headcountGrouping is how updates break the headcount down.
+var headcountGrouping = pkg.HeadcountGrouping{Meal: true, Site: true}
+
+// at keeps only the headcounts of sites, nil keeping them all.
+func (u HeadcountUpdate) at(sites []int64) HeadcountUpdate {
+	if sites == nil {
+		return u
+	}
+	headcounts := make([]pkg.Headcount, 0, len(u.Headcounts))
+	for _, headcount := range u.Headcounts {
+		if pkg.HasSite(sites, headcount.SiteID) {
+			headcounts = append(headcounts, headcount)
+		}
+	}
+	u.Headcounts = headcounts
+	return u
+}
+
+const (
+	headcountBacklog = 256
+	subscriberBuffer = 16
+)
+
+// broker hands headcount updates to the watchers of their date in process.
+// It keeps the latest updates around, so that a watcher coming back can be
+// given those it missed.
+type broker struct {
+	// counting makes updates get counted one at a time, so that a later ID
+	// never carries an older count.
+	counting sync.Mutex
+
+	mu          sync.Mutex
+	lastID      int64
+	backlog     []HeadcountUpdate
+	subscribers map[*subscription]bool
+}
+
+type subscription struct {
+	date    time.Time
+	updates chan HeadcountUpdate
+}
+
+func newBroker() *broker {
+	// IDs start at the current time so that an ID from before a restart is
+	// never mistaken for a recent one.
+	return &broker{lastID: time.Now().UnixNano(), subscribers: make(map[*subscription]bool)}
+}
+
+// update counts the headcount of date with count and sends it to the
+// watchers of date. A date nobody watches is not counted.
+func (b *broker) update(date time.Time, count func() ([]pkg.Headcount, error)) error {
+	b.counting.Lock()
+	defer b.counting.Unlock()
+
+	if !b.watched(date) {
+		return nil
+	}
+	headcounts, err := count()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	update := b.add(HeadcountUpdate{Date: date, Headcounts: headcounts})
+	for sub := range b.subscribers {
+		if !sub.date.Equal(date) {
+			continue
+		}
+		select {
+		case sub.updates <- update:
+		default:
+			// a watcher that falls behind is dropped rather than holding
+			// up the others. It can come back for what it missed.
+			delete(b.subscribers, sub)
+			close(sub.updates)
+		}
+	}
+	return nil
+}
+
+// watched reports whether date has watchers. The change is still recorded
+// if it has none, so that a watcher coming back for date starts over rather
+// than missing it.
+func (b *broker) watched(date time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.date.Equal(date) {
+			return true
+		}
+	}
+	b.add(HeadcountUpdate{Date: date, uncounted: true})
+	return false
+}
+
+// add gives update the next ID and keeps it in the backlog. b.mu must be
+// held.
+func (b *broker) add(update HeadcountUpdate) HeadcountUpdate {
+	b.lastID++
+	update.ID = b.lastID
+	if len(b.backlog) == headcountBacklog {
+		b.backlog = append(b.backlog[:0], b.backlog[1:]...)
+	}
+	b.backlog = append(b.backlog, update)
+	return update
+}
+
+// subscribe starts watching date. Unless after is the ID of an update that
+// is still kept, caughtUp is false and missed is empty: the watcher has to
+// start over from the current headcount, as of lastID.
+func (b *broker) subscribe(date time.Time, after int64) (sub *subscription, missed []HeadcountUpdate, caughtUp bool, lastID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &subscription{date: date, updates: make(chan HeadcountUpdate, subscriberBuffer)}
+	b.subscribers[sub] = true
+
+	oldest := b.lastID + 1
+	if len(b.backlog) > 0 {
+		oldest = b.backlog[0].ID
+	}
+	if after < oldest-1 || after > b.lastID {
+		return sub, nil, false, b.lastID
+	}
+	for _, update := range b.backlog {
+		if update.ID <= after || !update.Date.Equal(date) {
+			continue
+		}
+		if update.uncounted {
+			return sub, nil, false, b.lastID
+		}
+		missed = append(missed, update)
+	}
+	return sub, missed, true, b.lastID
+}
+
+func (b *broker) unsubscribe(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.updates)
+	}
+}
@@ -0,0 +1,117 @@
+package reservations
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/ical"
+)
+
+func (s *service) RegenerateFeed(ctx context.Context, userID int64) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("could not generate feed token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := s.feeds.Save(ctx, userID, hashFeedToken(token)); err != nil {
+		if err == pkg.ErrUserNotFound {
+			return "", err
+		}
+		return "", fmt.Errorf("could not save feed: %v", err)
+	}
+	return token, nil
+}
+
+func (s *service) RevokeFeed(ctx context.Context, userID int64) error {
+	if err := s.feeds.Delete(ctx, userID); err != nil {
+		if err == pkg.ErrFeedNotFound {
+			return err
+		}
+		return fmt.Errorf("could not revoke feed: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Feed(ctx context.Context, token string) (*ical.Calendar, error) {
+	userID, err := s.feeds.FindUserByTokenHash(ctx, hashFeedToken(token))
+	if err == pkg.ErrFeedNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find feed: %v", err)
+	}
+
+	// today is yesterday in a site behind UTC, so the day before is asked
+	// for too.
+	now := time.Now()
+	reservations, err := s.repository.FindUpcomingByUser(ctx, userID, now.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("could not list reservations: %v", err)
+	}
+
+	mealTypes := make(map[int64]*pkg.MealType)
+	locations := make(map[int64]*time.Location)
+	calendar := ical.Calendar{ProdID: "-//messapp//reservations//EN", Name: "Mess reservations", Stamp: now}
+	for _, reservation := range reservations {
+		mealType, ok := mealTypes[reservation.MealTypeID]
+		if !ok {
+			if mealType, err = s.mealTypes.FindByID(ctx, reservation.MealTypeID); err != nil {
+				return nil, fmt.Errorf("could not find meal type: %v", err)
+			}
+			mealTypes[reservation.MealTypeID] = mealType
+		}
+		loc, ok := locations[reservation.SiteID]
+		if !ok {
+			if loc, err = s.siteLocation(ctx, reservation.SiteID); err != nil {
+				return nil, err
+			}
+			locations[reservation.SiteID] = loc
+		}
+		calendar.Events = append(calendar.Events, feedEvent(reservation, *mealType, loc))
+	}
+	return &calendar, nil
+}
+
+// feedEvent spans the serving times of the meal on its service date, in loc,
+// the time zone of its site, ending the next day if the meal is served past
+// midnight. The UID only depends on the reservation, so
+// calendar apps update the event in place, and the version orders those
+// updates.
+func feedEvent(reservation pkg.Reservation, mealType pkg.MealType, loc *time.Location) ical.Event {
+	day := pkg.LocalDate(reservation.ServiceDate, loc)
+	// a meal served past midnight ends on the day after.
+	endDay := day
+	if mealType.ServingEnd <= mealType.ServingStart {
+		endDay = day.AddDate(0, 0, 1)
+	}
+
+	event := ical.Event{
+		UID:      fmt.Sprintf("reservation-%d@messapp", reservation.ID),
+		Summary:  mealType.Name,
+		Start:    mealType.ServingStart.On(day),
+		End:      mealType.ServingEnd.On(endDay),
+		Status:   ical.StatusConfirmed,
+		Sequence: reservation.Version,
+	}
+	if reservation.NoOfGuests > 0 {
+		event.Summary = fmt.Sprintf("%s (+%d)", mealType.Name, reservation.NoOfGuests)
+		event.Description = fmt.Sprintf("Booked with %d guest(s).", reservation.NoOfGuests)
+	}
+	if reservation.Status == pkg.ReservationCancelled {
+		event.Status = ical.StatusCancelled
+		event.Summary = "Cancelled: " + event.Summary
+	}
+	return event
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/ical"
+	"github.com/markhaur/messapp-backend/pkg/mergepatch"
 	"github.com/matryer/way"
 )
 
@@ -20,10 +25,18 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 	handleSaveReservation = s.handleSaveReservation()
 	handleSaveReservation = httpLoggingMiddleware(logger, "handleSaveReservation")(handleSaveReservation)
 
+	var handleSaveBatch http.Handler
+	handleSaveBatch = s.handleSaveBatch()
+	handleSaveBatch = httpLoggingMiddleware(logger, "handleSaveBatch")(handleSaveBatch)
+
 	var handleListReservations http.Handler
 	handleListReservations = s.handleListReservations()
 	handleListReservations = httpLoggingMiddleware(logger, "handleListReservations")(handleListReservations)
 
+	var handleGetReservation http.Handler
+	handleGetReservation = s.handleGetReservation()
+	handleGetReservation = httpLoggingMiddleware(logger, "handleGetReservation")(handleGetReservation)
+
 	var handleRemoveReservation http.Handler
 	handleRemoveReservation = s.handleRemoveReservation()
 	handleRemoveReservation = httpLoggingMiddleware(logger, "handleRemoveReservation")(handleRemoveReservation)
@@ -32,12 +45,49 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 	handleUpdateReservation = s.handleUpdateReservation()
 	handleUpdateReservation = httpLoggingMiddleware(logger, "handleUpdateReservation")(handleUpdateReservation)
 
+	var handlePatchReservation http.Handler
+	handlePatchReservation = s.handlePatchReservation()
+	handlePatchReservation = httpLoggingMiddleware(logger, "handlePatchReservation")(handlePatchReservation)
+
+	var handleCheckIn http.Handler
+	handleCheckIn = s.handleCheckIn()
+	handleCheckIn = httpLoggingMiddleware(logger, "handleCheckIn")(handleCheckIn)
+
+	var handleHeadcount http.Handler
+	handleHeadcount = s.handleHeadcount()
+	handleHeadcount = httpLoggingMiddleware(logger, "handleHeadcount")(handleHeadcount)
+
+	var handleHeadcountStream http.Handler
+	handleHeadcountStream = s.handleHeadcountStream()
+	handleHeadcountStream = httpLoggingMiddleware(logger, "handleHeadcountStream")(handleHeadcountStream)
+
+	var handleRegenerateFeed http.Handler
+	handleRegenerateFeed = s.handleRegenerateFeed()
+	handleRegenerateFeed = httpLoggingMiddleware(logger, "handleRegenerateFeed")(handleRegenerateFeed)
+
+	var handleRevokeFeed http.Handler
+	handleRevokeFeed = s.handleRevokeFeed()
+	handleRevokeFeed = httpLoggingMiddleware(logger, "handleRevokeFeed")(handleRevokeFeed)
+
+	var handleFeed http.Handler
+	handleFeed = s.handleFeed()
+	handleFeed = httpLoggingMiddleware(logger, "handleFeed")(handleFeed)
+
 	router := way.NewRouter()
 
 	router.Handle("POST", "/resvlist/v1/reservations", handleSaveReservation)
+	router.Handle("POST", "/resvlist/v1/reservations/batch", handleSaveBatch)
 	router.Handle("GET", "/resvlist/v1/reservations", handleListReservations)
+	router.Handle("GET", "/resvlist/v1/reservation/:id", handleGetReservation)
 	router.Handle("DELETE", "/resvlist/v1/reservation/:id", handleRemoveReservation)
 	router.Handle("PUT", "/resvlist/v1/reservation/:id", handleUpdateReservation)
+	router.Handle("PATCH", "/resvlist/v1/reservation/:id", handlePatchReservation)
+	router.Handle("POST", "/resvlist/v1/reservation/:id/check-in", handleCheckIn)
+	router.Handle("GET", "/resvlist/v1/headcount", handleHeadcount)
+	router.Handle("GET", "/resvlist/v1/headcount/stream", handleHeadcountStream)
+	router.Handle("POST", "/resvlist/v1/feed", handleRegenerateFeed)
+	router.Handle("DELETE", "/resvlist/v1/feed", handleRevokeFeed)
+	router.Handle("GET", "/resvlist/v1/feeds/:token", handleFeed)
 
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
 
@@ -47,12 +97,28 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 const (
 	contentTypeKey   = "Content-Type"
 	contentTypeValue = "application/json; charset=utf-8"
+	etagKey          = "ETag"
+	ifMatchKey       = "If-Match"
+	dateLayout       = "2006-01-02"
+	// userIDKey carries the ID of the calling user. The service does no
+	// authentication of its own and trusts the gateway in front of it to
+	// set this header.
+	userIDKey       = "X-User-ID"
+	feedPath        = "/resvlist/v1/feeds/"
+	feedExtension   = ".ics"
+	feedContentType = "text/calendar; charset=utf-8"
+	lastEventIDKey  = "Last-Event-ID"
 )
 
 var (
+	ErrMissingUserID           = fmt.Errorf("%s header is required", userIDKey)
 	ErrNonNumericReservationID = errors.New("reservation id in path must be numberic")
 	ErrResourceNotFound        = errors.New("resource not found")
 	ErrMethodNotAllowed        = errors.New("method not allowed")
+	ErrUnsupportedMediaType    = fmt.Errorf("content type must be %s", mergepatch.ContentType)
+	ErrInvalidQuery            = errors.New("invalid query parameter")
+	ErrStreamingUnsupported    = errors.New("connection does not support streaming")
+	ErrMissingBatch            = errors.New("either reservations or range is required, but not both")
 )
 
 type ErrInvalidRequestBody struct{ err error }
@@ -63,20 +129,182 @@ type server struct {
 	service Service
 }
 
+type reservationResponse struct {
+	ID              int64            `json:"id"`
+	UserID          int64            `json:"user_id"`
+	ReservationTime time.Time        `json:"reservation_time"`
+	ServiceDate     string           `json:"service_date"`
+	MealTypeID      int64            `json:"type"`
+	SiteID          int64            `json:"site_id"`
+	NoOfGuests      int64            `json:"no_of_guests"`
+	Dietary         *dietaryOverride `json:"dietary"`
+	Status          string           `json:"status"`
+	CheckedInAt     *time.Time       `json:"checked_in_at"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	Version         int64            `json:"version"`
+}
+
+func newReservationResponse(reservation pkg.Reservation) reservationResponse {
+	return reservationResponse{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, ServiceDate: reservation.ServiceDate.Format(dateLayout), MealTypeID: reservation.MealTypeID, SiteID: reservation.SiteID, NoOfGuests: reservation.NoOfGuests, Dietary: newDietaryOverride(reservation.Dietary), Status: string(reservation.Status), CheckedInAt: reservation.CheckedInAt, CreatedAt: reservation.CreatedAt, Version: reservation.Version}
+}
+
+// menuResponse is the published menu of the meal a reservation is for.
+type menuResponse struct {
+	ID     int64              `json:"id"`
+	Date   string             `json:"date"`
+	Dishes []menuDishResponse `json:"dishes"`
+}
+
+type menuDishResponse struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Diets       []string `json:"diets"`
+	Allergens   []string `json:"allergens"`
+}
+
+func newMenuResponse(menu pkg.Menu) *menuResponse {
+	resp := &menuResponse{ID: menu.ID, Date: menu.Date.Format(dateLayout), Dishes: make([]menuDishResponse, 0, len(menu.Dishes))}
+	for _, dish := range menu.Dishes {
+		d := menuDishResponse{ID: dish.ID, Name: dish.Name, Description: dish.Description, Diets: make([]string, 0, len(dish.Diets)), Allergens: dish.Allergens}
+		for _, diet := range dish.Diets {
+			d.Diets = append(d.Diets, string(diet))
+		}
+		if d.Allergens == nil {
+			d.Allergens = []string{}
+		}
+		resp.Dishes = append(resp.Dishes, d)
+	}
+	return resp
+}
+
+// warning flags something about a saved reservation the client should show.
+type warning struct {
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	DishID    int64    `json:"dish_id,omitempty"`
+	Allergens []string `json:"allergens,omitempty"`
+}
+
+func newAllergenWarning(c pkg.DishConflict) warning {
+	return warning{
+		Code:      "allergen_conflict",
+		Message:   fmt.Sprintf("%s contains %s", c.DishName, strings.Join(c.Allergens, ", ")),
+		DishID:    c.DishID,
+		Allergens: c.Allergens,
+	}
+}
+
+// dietaryOverride is the dietary profile a reservation uses instead of its
+// user's. null means the user's profile applies.
+type dietaryOverride struct {
+	Diet      string   `json:"diet"`
+	Allergens []string `json:"allergens"`
+}
+
+func newDietaryOverride(profile *pkg.DietaryProfile) *dietaryOverride {
+	if profile == nil {
+		return nil
+	}
+	allergens := profile.Allergens
+	if allergens == nil {
+		allergens = []string{}
+	}
+	return &dietaryOverride{Diet: string(profile.Diet), Allergens: allergens}
+}
+
+func (d *dietaryOverride) profile() *pkg.DietaryProfile {
+	if d == nil {
+		return nil
+	}
+	diet := d.Diet
+	if diet == "" {
+		diet = string(pkg.DietOmnivore)
+	}
+	return &pkg.DietaryProfile{Diet: pkg.Diet(diet), Allergens: d.Allergens}
+}
+
 func (s *server) handleSaveReservation() http.HandlerFunc {
 	type request struct {
-		UserID          int64               `json:"user_id"`
-		ReservationTime time.Time           `json:"reservation_time"`
-		Type            pkg.ReservationType `json:"type"`
-		NoOfGuests      int64               `json:"no_of_guests"`
+		UserID          int64            `json:"user_id"`
+		ReservationTime time.Time        `json:"reservation_time"`
+		MealTypeID      int64            `json:"type"`
+		SiteID          int64            `json:"site_id"`
+		NoOfGuests      int64            `json:"no_of_guests"`
+		Dietary         *dietaryOverride `json:"dietary"`
+	}
+	type response struct {
+		reservationResponse
+		Menu     *menuResponse `json:"menu"`
+		Warnings []warning     `json:"warnings"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		reservation, err := s.service.Save(r.Context(), pkg.Reservation{UserID: req.UserID, ReservationTime: req.ReservationTime, MealTypeID: req.MealTypeID, SiteID: req.SiteID, NoOfGuests: req.NoOfGuests, Dietary: req.Dietary.profile()})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// the reservation is saved by now, so a menu that cannot be found
+		// is left out rather than failing the request.
+		resp := response{reservationResponse: newReservationResponse(*reservation), Warnings: []warning{}}
+		if menu, conflicts, err := s.service.Menu(r.Context(), *reservation); err == nil {
+			resp.Menu = newMenuResponse(*menu)
+			for _, c := range conflicts {
+				resp.Warnings = append(resp.Warnings, newAllergenWarning(c))
+			}
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		w.Header().Set(etagKey, etag(reservation.Version))
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleSaveBatch answers POST /resvlist/v1/reservations/batch, which books
+// either a list of reservations or the meals of a date range. The response is
+// 200 OK when every reservation is booked and 207 Multi-Status otherwise,
+// with a result for each reservation in either case.
+func (s *server) handleSaveBatch() http.HandlerFunc {
+	type reservation struct {
+		UserID          int64            `json:"user_id"`
+		ReservationTime time.Time        `json:"reservation_time"`
+		MealTypeID      int64            `json:"type"`
+		SiteID          int64            `json:"site_id"`
+		NoOfGuests      int64            `json:"no_of_guests"`
+		Dietary         *dietaryOverride `json:"dietary"`
+	}
+	type bookingRange struct {
+		UserID      int64            `json:"user_id"`
+		SiteID      int64            `json:"site_id"`
+		From        string           `json:"from"`
+		To          string           `json:"to"`
+		MealTypeIDs []int64          `json:"types"`
+		NoOfGuests  int64            `json:"no_of_guests"`
+		Dietary     *dietaryOverride `json:"dietary"`
+	}
+	type request struct {
+		Mode         BatchMode     `json:"mode"`
+		Reservations []reservation `json:"reservations"`
+		Range        *bookingRange `json:"range"`
+	}
+	type result struct {
+		Index       int                    `json:"index"`
+		Status      int                    `json:"status"`
+		Reservation *reservationResponse   `json:"reservation,omitempty"`
+		Error       map[string]interface{} `json:"error,omitempty"`
 	}
 	type response struct {
-		ID              int64               `json:"id"`
-		UserID          int64               `json:"user_id"`
-		ReservationTime time.Time           `json:"reservation_time"`
-		Type            pkg.ReservationType `json:"type"`
-		NoOfGuests      int64               `json:"no_of_guests"`
-		CreatedAt       time.Time           `json:"createdAt"`
+		Mode    BatchMode `json:"mode"`
+		Saved   int       `json:"saved"`
+		Failed  int       `json:"failed"`
+		Results []result  `json:"results"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -85,14 +313,59 @@ func (s *server) handleSaveReservation() http.HandlerFunc {
 			writeError(w, ErrInvalidRequestBody{err})
 			return
 		}
+		if (req.Range == nil) == (req.Reservations == nil) {
+			writeError(w, ErrMissingBatch)
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = BatchAllOrNothing
+		}
+
+		var reservations []pkg.Reservation
+		if req.Range != nil {
+			from, err := time.Parse(dateLayout, req.Range.From)
+			if err != nil && req.Range.From != "" {
+				writeError(w, ErrInvalidRequestBody{fmt.Errorf("from must be a date like %s", dateLayout)})
+				return
+			}
+			to, err := time.Parse(dateLayout, req.Range.To)
+			if err != nil && req.Range.To != "" {
+				writeError(w, ErrInvalidRequestBody{fmt.Errorf("to must be a date like %s", dateLayout)})
+				return
+			}
+			reservations, err = s.service.ExpandRange(r.Context(), BookingRange{UserID: req.Range.UserID, SiteID: req.Range.SiteID, From: from, To: to, MealTypeIDs: req.Range.MealTypeIDs, NoOfGuests: req.Range.NoOfGuests, Dietary: req.Range.Dietary.profile()})
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		for _, v := range req.Reservations {
+			reservations = append(reservations, pkg.Reservation{UserID: v.UserID, ReservationTime: v.ReservationTime, MealTypeID: v.MealTypeID, SiteID: v.SiteID, NoOfGuests: v.NoOfGuests, Dietary: v.Dietary.profile()})
+		}
 
-		reservation, err := s.service.Save(r.Context(), pkg.Reservation{UserID: req.UserID, ReservationTime: req.ReservationTime, Type: req.Type, NoOfGuests: req.NoOfGuests})
+		results, err := s.service.SaveBatch(r.Context(), req.Mode, reservations)
 		if err != nil {
 			writeError(w, err)
 			return
 		}
+
+		resp := response{Mode: req.Mode, Results: make([]result, 0, len(results))}
+		for i, v := range results {
+			if v.Err != nil {
+				status, body := errorResponse(v.Err)
+				resp.Results = append(resp.Results, result{Index: i, Status: status, Error: body})
+				resp.Failed++
+				continue
+			}
+			booked := newReservationResponse(*v.Reservation)
+			resp.Results = append(resp.Results, result{Index: i, Status: http.StatusCreated, Reservation: &booked})
+			resp.Saved++
+		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: reservation.Type, NoOfGuests: req.NoOfGuests, CreatedAt: reservation.CreatedAt})
+		if resp.Failed > 0 {
+			w.WriteHeader(http.StatusMultiStatus)
+		}
+		json.NewEncoder(w).Encode(resp)
 	}
 }
 
@@ -101,14 +374,21 @@ func (s *server) handleListReservations() http.HandlerFunc {
 		ID              int64
 		UserID          int64
 		ReservationTime time.Time
-		Type            pkg.ReservationType
+		Type            int64
+		SiteID          int64
 		NoOfGuests      int64
 		CreatedAt       time.Time
 	}
 	type response []reservation
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		list, err := s.service.List(r.Context())
+		filter, err := siteFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		list, err := s.service.List(r.Context(), filter)
 		if err != nil {
 			writeError(w, err)
 			return
@@ -116,14 +396,113 @@ func (s *server) handleListReservations() http.HandlerFunc {
 
 		resp := make(response, 0, len(list))
 		for _, v := range list {
-			resp = append(resp, reservation{ID: v.ID, UserID: v.UserID, ReservationTime: v.ReservationTime, Type: v.Type, NoOfGuests: v.NoOfGuests, CreatedAt: v.CreatedAt})
+			resp = append(resp, reservation{ID: v.ID, UserID: v.UserID, ReservationTime: v.ReservationTime, Type: v.MealTypeID, SiteID: v.SiteID, NoOfGuests: v.NoOfGuests, CreatedAt: v.CreatedAt})
 		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
 		json.NewEncoder(w).Encode(resp)
 	}
 }
 
-func (s *server) handleRemoveReservation() http.HandlerFunc {
+// handleHeadcount answers GET /resvlist/v1/headcount?from=&to=&group_by=&site_id=.
+// from defaults to today, to defaults to from and group_by to meal. Without
+// site_id every site the caller may see is counted.
+func (s *server) handleHeadcount() http.HandlerFunc {
+	type headcount struct {
+		ServiceDate string  `json:"service_date"`
+		SiteID      *int64  `json:"site_id,omitempty"`
+		MealTypeID  *int64  `json:"meal_type_id,omitempty"`
+		Department  *string `json:"department,omitempty"`
+		Diet        *string `json:"diet,omitempty"`
+		Allergen    *string `json:"allergen,omitempty"`
+		Employees   int64   `json:"employees"`
+		Guests      int64   `json:"guests"`
+		Total       int64   `json:"total"`
+	}
+	type response []headcount
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		from := pkg.Date(time.Now())
+		if v := query.Get("from"); v != "" {
+			var err error
+			if from, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		to := from
+		if v := query.Get("to"); v != "" {
+			var err error
+			if to, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		groupBy := pkg.HeadcountGrouping{Meal: true}
+		if v, ok := query["group_by"]; ok {
+			groupBy = pkg.HeadcountGrouping{}
+			for _, dimension := range strings.Split(strings.Join(v, ","), ",") {
+				switch strings.TrimSpace(dimension) {
+				case "meal":
+					groupBy.Meal = true
+				case "department":
+					groupBy.Department = true
+				case "diet":
+					groupBy.Diet = true
+				case "allergen":
+					groupBy.Allergen = true
+				case "site":
+					groupBy.Site = true
+				case "":
+				default:
+					writeError(w, ErrInvalidQuery)
+					return
+				}
+			}
+		}
+
+		filter, err := siteFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		list, err := s.service.Headcount(r.Context(), from, to, groupBy, filter)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			v := v
+			h := headcount{ServiceDate: v.ServiceDate.Format(dateLayout), Employees: v.Employees, Guests: v.Guests, Total: v.Employees + v.Guests}
+			if groupBy.Site {
+				h.SiteID = &v.SiteID
+			}
+			if groupBy.Meal {
+				h.MealTypeID = &v.MealTypeID
+			}
+			if groupBy.Department {
+				h.Department = &v.Department
+			}
+			if groupBy.Diet {
+				diet := string(v.Diet)
+				h.Diet = &diet
+			}
+			if groupBy.Allergen {
+				h.Allergen = &v.Allergen
+			}
+			resp = append(resp, h)
+		}
+		w.Header().Set(contentTypeKey, contentTypeValue)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (s *server) handleGetReservation() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
 		if err != nil {
@@ -131,28 +510,56 @@ func (s *server) handleRemoveReservation() http.HandlerFunc {
 			return
 		}
 
-		if err := s.service.Remove(r.Context(), id); err != nil {
+		reservation, err := s.service.Get(r.Context(), id)
+		if err != nil {
 			writeError(w, err)
 			return
 		}
+		writeReservation(w, http.StatusOK, *reservation)
+	}
+}
+
+func (s *server) handleRemoveReservation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericReservationID)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id, ifMatchVersion(r)); err != nil {
+			s.writeUpdateError(w, r, id, err)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+func (s *server) handleCheckIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericReservationID)
+			return
+		}
+
+		reservation, err := s.service.CheckIn(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeReservation(w, http.StatusOK, *reservation)
+	}
+}
+
 func (s *server) handleUpdateReservation() http.HandlerFunc {
 	type request struct {
-		UserID          int64               `json:"user_id"`
-		ReservationTime time.Time           `json:"reservation_time"`
-		Type            pkg.ReservationType `json:"type"`
-		NoOfGuests      int64               `json:"no_of_guests"`
-	}
-	type response struct {
-		ID              int64               `json:"id"`
-		UserID          int64               `json:"user_id"`
-		ReservationTime time.Time           `json:"reservation_time"`
-		Type            pkg.ReservationType `json:"type"`
-		NoOfGuests      int64               `json:"no_of_guests"`
-		CreatedAt       time.Time           `json:"createdAt"`
+		UserID          int64            `json:"user_id"`
+		ReservationTime time.Time        `json:"reservation_time"`
+		MealTypeID      int64            `json:"type"`
+		SiteID          int64            `json:"site_id"`
+		NoOfGuests      int64            `json:"no_of_guests"`
+		Dietary         *dietaryOverride `json:"dietary"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
@@ -167,42 +574,286 @@ func (s *server) handleUpdateReservation() http.HandlerFunc {
 			return
 		}
 
-		reservation, isCreated, err := s.service.Update(r.Context(), pkg.Reservation{ID: id, UserID: req.UserID, ReservationTime: req.ReservationTime, Type: req.Type, NoOfGuests: req.NoOfGuests})
+		reservation, isCreated, err := s.service.Update(r.Context(), pkg.Reservation{ID: id, UserID: req.UserID, ReservationTime: req.ReservationTime, MealTypeID: req.MealTypeID, SiteID: req.SiteID, NoOfGuests: req.NoOfGuests, Dietary: req.Dietary.profile(), Version: ifMatchVersion(r)})
 		if err != nil {
-			writeError(w, err)
+			s.writeUpdateError(w, r, id, err)
 			return
 		}
 
+		status := http.StatusOK
 		if isCreated {
-			w.WriteHeader(http.StatusCreated)
+			status = http.StatusCreated
 		}
+		writeReservation(w, status, *reservation)
+	}
+}
 
+func (s *server) handlePatchReservation() http.HandlerFunc {
+	type document struct {
+		UserID          int64            `json:"user_id"`
+		ReservationTime time.Time        `json:"reservation_time"`
+		MealTypeID      int64            `json:"type"`
+		SiteID          int64            `json:"site_id"`
+		NoOfGuests      int64            `json:"no_of_guests"`
+		Dietary         *dietaryOverride `json:"dietary"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericReservationID)
+			return
+		}
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentTypeKey)); mediaType != mergepatch.ContentType {
+			writeError(w, ErrUnsupportedMediaType)
+			return
+		}
+
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		current, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// without If-Match the patch still applies only to the version it
+		// was merged into, so a concurrent write is never silently lost.
+		version := ifMatchVersion(r)
+		if version == 0 {
+			version = current.Version
+		}
+
+		doc, err := json.Marshal(document{UserID: current.UserID, ReservationTime: current.ReservationTime, MealTypeID: current.MealTypeID, SiteID: current.SiteID, NoOfGuests: current.NoOfGuests, Dietary: newDietaryOverride(current.Dietary)})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		merged, err := mergepatch.Apply(doc, patch)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		var req document
+		if err := json.Unmarshal(merged, &req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		reservation, _, err := s.service.Update(r.Context(), pkg.Reservation{ID: id, UserID: req.UserID, ReservationTime: req.ReservationTime, MealTypeID: req.MealTypeID, SiteID: req.SiteID, NoOfGuests: req.NoOfGuests, Dietary: req.Dietary.profile(), Version: version})
+		if err != nil {
+			s.writeUpdateError(w, r, id, err)
+			return
+		}
+		writeReservation(w, http.StatusOK, *reservation)
+	}
+}
+
+// handleRegenerateFeed replaces the calendar feed of the calling user and
+// answers with the only copy of its URL.
+func (s *server) handleRegenerateFeed() http.HandlerFunc {
+	type response struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := callerID(r)
+		if !ok {
+			writeError(w, ErrMissingUserID)
+			return
+		}
+
+		token, err := s.service.RegenerateFeed(r.Context(), userID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: reservation.ID, UserID: reservation.UserID, ReservationTime: reservation.ReservationTime, Type: reservation.Type, NoOfGuests: reservation.NoOfGuests, CreatedAt: reservation.CreatedAt})
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response{Token: token, URL: feedPath + token + feedExtension})
+	}
+}
+
+func (s *server) handleRevokeFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := callerID(r)
+		if !ok {
+			writeError(w, ErrMissingUserID)
+			return
+		}
+
+		if err := s.service.RevokeFeed(r.Context(), userID); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleFeed serves GET /resvlist/v1/feeds/:token.ics. The token is the
+// only credential, so calendar apps can subscribe without a session.
+func (s *server) handleFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := way.Param(r.Context(), "token")
+		if !strings.HasSuffix(token, feedExtension) || token == feedExtension {
+			writeError(w, ErrResourceNotFound)
+			return
+		}
+
+		calendar, err := s.service.Feed(r.Context(), strings.TrimSuffix(token, feedExtension))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set(contentTypeKey, feedContentType)
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		ical.Write(w, *calendar)
+	}
+}
+
+// callerID returns the user named by the X-User-ID header, if any.
+func callerID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.Header.Get(userIDKey), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// siteFilter reads the site_id query parameter and the caller, whose managed
+// sites limit what they see.
+func siteFilter(r *http.Request) (pkg.SiteFilter, error) {
+	var filter pkg.SiteFilter
+	if v := r.URL.Query().Get("site_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, ErrInvalidQuery
+		}
+		filter.SiteID = id
+	}
+	if id, ok := callerID(r); ok {
+		filter.ViewerID = id
+	}
+	return filter, nil
+}
+
+// writeUpdateError answers a failed precondition with the current
+// representation of the reservation so the client can retry against it.
+func (s *server) writeUpdateError(w http.ResponseWriter, r *http.Request, id int64, err error) {
+	if err != pkg.ErrReservationModified {
+		writeError(w, err)
+		return
+	}
+
+	current, getErr := s.service.Get(r.Context(), id)
+	if getErr != nil {
+		writeError(w, err)
+		return
+	}
+	writeReservation(w, http.StatusPreconditionFailed, *current)
+}
+
+func writeReservation(w http.ResponseWriter, status int, reservation pkg.Reservation) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.Header().Set(etagKey, etag(reservation.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newReservationResponse(reservation))
+}
+
+func etag(version int64) string { return strconv.Quote(strconv.FormatInt(version, 10)) }
+
+// ifMatchVersion returns the version named by a strong If-Match entity tag.
+// Zero means the header was absent or "*", so any version is accepted. A tag
+// this server could not have issued yields -1, which matches no version.
+func ifMatchVersion(r *http.Request) int64 {
+	value := strings.TrimSpace(r.Header.Get(ifMatchKey))
+	if value == "" || value == "*" {
+		return 0
+	}
+	tag, err := strconv.Unquote(value)
+	if err != nil {
+		return -1
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return -1
 	}
+	return version
 }
 
 func writeError(w http.ResponseWriter, err error) {
+	status, body := errorResponse(err)
 	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// errorResponse maps err to a status code and the body describing it, which
+// is also how the reservations of a batch that were not booked are described.
+func errorResponse(err error) (int, map[string]interface{}) {
+	body := map[string]interface{}{"error": err.Error()}
 
 	switch err {
-	case ErrResourceNotFound, pkg.ErrReservationNotFound:
-		w.WriteHeader(http.StatusNotFound)
-	case pkg.ErrReservationAlreadyExists:
-		w.WriteHeader(http.StatusConflict)
-	case ErrNonNumericReservationID:
-		w.WriteHeader(http.StatusBadRequest)
+	case ErrResourceNotFound, pkg.ErrReservationNotFound, pkg.ErrFeedNotFound, pkg.ErrUserNotFound:
+		return http.StatusNotFound, body
+	case ErrMissingUserID:
+		return http.StatusUnauthorized, body
+	case pkg.ErrSiteForbidden:
+		return http.StatusForbidden, body
+	case pkg.ErrReservationAlreadyExists, pkg.ErrReservationNotActive, pkg.ErrBookingClosed, pkg.ErrMealFull:
+		return http.StatusConflict, body
+	case pkg.ErrReservationModified:
+		return http.StatusPreconditionFailed, body
+	case pkg.ErrInsufficientFunds:
+		return http.StatusPaymentRequired, body
+	case ErrNonNumericReservationID, pkg.ErrUnknownMealType, pkg.ErrMealTypeNotServed, pkg.ErrMealTypeNotAtSite, pkg.ErrUnknownSite, ErrInvalidQuery, ErrInvalidHeadcountRange, ErrUnknownBatchMode, ErrMissingBatch:
+		return http.StatusBadRequest, body
+	case ErrBatchAborted:
+		return http.StatusFailedDependency, body
 	case ErrMethodNotAllowed:
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed, body
+	case ErrUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType, body
 	default:
-		switch err.(type) {
+		switch e := err.(type) {
 		case ErrInvalidRequestBody:
-			w.WriteHeader(http.StatusBadRequest)
+			return http.StatusBadRequest, body
+		case pkg.ErrDuplicateReservation:
+			body["existing_id"] = e.ExistingID
+			return http.StatusConflict, body
+		case pkg.ErrClosed:
+			body["closure_id"] = e.ClosureID
+			return http.StatusConflict, body
+		case pkg.ErrPriceMissing:
+			body["meal_type_id"] = e.MealTypeID
+			body["date"] = e.Date.Format(dateLayout)
+			return http.StatusConflict, body
+		case pkg.ValidationError:
+			body["fields"] = fieldErrors(e)
+			return http.StatusUnprocessableEntity, body
 		default:
-			w.WriteHeader(http.StatusInternalServerError)
+			return http.StatusInternalServerError, body
 		}
 	}
-	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
 }
 
 type loggingResponseWriter struct {
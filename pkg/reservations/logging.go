@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/ical"
 )
 
 func LoggingMiddleware(logger log.Logger) Middleware {
@@ -28,25 +29,81 @@ func (s *loggingMiddleware) Save(ctx context.Context, reservation pkg.Reservatio
 	return s.Service.Save(ctx, reservation)
 }
 
-func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Reservation, err error) {
+func (s *loggingMiddleware) SaveBatch(ctx context.Context, mode BatchMode, reservations []pkg.Reservation) (results []BatchResult, err error) {
+	defer func(begin time.Time) {
+		saved := 0
+		for _, result := range results {
+			if result.Err == nil {
+				saved++
+			}
+		}
+		s.logger.Log(
+			"method", "save_batch",
+			"mode", mode,
+			"reservations", len(reservations),
+			"saved", saved,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SaveBatch(ctx, mode, reservations)
+}
+
+func (s *loggingMiddleware) ExpandRange(ctx context.Context, booking BookingRange) (_ []pkg.Reservation, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "expand_range",
+			"user_id", booking.UserID,
+			"from", booking.From.Format(dateLayout),
+			"to", booking.To.Format(dateLayout),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.ExpandRange(ctx, booking)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context, filter pkg.SiteFilter) (_ []pkg.Reservation, err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
 			"method", "list",
+			"site_id", filter.SiteID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx, filter)
+}
+
+func (s *loggingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.Reservation, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get",
+			"id", id,
 			"took", time.Since(begin),
 			"err", err)
 	}(time.Now())
-	return s.Service.List(ctx)
+	return s.Service.Get(ctx, id)
 }
 
-func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+func (s *loggingMiddleware) Remove(ctx context.Context, id, version int64) (err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
 			"method", "remove",
 			"id", id,
+			"version", version,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Remove(ctx, id, version)
+}
+
+func (s *loggingMiddleware) CheckIn(ctx context.Context, id int64) (_ *pkg.Reservation, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "check_in",
+			"id", id,
 			"took", time.Since(begin),
 			"err", err)
 	}(time.Now())
-	return s.Service.Remove(ctx, id)
+	return s.Service.CheckIn(ctx, id)
 }
 
 func (s *loggingMiddleware) Update(ctx context.Context, reservation pkg.Reservation) (_ *pkg.Reservation, _ bool, err error) {
@@ -59,3 +116,85 @@ func (s *loggingMiddleware) Update(ctx context.Context, reservation pkg.Reservat
 	}(time.Now())
 	return s.Service.Update(ctx, reservation)
 }
+
+func (s *loggingMiddleware) Headcount(ctx context.Context, from, to time.Time, groupBy pkg.HeadcountGrouping, filter pkg.SiteFilter) (list []pkg.Headcount, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "headcount",
+			"from", from,
+			"to", to,
+			"by_meal", groupBy.Meal,
+			"by_department", groupBy.Department,
+			"by_site", groupBy.Site,
+			"site_id", filter.SiteID,
+			"rows", len(list),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Headcount(ctx, from, to, groupBy, filter)
+}
+
+func (s *loggingMiddleware) WatchHeadcount(ctx context.Context, date time.Time, lastEventID int64, filter pkg.SiteFilter) (_ <-chan HeadcountUpdate, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "watch_headcount",
+			"date", date,
+			"last_event_id", lastEventID,
+			"site_id", filter.SiteID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.WatchHeadcount(ctx, date, lastEventID, filter)
+}
+
+func (s *loggingMiddleware) Menu(ctx context.Context, reservation pkg.Reservation) (_ *pkg.Menu, conflicts []pkg.DishConflict, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "menu",
+			"reservation_time", reservation.ReservationTime,
+			"type", reservation.MealTypeID,
+			"conflicts", len(conflicts),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Menu(ctx, reservation)
+}
+
+func (s *loggingMiddleware) RegenerateFeed(ctx context.Context, userID int64) (_ string, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "regenerate_feed",
+			"user_id", userID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RegenerateFeed(ctx, userID)
+}
+
+func (s *loggingMiddleware) RevokeFeed(ctx context.Context, userID int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "revoke_feed",
+			"user_id", userID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.RevokeFeed(ctx, userID)
+}
+
+// Feed leaves the token out of the logs, as it is all it takes to read the
+// feed.
+func (s *loggingMiddleware) Feed(ctx context.Context, token string) (calendar *ical.Calendar, err error) {
+	defer func(begin time.Time) {
+		events := 0
+		if calendar != nil {
+			events = len(calendar.Events)
+		}
+		s.logger.Log(
+			"method", "feed",
+			"events", events,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Feed(ctx, token)
+}
@@ -2,65 +2,394 @@ package reservations
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/ical"
 )
 
 type Service interface {
 	Save(context.Context, pkg.Reservation) (*pkg.Reservation, error)
-	List(context.Context) ([]pkg.Reservation, error)
+	// SaveBatch books several reservations at once, checking each the way
+	// Save does and also against the booking cutoff, which is the start of
+	// its meal, and the capacity of its meal. There is a result for every
+	// reservation, in order. All or nothing batches are stored in a single
+	// transaction.
+	SaveBatch(context.Context, BatchMode, []pkg.Reservation) ([]BatchResult, error)
+	// ExpandRange turns a booking range into a reservation for every meal
+	// and day of it the meal is served on, at the start of the meal. Meals
+	// that have already started are left out.
+	ExpandRange(context.Context, BookingRange) ([]pkg.Reservation, error)
+	// List returns the reservations at the sites filter allows.
+	List(context.Context, pkg.SiteFilter) ([]pkg.Reservation, error)
+	Get(context.Context, int64) (*pkg.Reservation, error)
 	Update(context.Context, pkg.Reservation) (*pkg.Reservation, bool, error)
-	Remove(context.Context, int64) error
+	Remove(ctx context.Context, id, version int64) error
+	// CheckIn marks the user of a reservation as having attended the meal.
+	CheckIn(context.Context, int64) (*pkg.Reservation, error)
+	// Headcount returns the booked plates for each service date from from to
+	// to, both inclusive, at the sites filter allows, broken down by groupBy.
+	Headcount(ctx context.Context, from, to time.Time, groupBy pkg.HeadcountGrouping, filter pkg.SiteFilter) ([]pkg.Headcount, error)
+	// WatchHeadcount sends the headcount of date, by meal and site, every
+	// time a reservation for it changes, until ctx is done or the watcher
+	// falls behind. It starts with the updates since lastEventID if those are
+	// still known, and with the current headcount otherwise. Only the sites
+	// filter allows are counted.
+	WatchHeadcount(ctx context.Context, date time.Time, lastEventID int64, filter pkg.SiteFilter) (<-chan HeadcountUpdate, error)
+	// PublishHeadcount tells the watchers of the service dates that their
+	// reservations changed, for changes made outside of this service.
+	PublishHeadcount(ctx context.Context, dates ...time.Time)
+	// Menu returns the published menu of the meal a reservation is for, or
+	// pkg.ErrMenuNotFound, along with the dishes that clash with the
+	// allergens of whoever eats it.
+	Menu(context.Context, pkg.Reservation) (*pkg.Menu, []pkg.DishConflict, error)
+	// RegenerateFeed gives the user a new calendar feed token, which stops
+	// any earlier one from working. The token is not stored, so it can only
+	// be shown to the user now.
+	RegenerateFeed(ctx context.Context, userID int64) (string, error)
+	RevokeFeed(ctx context.Context, userID int64) error
+	// Feed renders the upcoming reservations of whoever the feed token
+	// belongs to, or fails with pkg.ErrFeedNotFound.
+	Feed(ctx context.Context, token string) (*ical.Calendar, error)
 }
 
+var ErrInvalidHeadcountRange = errors.New("headcount range ends before it starts")
+
 // Middleware describes a Service Middleware
 type Middleware func(Service) Service
 
 type service struct {
 	repository pkg.ReservationRepository
+	users      pkg.UserRepository
+	mealTypes  pkg.MealTypeRepository
+	closures   pkg.ClosureRepository
+	menus      pkg.MenuRepository
+	payments   pkg.Payments
+	feeds      pkg.CalendarFeedRepository
+	sites      pkg.SiteRepository
+	headcounts *broker
+	logger     log.Logger
 }
 
-func NewService(repository pkg.ReservationRepository) Service {
-	return &service{repository: repository}
+func NewService(repository pkg.ReservationRepository, users pkg.UserRepository, mealTypes pkg.MealTypeRepository, closures pkg.ClosureRepository, menus pkg.MenuRepository, payments pkg.Payments, feeds pkg.CalendarFeedRepository, sites pkg.SiteRepository, logger log.Logger) Service {
+	return &service{repository: repository, users: users, mealTypes: mealTypes, closures: closures, menus: menus, payments: payments, feeds: feeds, sites: sites, headcounts: newBroker(), logger: logger}
 }
 
 func (s *service) Save(ctx context.Context, reservation pkg.Reservation) (*pkg.Reservation, error) {
-	if err := s.repository.Insert(ctx, &reservation); err != nil {
+	if err := s.place(ctx, &reservation); err != nil {
+		return nil, err
+	}
+	if err := s.checkMealType(ctx, reservation); err != nil {
+		return nil, err
+	}
+	if err := s.checkClosures(ctx, reservation); err != nil {
+		return nil, err
+	}
+	hold, err := s.payments.Quote(ctx, reservation)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.Insert(ctx, &reservation, hold); err != nil {
+		if errors.Is(err, pkg.ErrReservationAlreadyExists) || err == pkg.ErrInsufficientFunds {
+			return nil, err
+		}
 		return nil, fmt.Errorf("could not save reservation: %v", err)
 	}
+	s.PublishHeadcount(ctx, reservation.ServiceDate)
 	return &reservation, nil
 }
 
-func (s service) List(ctx context.Context) ([]pkg.Reservation, error) {
+func (s service) List(ctx context.Context, filter pkg.SiteFilter) ([]pkg.Reservation, error) {
+	sites, err := s.resolveSites(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 	list, err := s.repository.FindAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not list all reservations: %v", err)
 	}
-	return list, nil
+	if sites == nil {
+		return list, nil
+	}
+	var scoped []pkg.Reservation
+	for _, reservation := range list {
+		if pkg.HasSite(sites, reservation.SiteID) {
+			scoped = append(scoped, reservation)
+		}
+	}
+	return scoped, nil
+}
+
+func (s *service) Get(ctx context.Context, id int64) (*pkg.Reservation, error) {
+	reservation, err := s.repository.FindByID(ctx, id)
+	if err == pkg.ErrReservationNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find reservation: %v", err)
+	}
+	return reservation, nil
 }
 
 func (s *service) Update(ctx context.Context, reservation pkg.Reservation) (*pkg.Reservation, bool, error) {
-	err := s.repository.Update(ctx, &reservation)
+	if err := s.place(ctx, &reservation); err != nil {
+		return nil, false, err
+	}
+	if err := s.checkMealType(ctx, reservation); err != nil {
+		return nil, false, err
+	}
+	if err := s.checkClosures(ctx, reservation); err != nil {
+		return nil, false, err
+	}
+
+	previous, err := s.repository.FindByID(ctx, reservation.ID)
+	if err != nil && err != pkg.ErrReservationNotFound {
+		return nil, false, fmt.Errorf("could not find reservation: %v", err)
+	}
+
+	// the new cost is held along with the change, so a user who cannot
+	// afford it keeps the reservation and the hold they had.
+	hold, err := s.payments.Quote(ctx, reservation)
+	if err != nil {
+		return nil, false, err
+	}
+	err = s.repository.Update(ctx, &reservation, hold)
+	if err == pkg.ErrReservationNotFound && reservation.Version != 0 {
+		return nil, false, pkg.ErrReservationModified
+	}
 	if err == pkg.ErrReservationNotFound {
-		err = s.repository.Insert(ctx, &reservation)
+		err = s.repository.Insert(ctx, &reservation, hold)
+		if errors.Is(err, pkg.ErrReservationAlreadyExists) || err == pkg.ErrInsufficientFunds {
+			return nil, false, err
+		}
 		if err != nil {
 			return nil, false, fmt.Errorf("could not create reservation: %v", err)
 		}
+		s.PublishHeadcount(ctx, reservation.ServiceDate)
 		return &reservation, true, nil
 	}
+	if err == pkg.ErrReservationModified || err == pkg.ErrInsufficientFunds {
+		return nil, false, err
+	}
 	if err != nil {
 		return nil, false, fmt.Errorf("could not update reservation: %v", err)
 	}
+	changed := []time.Time{reservation.ServiceDate}
+	if previous != nil {
+		changed = append(changed, previous.ServiceDate)
+	}
+	s.PublishHeadcount(ctx, changed...)
 	return &reservation, false, nil
 }
 
-func (s *service) Remove(ctx context.Context, id int64) error {
-	if err := s.repository.DeleteByID(ctx, id); err != nil {
-		if err == pkg.ErrReservationNotFound {
+func (s *service) Remove(ctx context.Context, id, version int64) error {
+	reservation, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.DeleteByID(ctx, id, version); err != nil {
+		if err == pkg.ErrReservationNotFound || err == pkg.ErrReservationModified {
 			return err
 		}
 		return fmt.Errorf("could not remove reservation: %v", err)
 	}
+	// a hold that fails to be released here is released by SettleHolds.
+	s.payments.Release(ctx, id)
+	s.PublishHeadcount(ctx, reservation.ServiceDate)
 	return nil
 }
+
+func (s *service) CheckIn(ctx context.Context, id int64) (*pkg.Reservation, error) {
+	if err := s.repository.CheckIn(ctx, id, time.Now()); err != nil {
+		if err == pkg.ErrReservationNotFound || err == pkg.ErrReservationNotActive {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not check in reservation: %v", err)
+	}
+	// like a missed meal, an uncaptured hold is captured by SettleHolds.
+	s.payments.Capture(ctx, id)
+	reservation, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.PublishHeadcount(ctx, reservation.ServiceDate)
+	return reservation, nil
+}
+
+func (s *service) Headcount(ctx context.Context, from, to time.Time, groupBy pkg.HeadcountGrouping, filter pkg.SiteFilter) ([]pkg.Headcount, error) {
+	if pkg.Date(to).Before(pkg.Date(from)) {
+		return nil, ErrInvalidHeadcountRange
+	}
+	sites, err := s.resolveSites(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repository.Headcount(ctx, from, to, groupBy, sites)
+	if err != nil {
+		return nil, fmt.Errorf("could not count reservations: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) WatchHeadcount(ctx context.Context, date time.Time, lastEventID int64, filter pkg.SiteFilter) (<-chan HeadcountUpdate, error) {
+	sites, err := s.resolveSites(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	date = pkg.Date(date)
+	sub, missed, caughtUp, lastID := s.headcounts.subscribe(date, lastEventID)
+	if !caughtUp {
+		list, err := s.repository.Headcount(ctx, date, date, headcountGrouping, sites)
+		if err != nil {
+			s.headcounts.unsubscribe(sub)
+			return nil, fmt.Errorf("could not count reservations: %v", err)
+		}
+		// an update published while counting is sent again after this,
+		// which is harmless as every update carries the whole headcount.
+		missed = []HeadcountUpdate{{ID: lastID, Date: date, Headcounts: list}}
+	}
+
+	updates := make(chan HeadcountUpdate)
+	go func() {
+		defer close(updates)
+		defer s.headcounts.unsubscribe(sub)
+		for _, update := range missed {
+			select {
+			case updates <- update.at(sites):
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case update, ok := <-sub.updates:
+				if !ok {
+					return
+				}
+				select {
+				case updates <- update.at(sites):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// PublishHeadcount only logs a headcount that cannot be counted, as the
+// change is made by then. Watchers keep the previous headcount until the
+// next change.
+func (s *service) PublishHeadcount(ctx context.Context, dates ...time.Time) {
+	published := make(map[time.Time]bool)
+	for _, date := range dates {
+		date = pkg.Date(date)
+		if published[date] {
+			continue
+		}
+		published[date] = true
+		err := s.headcounts.update(date, func() ([]pkg.Headcount, error) {
+			return s.repository.Headcount(ctx, date, date, headcountGrouping, nil)
+		})
+		if err != nil {
+			s.logger.Log("method", "publish_headcount", "date", date.Format("2006-01-02"), "err", err)
+		}
+	}
+}
+
+func (s *service) Menu(ctx context.Context, reservation pkg.Reservation) (*pkg.Menu, []pkg.DishConflict, error) {
+	menu, err := s.menus.FindForMeal(ctx, reservation.ServiceDate, reservation.MealTypeID)
+	if err == pkg.ErrMenuNotFound {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find menu: %v", err)
+	}
+	if !menu.Published {
+		return nil, nil, pkg.ErrMenuNotFound
+	}
+
+	user, err := s.users.FindByID(ctx, reservation.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find user: %v", err)
+	}
+	return menu, menu.Conflicts(reservation.DietaryProfileOf(*user)), nil
+}
+
+// place books a reservation that names no site at the site of its user and
+// works out its service date in the time zone of that site.
+func (s *service) place(ctx context.Context, reservation *pkg.Reservation) error {
+	if reservation.SiteID == 0 {
+		user, err := s.users.FindByID(ctx, reservation.UserID)
+		if err != nil {
+			return fmt.Errorf("could not find user: %v", err)
+		}
+		reservation.SiteID = user.SiteID
+	}
+	loc, err := s.siteLocation(ctx, reservation.SiteID)
+	if err != nil {
+		return err
+	}
+	reservation.ServiceDate = pkg.ServiceDate(reservation.ReservationTime, loc)
+	return nil
+}
+
+func (s *service) siteLocation(ctx context.Context, siteID int64) (*time.Location, error) {
+	site, err := s.sites.FindByID(ctx, siteID)
+	if err == pkg.ErrSiteNotFound {
+		return nil, pkg.ErrUnknownSite
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find site: %v", err)
+	}
+	loc, err := site.Location()
+	if err != nil {
+		return nil, fmt.Errorf("could not load time zone of site %d: %v", siteID, err)
+	}
+	return loc, nil
+}
+
+// resolveSites returns the sites filter allows, nil for every site.
+func (s *service) resolveSites(ctx context.Context, filter pkg.SiteFilter) ([]int64, error) {
+	sites, err := pkg.ResolveSites(ctx, s.sites, filter)
+	if err == pkg.ErrSiteForbidden {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find managed sites: %v", err)
+	}
+	return sites, nil
+}
+
+func (s *service) checkMealType(ctx context.Context, reservation pkg.Reservation) error {
+	mealType, err := s.mealTypes.FindByID(ctx, reservation.MealTypeID)
+	if err == pkg.ErrMealTypeNotFound {
+		return pkg.ErrUnknownMealType
+	}
+	if err != nil {
+		return fmt.Errorf("could not find meal type: %v", err)
+	}
+	if !mealType.ServedOn(reservation.ServiceDate.Weekday()) {
+		return pkg.ErrMealTypeNotServed
+	}
+	if !mealType.ServedAt(reservation.SiteID) {
+		return pkg.ErrMealTypeNotAtSite
+	}
+	return nil
+}
+
+func (s *service) checkClosures(ctx context.Context, reservation pkg.Reservation) error {
+	closure, err := s.closures.FindCovering(ctx, reservation.ServiceDate, reservation.MealTypeID)
+	if err == pkg.ErrClosureNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not check closures: %v", err)
+	}
+	return pkg.ErrClosed{ClosureID: closure.ID, Reason: closure.Reason}
+}
@@ -0,0 +1,108 @@
+package reservations
+
+import (
+	"context"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// users only finds the users it holds.
+type users struct {
+	pkg.UserRepository
+	byID map[int64]pkg.User
+}
+
+func (u users) FindByID(_ context.Context, id int64) (*pkg.User, error) {
+	user, ok := u.byID[id]
+	if !ok {
+		return nil, pkg.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// sites only finds the sites it holds.
+type sites struct {
+	pkg.SiteRepository
+	byID map[int64]pkg.Site
+}
+
+func (s sites) FindByID(_ context.Context, id int64) (*pkg.Site, error) {
+	site, ok := s.byID[id]
+	if !ok {
+		return nil, pkg.ErrSiteNotFound
+	}
+	return &site, nil
+}
+
+// TestPlaceAcrossDST books meals around local midnight on the days Berlin
+// (site 2) and New York (site 3) change their clocks in 2026: Berlin on Mar
+// 29 and Oct 25, New York on Mar 8 and Nov 1.
+func TestPlaceAcrossDST(t *testing.T) {
+	s := &service{
+		users: users{byID: map[int64]pkg.User{
+			7: {ID: 7, SiteID: 2},
+			8: {ID: 8, SiteID: 3},
+		}},
+		sites: sites{byID: map[int64]pkg.Site{
+			1: {ID: 1, Timezone: "UTC"},
+			2: {ID: 2, Timezone: "Europe/Berlin"},
+			3: {ID: 3, Timezone: "America/New_York"},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		userID   int64
+		siteID   int64
+		at       time.Time
+		wantSite int64
+		want     time.Time
+	}{
+		{"berlin before spring-forward day", 7, 0, time.Date(2026, 3, 28, 22, 30, 0, 0, time.UTC), 2, time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC)},
+		{"berlin spring-forward day", 7, 0, time.Date(2026, 3, 28, 23, 30, 0, 0, time.UTC), 2, time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"berlin end of spring-forward day", 7, 0, time.Date(2026, 3, 29, 21, 30, 0, 0, time.UTC), 2, time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"berlin after spring-forward day", 7, 0, time.Date(2026, 3, 29, 22, 30, 0, 0, time.UTC), 2, time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC)},
+		{"berlin before fall-back day", 7, 0, time.Date(2026, 10, 24, 21, 30, 0, 0, time.UTC), 2, time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC)},
+		{"berlin fall-back day", 7, 0, time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC), 2, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"berlin end of fall-back day", 7, 0, time.Date(2026, 10, 25, 22, 30, 0, 0, time.UTC), 2, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"berlin after fall-back day", 7, 0, time.Date(2026, 10, 25, 23, 30, 0, 0, time.UTC), 2, time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{"new york before spring-forward day", 8, 0, time.Date(2026, 3, 8, 4, 30, 0, 0, time.UTC), 3, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"new york spring-forward day", 8, 0, time.Date(2026, 3, 8, 5, 30, 0, 0, time.UTC), 3, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"new york end of spring-forward day", 8, 0, time.Date(2026, 3, 9, 3, 30, 0, 0, time.UTC), 3, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"new york after spring-forward day", 8, 0, time.Date(2026, 3, 9, 4, 30, 0, 0, time.UTC), 3, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"new york before fall-back day", 8, 0, time.Date(2026, 11, 1, 3, 30, 0, 0, time.UTC), 3, time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)},
+		{"new york fall-back day", 8, 0, time.Date(2026, 11, 1, 4, 30, 0, 0, time.UTC), 3, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"new york end of fall-back day", 8, 0, time.Date(2026, 11, 2, 4, 30, 0, 0, time.UTC), 3, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"new york after fall-back day", 8, 0, time.Date(2026, 11, 2, 5, 30, 0, 0, time.UTC), 3, time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)},
+		{"booked at another site", 7, 3, time.Date(2026, 3, 29, 2, 30, 0, 0, time.UTC), 3, time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC)},
+		{"booked at a utc site", 8, 1, time.Date(2026, 11, 1, 23, 30, 0, 0, time.UTC), 1, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation := pkg.Reservation{UserID: tt.userID, SiteID: tt.siteID, ReservationTime: tt.at}
+			if err := s.place(context.Background(), &reservation); err != nil {
+				t.Fatalf("place() error = %v", err)
+			}
+			if reservation.SiteID != tt.wantSite {
+				t.Errorf("SiteID = %d, want %d", reservation.SiteID, tt.wantSite)
+			}
+			if !reservation.ServiceDate.Equal(tt.want) {
+				t.Errorf("ServiceDate = %v, want %v", reservation.ServiceDate, tt.want)
+			}
+			if !reservation.ReservationTime.Equal(tt.at) {
+				t.Errorf("ReservationTime = %v, want it left at %v", reservation.ReservationTime, tt.at)
+			}
+		})
+	}
+}
+
+func TestPlaceUnknownSite(t *testing.T) {
+	s := &service{users: users{}, sites: sites{}}
+	reservation := pkg.Reservation{UserID: 7, SiteID: 9, ReservationTime: time.Date(2026, 3, 29, 10, 0, 0, 0, time.UTC)}
+	if err := s.place(context.Background(), &reservation); err != pkg.ErrUnknownSite {
+		t.Errorf("place() error = %v, want %v", err, pkg.ErrUnknownSite)
+	}
+}
@@ -0,0 +1,134 @@
+package reservations
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+const (
+	heartbeatInterval  = 15 * time.Second
+	streamWriteTimeout = 10 * time.Second
+)
+
+// handleHeadcountStream answers GET /resvlist/v1/headcount/stream?date=&site_id=
+// with Server-Sent Events carrying the headcount of date, which defaults to
+// today, whenever it changes. A client sending Last-Event-ID gets the
+// updates it missed, or the current headcount if those are gone. The
+// connection is taken over from the http.Server, whose write timeout would
+// otherwise cut every stream short.
+func (s *server) handleHeadcountStream() http.HandlerFunc {
+	type headcount struct {
+		SiteID     int64 `json:"site_id"`
+		MealTypeID int64 `json:"meal_type_id"`
+		Employees  int64 `json:"employees"`
+		Guests     int64 `json:"guests"`
+		Total      int64 `json:"total"`
+	}
+	type message struct {
+		ServiceDate string      `json:"service_date"`
+		Meals       []headcount `json:"meals"`
+		Total       int64       `json:"total"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := pkg.Date(time.Now())
+		if v := r.URL.Query().Get("date"); v != "" {
+			var err error
+			if date, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		filter, err := siteFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		// an ID this server did not hand out makes the stream start over.
+		lastEventID, _ := strconv.ParseInt(r.Header.Get(lastEventIDKey), 10, 64)
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		updates, err := s.service.WatchHeadcount(ctx, date, lastEventID, filter)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			writeError(w, ErrStreamingUnsupported)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			writeError(w, ErrStreamingUnsupported)
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Time{})
+
+		// the client has nothing more to send, so reading only ends once it
+		// has gone away.
+		go func() {
+			io.Copy(io.Discard, rw.Reader)
+			cancel()
+		}()
+
+		write := func(format string, a ...interface{}) bool {
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			fmt.Fprintf(rw.Writer, format, a...)
+			return rw.Writer.Flush() == nil
+		}
+
+		if !write("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nCache-Control: no-cache\r\nConnection: close\r\n\r\n") {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					// the watch ended, most likely because the client fell
+					// behind. It reconnects and resumes from the last ID.
+					return
+				}
+				msg := message{ServiceDate: update.Date.Format(dateLayout), Meals: make([]headcount, 0, len(update.Headcounts))}
+				for _, v := range update.Headcounts {
+					msg.Meals = append(msg.Meals, headcount{SiteID: v.SiteID, MealTypeID: v.MealTypeID, Employees: v.Employees, Guests: v.Guests, Total: v.Employees + v.Guests})
+					msg.Total += v.Employees + v.Guests
+				}
+				data, _ := json.Marshal(msg)
+				if !write("id: %d\nevent: headcount\ndata: %s\n\n", update.ID, data) {
+					return
+				}
+			case <-heartbeat.C:
+				if !write(": heartbeat\n\n") {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Hijack lets the headcount stream take over the connection.
+func (lrw *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrStreamingUnsupported
+	}
+	return hijacker.Hijack()
+}
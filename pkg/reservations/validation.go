@@ -0,0 +1,222 @@
+package reservations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware(users pkg.UserRepository, mealTypes pkg.MealTypeRepository, allergens pkg.AllergenRepository, sites pkg.SiteRepository) Middleware {
+	return func(s Service) Service { return &validationMiddleware{users, mealTypes, allergens, sites, s} }
+}
+
+type validationMiddleware struct {
+	users     pkg.UserRepository
+	mealTypes pkg.MealTypeRepository
+	allergens pkg.AllergenRepository
+	sites     pkg.SiteRepository
+	Service
+}
+
+func (s *validationMiddleware) Save(ctx context.Context, reservation pkg.Reservation) (*pkg.Reservation, error) {
+	if err := s.validate(ctx, reservation, true); err != nil {
+		return nil, err
+	}
+	return s.Service.Save(ctx, reservation)
+}
+
+// Update lets a reservation whose meal has passed be corrected, as long as
+// its time stays the same.
+func (s *validationMiddleware) Update(ctx context.Context, reservation pkg.Reservation) (*pkg.Reservation, bool, error) {
+	stored, err := s.Service.Get(ctx, reservation.ID)
+	if err != nil && err != pkg.ErrReservationNotFound {
+		return nil, false, err
+	}
+	timeChanged := stored == nil || !stored.ReservationTime.Equal(reservation.ReservationTime)
+	if err := s.validate(ctx, reservation, timeChanged); err != nil {
+		return nil, false, err
+	}
+	return s.Service.Update(ctx, reservation)
+}
+
+func (s *validationMiddleware) SaveBatch(ctx context.Context, mode BatchMode, reservations []pkg.Reservation) ([]BatchResult, error) {
+	var verr pkg.ValidationError
+	if mode != BatchAllOrNothing && mode != BatchBestEffort {
+		verr.Add("mode", "unknown", fmt.Sprintf("mode must be %s or %s", BatchAllOrNothing, BatchBestEffort))
+	}
+	if len(reservations) == 0 {
+		verr.Add("reservations", "required", "there is nothing to book")
+	} else if len(reservations) > maxBatchSize {
+		verr.Add("reservations", "max", fmt.Sprintf("at most %d reservations can be booked at once", maxBatchSize))
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	// an invalid reservation is its own result, and only the valid ones are
+	// passed on, unless the batch is all or nothing.
+	results := make([]BatchResult, len(reservations))
+	var valid []pkg.Reservation
+	var indexes []int
+	for i, reservation := range reservations {
+		err := s.validate(ctx, reservation, true)
+		if _, ok := err.(pkg.ValidationError); ok {
+			results[i].Err = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		valid = append(valid, reservation)
+		indexes = append(indexes, i)
+	}
+	if len(valid) < len(reservations) && mode == BatchAllOrNothing {
+		return aborted(results), nil
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	saved, err := s.Service.SaveBatch(ctx, mode, valid)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range saved {
+		results[indexes[i]] = result
+	}
+	return results, nil
+}
+
+func (s *validationMiddleware) ExpandRange(ctx context.Context, booking BookingRange) ([]pkg.Reservation, error) {
+	var verr pkg.ValidationError
+	if booking.UserID <= 0 {
+		verr.Add("user_id", "required", "user_id is required")
+	} else if _, err := s.users.FindByID(ctx, booking.UserID); err == pkg.ErrUserNotFound {
+		verr.Add("user_id", "not_found", fmt.Sprintf("user %d does not exist", booking.UserID))
+	} else if err != nil {
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+
+	if booking.SiteID < 0 {
+		verr.Add("site_id", "min", "site_id must not be negative")
+	} else if booking.SiteID > 0 {
+		if _, err := s.sites.FindByID(ctx, booking.SiteID); err == pkg.ErrSiteNotFound {
+			verr.Add("site_id", "unknown", fmt.Sprintf("site %d does not exist", booking.SiteID))
+		} else if err != nil {
+			return nil, fmt.Errorf("could not find site: %v", err)
+		}
+	}
+
+	if booking.From.IsZero() {
+		verr.Add("from", "required", "from is required")
+	}
+	if booking.To.IsZero() {
+		verr.Add("to", "required", "to is required")
+	} else if booking.To.Before(booking.From) {
+		verr.Add("to", "before_from", "to must not be before from")
+	} else if !booking.From.IsZero() && booking.To.Sub(booking.From) >= maxRangeDays*24*time.Hour {
+		verr.Add("to", "max", fmt.Sprintf("at most %d days can be booked at once", maxRangeDays))
+	}
+
+	if len(booking.MealTypeIDs) == 0 {
+		verr.Add("types", "required", "types is required")
+	}
+	seen := make(map[int64]bool, len(booking.MealTypeIDs))
+	for i, id := range booking.MealTypeIDs {
+		field := fmt.Sprintf("types[%d]", i)
+		if id <= 0 {
+			verr.Add(field, "invalid", "meal type ids must be positive")
+		} else if seen[id] {
+			verr.Add(field, "duplicate", fmt.Sprintf("meal type %d is listed more than once", id))
+		} else if _, err := s.mealTypes.FindByID(ctx, id); err == pkg.ErrMealTypeNotFound {
+			verr.Add(field, "unknown", fmt.Sprintf("meal type %d does not exist", id))
+		} else if err != nil {
+			return nil, fmt.Errorf("could not find meal type: %v", err)
+		}
+		seen[id] = true
+	}
+
+	if booking.NoOfGuests < 0 {
+		verr.Add("no_of_guests", "min", "no_of_guests must not be negative")
+	}
+
+	if booking.Dietary != nil {
+		known, err := s.allergens.FindAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not list allergens: %v", err)
+		}
+		booking.Dietary.Validate(&verr, "dietary.", known)
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.ExpandRange(ctx, booking)
+}
+
+// validate only rejects a reservation_time in the past if checkPast is set.
+func (s *validationMiddleware) validate(ctx context.Context, reservation pkg.Reservation, checkPast bool) error {
+	var verr pkg.ValidationError
+
+	// the site defaults to that of the user.
+	siteID := reservation.SiteID
+	if reservation.UserID <= 0 {
+		verr.Add("user_id", "required", "user_id is required")
+	} else if user, err := s.users.FindByID(ctx, reservation.UserID); err == pkg.ErrUserNotFound {
+		verr.Add("user_id", "not_found", fmt.Sprintf("user %d does not exist", reservation.UserID))
+	} else if err != nil {
+		return fmt.Errorf("could not find user: %v", err)
+	} else if siteID == 0 {
+		siteID = user.SiteID
+	}
+
+	// the weekday is that of the service date at the site, which is taken
+	// in UTC until the site is known.
+	loc := time.UTC
+	if reservation.SiteID < 0 {
+		verr.Add("site_id", "min", "site_id must not be negative")
+	} else if siteID > 0 {
+		if site, err := s.sites.FindByID(ctx, siteID); err == pkg.ErrSiteNotFound {
+			verr.Add("site_id", "unknown", fmt.Sprintf("site %d does not exist", siteID))
+		} else if err != nil {
+			return fmt.Errorf("could not find site: %v", err)
+		} else if loc, err = site.Location(); err != nil {
+			return fmt.Errorf("could not load time zone of site %d: %v", siteID, err)
+		}
+	}
+	weekday := pkg.ServiceDate(reservation.ReservationTime, loc).Weekday()
+
+	if reservation.ReservationTime.IsZero() {
+		verr.Add("reservation_time", "required", "reservation_time is required")
+	} else if checkPast && reservation.ReservationTime.Before(time.Now()) {
+		verr.Add("reservation_time", "past", "reservation_time must not be in the past")
+	}
+
+	if reservation.MealTypeID <= 0 {
+		verr.Add("type", "required", "type is required")
+	} else if mealType, err := s.mealTypes.FindByID(ctx, reservation.MealTypeID); err == pkg.ErrMealTypeNotFound {
+		verr.Add("type", "unknown", fmt.Sprintf("meal type %d does not exist", reservation.MealTypeID))
+	} else if err != nil {
+		return fmt.Errorf("could not find meal type: %v", err)
+	} else if !reservation.ReservationTime.IsZero() && !mealType.ServedOn(weekday) {
+		verr.Add("type", "not_served", fmt.Sprintf("%s is not served on %s", mealType.Name, weekday))
+	} else if siteID > 0 && !mealType.ServedAt(siteID) {
+		verr.Add("type", "not_at_site", fmt.Sprintf("%s is not served at site %d", mealType.Name, siteID))
+	}
+
+	if reservation.NoOfGuests < 0 {
+		verr.Add("no_of_guests", "min", "no_of_guests must not be negative")
+	}
+
+	if reservation.Dietary != nil {
+		known, err := s.allergens.FindAll(ctx)
+		if err != nil {
+			return fmt.Errorf("could not list allergens: %v", err)
+		}
+		reservation.Dietary.Validate(&verr, "dietary.", known)
+	}
+
+	return verr.Err()
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSiteNotFound      = errors.New("site not found")
+	ErrSiteAlreadyExists = errors.New("site already exists")
+	ErrSiteInUse         = errors.New("site is referenced by users, meal types or reservations")
+	ErrUnknownSite       = errors.New("unknown site")
+	ErrSiteForbidden     = errors.New("site is not managed by the caller")
+	ErrMealTypeNotAtSite = errors.New("meal type is not served at that site")
+)
+
+// DefaultSiteID is the site everything belonged to before there were more.
+const DefaultSiteID = 1
+
+// Site is a mess hall. DefaultCapacity is the number of plates a meal there
+// takes when its meal type sets no capacity of its own. Service dates are
+// days in Timezone; changing it leaves those of reservations already made.
+type Site struct {
+	ID              int64
+	Name            string
+	Timezone        string
+	Address         string
+	DefaultCapacity int64
+	CreatedAt       time.Time
+}
+
+// Location is the time zone the site serves meals in.
+func (s Site) Location() (*time.Location, error) { return time.LoadLocation(s.Timezone) }
+
+type SiteRepository interface {
+	Insert(context.Context, *Site) error
+	FindAll(context.Context) ([]Site, error)
+	FindByID(context.Context, int64) (*Site, error)
+	Update(context.Context, *Site) error
+	DeleteByID(context.Context, int64) error
+	// FindManagers returns the users managing a site.
+	FindManagers(ctx context.Context, siteID int64) ([]int64, error)
+	// SetManagers replaces the managers of a site.
+	SetManagers(ctx context.Context, siteID int64, userIDs []int64) error
+	// FindManagedBy returns the sites a user manages.
+	FindManagedBy(ctx context.Context, userID int64) ([]int64, error)
+}
+
+// ScopeSites returns the sites that a caller managing managed may read when
+// asking for siteID, zero asking for all of them. Nil stands for every site.
+// Callers who manage no site are not limited, while managers are kept to
+// their own sites.
+func ScopeSites(managed []int64, siteID int64) ([]int64, error) {
+	if len(managed) == 0 {
+		if siteID == 0 {
+			return nil, nil
+		}
+		return []int64{siteID}, nil
+	}
+	if siteID == 0 {
+		return managed, nil
+	}
+	for _, id := range managed {
+		if id == siteID {
+			return []int64{siteID}, nil
+		}
+	}
+	return nil, ErrSiteForbidden
+}
+
+// SiteFilter narrows what is read to a site, zero meaning every site, on
+// behalf of the caller ViewerID, zero when not known.
+type SiteFilter struct {
+	SiteID   int64
+	ViewerID int64
+}
+
+// ResolveSites applies ScopeSites to filter, looking up what the viewer
+// manages in sites.
+func ResolveSites(ctx context.Context, sites SiteRepository, filter SiteFilter) ([]int64, error) {
+	var managed []int64
+	if filter.ViewerID != 0 {
+		var err error
+		if managed, err = sites.FindManagedBy(ctx, filter.ViewerID); err != nil {
+			return nil, err
+		}
+	}
+	return ScopeSites(managed, filter.SiteID)
+}
+
+// HasSite reports whether id is among sites, nil holding every site.
+func HasSite(sites []int64, id int64) bool {
+	if sites == nil {
+		return true
+	}
+	for _, site := range sites {
+		if site == id {
+			return true
+		}
+	}
+	return false
+}
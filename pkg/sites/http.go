@@ -0,0 +1,323 @@
+package sites
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSaveSite http.Handler
+	handleSaveSite = s.handleSaveSite()
+	handleSaveSite = httpLoggingMiddleware(logger, "handleSaveSite")(handleSaveSite)
+
+	var handleListSites http.Handler
+	handleListSites = s.handleListSites()
+	handleListSites = httpLoggingMiddleware(logger, "handleListSites")(handleListSites)
+
+	var handleGetSite http.Handler
+	handleGetSite = s.handleGetSite()
+	handleGetSite = httpLoggingMiddleware(logger, "handleGetSite")(handleGetSite)
+
+	var handleUpdateSite http.Handler
+	handleUpdateSite = s.handleUpdateSite()
+	handleUpdateSite = httpLoggingMiddleware(logger, "handleUpdateSite")(handleUpdateSite)
+
+	var handleRemoveSite http.Handler
+	handleRemoveSite = s.handleRemoveSite()
+	handleRemoveSite = httpLoggingMiddleware(logger, "handleRemoveSite")(handleRemoveSite)
+
+	var handleGetManagers http.Handler
+	handleGetManagers = s.handleGetManagers()
+	handleGetManagers = httpLoggingMiddleware(logger, "handleGetManagers")(handleGetManagers)
+
+	var handleSetManagers http.Handler
+	handleSetManagers = s.handleSetManagers()
+	handleSetManagers = httpLoggingMiddleware(logger, "handleSetManagers")(handleSetManagers)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/sites/v1/sites", handleSaveSite)
+	router.Handle("GET", "/sites/v1/sites", handleListSites)
+	router.Handle("GET", "/sites/v1/site/:id", handleGetSite)
+	router.Handle("PUT", "/sites/v1/site/:id", handleUpdateSite)
+	router.Handle("DELETE", "/sites/v1/site/:id", handleRemoveSite)
+	router.Handle("GET", "/sites/v1/site/:id/managers", handleGetManagers)
+	router.Handle("PUT", "/sites/v1/site/:id/managers", handleSetManagers)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrNonNumericSiteID = errors.New("site id in path must be numberic")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+type siteRequest struct {
+	Name            string `json:"name"`
+	Timezone        string `json:"timezone"`
+	Address         string `json:"address"`
+	DefaultCapacity int64  `json:"default_capacity"`
+}
+
+func (req siteRequest) site(id int64) pkg.Site {
+	return pkg.Site{ID: id, Name: req.Name, Timezone: req.Timezone, Address: req.Address, DefaultCapacity: req.DefaultCapacity}
+}
+
+type siteResponse struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Timezone        string    `json:"timezone"`
+	Address         string    `json:"address"`
+	DefaultCapacity int64     `json:"default_capacity"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func newSiteResponse(site pkg.Site) siteResponse {
+	return siteResponse{ID: site.ID, Name: site.Name, Timezone: site.Timezone, Address: site.Address, DefaultCapacity: site.DefaultCapacity, CreatedAt: site.CreatedAt}
+}
+
+type managersResponse struct {
+	UserIDs []int64 `json:"user_ids"`
+}
+
+func newManagersResponse(userIDs []int64) managersResponse {
+	if userIDs == nil {
+		userIDs = []int64{}
+	}
+	return managersResponse{UserIDs: userIDs}
+}
+
+func (s *server) handleSaveSite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req siteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		site, err := s.service.Save(r.Context(), req.site(0))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newSiteResponse(*site))
+	}
+}
+
+func (s *server) handleListSites() http.HandlerFunc {
+	type response []siteResponse
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.List(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make(response, 0, len(list))
+		for _, v := range list {
+			resp = append(resp, newSiteResponse(v))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleGetSite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericSiteID)
+			return
+		}
+
+		site, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newSiteResponse(*site))
+	}
+}
+
+func (s *server) handleUpdateSite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericSiteID)
+			return
+		}
+
+		var req siteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		site, err := s.service.Update(r.Context(), req.site(id))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newSiteResponse(*site))
+	}
+}
+
+func (s *server) handleRemoveSite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericSiteID)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleGetManagers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericSiteID)
+			return
+		}
+
+		managers, err := s.service.Managers(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newManagersResponse(managers))
+	}
+}
+
+func (s *server) handleSetManagers() http.HandlerFunc {
+	type request struct {
+		UserIDs []int64 `json:"user_ids"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericSiteID)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		managers, err := s.service.SetManagers(r.Context(), id, req.UserIDs)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newManagersResponse(managers))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrSiteNotFound, pkg.ErrUserNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrSiteAlreadyExists, pkg.ErrSiteInUse:
+		w.WriteHeader(http.StatusConflict)
+	case ErrNonNumericSiteID:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
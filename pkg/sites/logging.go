@@ -0,0 +1,95 @@
+package sites
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Save(ctx context.Context, site pkg.Site) (_ *pkg.Site, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "save",
+			"name", site.Name,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Save(ctx, site)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Site, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list",
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx)
+}
+
+func (s *loggingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.Site, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Get(ctx, id)
+}
+
+func (s *loggingMiddleware) Update(ctx context.Context, site pkg.Site) (_ *pkg.Site, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "update",
+			"id", site.ID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Update(ctx, site)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "remove",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Remove(ctx, id)
+}
+
+func (s *loggingMiddleware) Managers(ctx context.Context, id int64) (_ []int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "managers",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Managers(ctx, id)
+}
+
+func (s *loggingMiddleware) SetManagers(ctx context.Context, id int64, userIDs []int64) (_ []int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "set_managers",
+			"id", id,
+			"managers", len(userIDs),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SetManagers(ctx, id, userIDs)
+}
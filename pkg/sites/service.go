@@ -0,0 +1,104 @@
+// Package sites manages the mess halls meals are served at and who runs
+// them.
+package sites
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	Save(context.Context, pkg.Site) (*pkg.Site, error)
+	List(context.Context) ([]pkg.Site, error)
+	Get(context.Context, int64) (*pkg.Site, error)
+	Update(context.Context, pkg.Site) (*pkg.Site, error)
+	// Remove deletes a site nothing refers to anymore.
+	Remove(context.Context, int64) error
+	Managers(ctx context.Context, id int64) ([]int64, error)
+	// SetManagers replaces the managers of a site, who from then on only see
+	// the reservations, headcounts and reports of the sites they manage.
+	SetManagers(ctx context.Context, id int64, userIDs []int64) ([]int64, error)
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository pkg.SiteRepository
+}
+
+func NewService(repository pkg.SiteRepository) Service {
+	return &service{repository: repository}
+}
+
+func (s *service) Save(ctx context.Context, site pkg.Site) (*pkg.Site, error) {
+	if err := s.repository.Insert(ctx, &site); err != nil {
+		if err == pkg.ErrSiteAlreadyExists {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not save site: %v", err)
+	}
+	return s.Get(ctx, site.ID)
+}
+
+func (s *service) List(ctx context.Context) ([]pkg.Site, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all sites: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Get(ctx context.Context, id int64) (*pkg.Site, error) {
+	site, err := s.repository.FindByID(ctx, id)
+	if err == pkg.ErrSiteNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find site: %v", err)
+	}
+	return site, nil
+}
+
+func (s *service) Update(ctx context.Context, site pkg.Site) (*pkg.Site, error) {
+	if err := s.repository.Update(ctx, &site); err != nil {
+		if err == pkg.ErrSiteNotFound || err == pkg.ErrSiteAlreadyExists {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not update site: %v", err)
+	}
+	return s.Get(ctx, site.ID)
+}
+
+func (s *service) Remove(ctx context.Context, id int64) error {
+	if err := s.repository.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrSiteNotFound || err == pkg.ErrSiteInUse {
+			return err
+		}
+		return fmt.Errorf("could not remove site: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Managers(ctx context.Context, id int64) ([]int64, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	managers, err := s.repository.FindManagers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not list site managers: %v", err)
+	}
+	return managers, nil
+}
+
+func (s *service) SetManagers(ctx context.Context, id int64, userIDs []int64) ([]int64, error) {
+	if err := s.repository.SetManagers(ctx, id, userIDs); err != nil {
+		if err == pkg.ErrSiteNotFound || err == pkg.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not set site managers: %v", err)
+	}
+	return s.Managers(ctx, id)
+}
@@ -0,0 +1,70 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+const maxNameLength = 128
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) Save(ctx context.Context, site pkg.Site) (*pkg.Site, error) {
+	if err := validate(site); err != nil {
+		return nil, err
+	}
+	return s.Service.Save(ctx, site)
+}
+
+func (s *validationMiddleware) Update(ctx context.Context, site pkg.Site) (*pkg.Site, error) {
+	if err := validate(site); err != nil {
+		return nil, err
+	}
+	return s.Service.Update(ctx, site)
+}
+
+func (s *validationMiddleware) SetManagers(ctx context.Context, id int64, userIDs []int64) ([]int64, error) {
+	var verr pkg.ValidationError
+	seen := make(map[int64]bool, len(userIDs))
+	for i, userID := range userIDs {
+		field := fmt.Sprintf("user_ids[%d]", i)
+		if userID <= 0 {
+			verr.Add(field, "invalid", "user ids must be positive")
+		} else if seen[userID] {
+			verr.Add(field, "duplicate", fmt.Sprintf("user %d is listed more than once", userID))
+		}
+		seen[userID] = true
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.SetManagers(ctx, id, userIDs)
+}
+
+func validate(site pkg.Site) error {
+	var verr pkg.ValidationError
+	if strings.TrimSpace(site.Name) == "" {
+		verr.Add("name", "required", "name is required")
+	} else if len(site.Name) > maxNameLength {
+		verr.Add("name", "max", fmt.Sprintf("name must be at most %d characters", maxNameLength))
+	}
+	if site.Timezone == "" {
+		verr.Add("timezone", "required", "timezone is required")
+	} else if _, err := time.LoadLocation(site.Timezone); err != nil {
+		verr.Add("timezone", "unknown", fmt.Sprintf("%q is not a known time zone", site.Timezone))
+	}
+	if site.DefaultCapacity < 0 {
+		verr.Add("default_capacity", "min", "default_capacity must not be negative")
+	}
+	return verr.Err()
+}
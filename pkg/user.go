@@ -9,6 +9,7 @@ import (
 var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrUserModified      = errors.New("user was modified concurrently")
 )
 
 type User struct {
@@ -16,8 +17,15 @@ type User struct {
 	Name        string
 	Password    string
 	Designation string
+	Department  string
 	EmployeeID  string
-	CreatedAt   time.Time
+	// SiteID is where the user eats unless a booking says otherwise.
+	SiteID    int64
+	Dietary   DietaryProfile
+	CreatedAt time.Time
+	// Version is bumped on every update. When passed to Update or
+	// DeleteByID a non-zero Version must match the stored one.
+	Version int64
 }
 
 type UserRepository interface {
@@ -25,5 +33,5 @@ type UserRepository interface {
 	FindAll(context.Context) ([]User, error)
 	FindByID(context.Context, int64) (*User, error)
 	Update(context.Context, *User) error
-	DeleteByID(context.Context, int64) error
+	DeleteByID(ctx context.Context, id, version int64) error
 }
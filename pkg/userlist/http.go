@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/markhaur/messapp-backend/pkg/mergepatch"
 	"github.com/matryer/way"
 )
 
@@ -24,6 +28,10 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 	handleListUsers = s.handleListUsers()
 	handleListUsers = httpLoggingMiddleware(logger, "handleListUsers")(handleListUsers)
 
+	var handleGetUser http.Handler
+	handleGetUser = s.handleGetUser()
+	handleGetUser = httpLoggingMiddleware(logger, "handleGetUser")(handleGetUser)
+
 	var handleRemoveUser http.Handler
 	handleRemoveUser = s.handleRemoveUser()
 	handleRemoveUser = httpLoggingMiddleware(logger, "handleRemoveUser")(handleRemoveUser)
@@ -32,12 +40,28 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 	handleUpdateUser = s.handleUpdateUser()
 	handleUpdateUser = httpLoggingMiddleware(logger, "handleUpdateUser")(handleUpdateUser)
 
+	var handlePatchUser http.Handler
+	handlePatchUser = s.handlePatchUser()
+	handlePatchUser = httpLoggingMiddleware(logger, "handlePatchUser")(handlePatchUser)
+
+	var handleGetDietaryProfile http.Handler
+	handleGetDietaryProfile = s.handleGetDietaryProfile()
+	handleGetDietaryProfile = httpLoggingMiddleware(logger, "handleGetDietaryProfile")(handleGetDietaryProfile)
+
+	var handleUpdateDietaryProfile http.Handler
+	handleUpdateDietaryProfile = s.handleUpdateDietaryProfile()
+	handleUpdateDietaryProfile = httpLoggingMiddleware(logger, "handleUpdateDietaryProfile")(handleUpdateDietaryProfile)
+
 	router := way.NewRouter()
 
 	router.Handle("POST", "/userlist/v1/users", handleSaveUser)
 	router.Handle("GET", "/userlist/v1/users", handleListUsers)
+	router.Handle("GET", "/userlist/v1/user/:id", handleGetUser)
 	router.Handle("DELETE", "/userlist/v1/user/:id", handleRemoveUser)
 	router.Handle("PUT", "/userlist/v1/user/:id", handleUpdateUser)
+	router.Handle("PATCH", "/userlist/v1/user/:id", handlePatchUser)
+	router.Handle("GET", "/userlist/v1/user/:id/dietary-profile", handleGetDietaryProfile)
+	router.Handle("PUT", "/userlist/v1/user/:id/dietary-profile", handleUpdateDietaryProfile)
 
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
 
@@ -47,12 +71,22 @@ func NewServer(service Service, logger log.Logger) http.Handler {
 const (
 	contentTypeKey   = "Content-Type"
 	contentTypeValue = "application/json; charset=utf-8"
+	etagKey          = "ETag"
+	ifMatchKey       = "If-Match"
+	// userIDKey carries the ID of the calling user. The service does no
+	// authentication of its own and trusts the gateway in front of it to
+	// set this header.
+	userIDKey = "X-User-ID"
 )
 
 var (
-	ErrNonNumericUserID = errors.New("user id in path must be numberic")
-	ErrResourceNotFound = errors.New("resource not found")
-	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrNonNumericUserID     = errors.New("user id in path must be numberic")
+	ErrResourceNotFound     = errors.New("resource not found")
+	ErrMethodNotAllowed     = errors.New("method not allowed")
+	ErrUnsupportedMediaType = fmt.Errorf("content type must be %s", mergepatch.ContentType)
+	ErrInvalidQuery         = errors.New("invalid query parameter")
+	ErrMissingUserID        = fmt.Errorf("%s header is required", userIDKey)
+	ErrNotProfileOwner      = errors.New("users can only change their own dietary profile")
 )
 
 type ErrInvalidRequestBody struct{ err error }
@@ -63,19 +97,49 @@ type server struct {
 	service Service
 }
 
+type userResponse struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Designation string    `json:"designation"`
+	Department  string    `json:"department"`
+	EmployeeID  string    `json:"employeeID"`
+	SiteID      int64     `json:"site_id"`
+	Diet        string    `json:"diet"`
+	Allergens   []string  `json:"allergens"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Version     int64     `json:"version"`
+}
+
+func newUserResponse(user pkg.User) userResponse {
+	return userResponse{ID: user.ID, Name: user.Name, Designation: user.Designation, Department: user.Department, EmployeeID: user.EmployeeID, SiteID: user.SiteID, Diet: string(user.Dietary.Diet), Allergens: allergenCodes(user.Dietary), CreatedAt: user.CreatedAt, Version: user.Version}
+}
+
+// dietaryProfile builds a profile from request fields; no diet means an
+// omnivore and an unknown one is left for validation to reject.
+func dietaryProfile(diet string, allergens []string) pkg.DietaryProfile {
+	if diet == "" {
+		diet = string(pkg.DietOmnivore)
+	}
+	return pkg.DietaryProfile{Diet: pkg.Diet(diet), Allergens: allergens}
+}
+
+func allergenCodes(profile pkg.DietaryProfile) []string {
+	if profile.Allergens == nil {
+		return []string{}
+	}
+	return profile.Allergens
+}
+
 func (s *server) handleSaveUser() http.HandlerFunc {
 	type request struct {
-		Name        string `json:"name"`
-		Password    string `json:"password"`
-		Designation string `json:"designation"`
-		EmployeeID  string `json:"employeeid"`
-	}
-	type response struct {
-		ID          int64     `json:"id"`
-		Name        string    `json:"name"`
-		Designation string    `json:"designation"`
-		EmployeeID  string    `json:"employeeID"`
-		CreatedAt   time.Time `json:"createdAt"`
+		Name        string   `json:"name"`
+		Password    string   `json:"password"`
+		Designation string   `json:"designation"`
+		Department  string   `json:"department"`
+		EmployeeID  string   `json:"employeeid"`
+		SiteID      int64    `json:"site_id"`
+		Diet        string   `json:"diet"`
+		Allergens   []string `json:"allergens"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -85,13 +149,12 @@ func (s *server) handleSaveUser() http.HandlerFunc {
 			return
 		}
 
-		user, err := s.service.Save(r.Context(), pkg.User{Name: req.Name, Designation: req.Designation, EmployeeID: req.EmployeeID})
+		user, err := s.service.Save(r.Context(), pkg.User{Name: req.Name, Designation: req.Designation, Department: req.Department, EmployeeID: req.EmployeeID, SiteID: req.SiteID, Dietary: dietaryProfile(req.Diet, req.Allergens)})
 		if err != nil {
 			writeError(w, err)
 			return
 		}
-		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: user.ID, Name: user.Name, Designation: user.Designation, EmployeeID: user.EmployeeID, CreatedAt: user.CreatedAt})
+		writeUser(w, http.StatusOK, *user)
 	}
 }
 
@@ -100,13 +163,26 @@ func (s *server) handleListUsers() http.HandlerFunc {
 		ID          int64     `json:"id"`
 		Name        string    `json:"name"`
 		Designation string    `json:"designation"`
+		Department  string    `json:"department"`
 		EmployeeID  string    `json:"employeeID"`
+		SiteID      int64     `json:"site_id"`
+		Diet        string    `json:"diet"`
+		Allergens   []string  `json:"allergens"`
 		CreatedAt   time.Time `json:"createdAt"`
 	}
 	type response []user
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		list, err := s.service.List(r.Context())
+		var siteID int64
+		if v := r.URL.Query().Get("site_id"); v != "" {
+			var err error
+			if siteID, err = strconv.ParseInt(v, 10, 64); err != nil || siteID <= 0 {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		list, err := s.service.List(r.Context(), siteID)
 		if err != nil {
 			writeError(w, err)
 			return
@@ -114,14 +190,14 @@ func (s *server) handleListUsers() http.HandlerFunc {
 
 		resp := make(response, 0, len(list))
 		for _, v := range list {
-			resp = append(resp, user{ID: v.ID, Name: v.Name, Designation: v.Designation, EmployeeID: v.EmployeeID, CreatedAt: v.CreatedAt})
+			resp = append(resp, user{ID: v.ID, Name: v.Name, Designation: v.Designation, Department: v.Department, EmployeeID: v.EmployeeID, SiteID: v.SiteID, Diet: string(v.Dietary.Diet), Allergens: allergenCodes(v.Dietary), CreatedAt: v.CreatedAt})
 		}
 		w.Header().Set(contentTypeKey, contentTypeValue)
 		json.NewEncoder(w).Encode(resp)
 	}
 }
 
-func (s *server) handleRemoveUser() http.HandlerFunc {
+func (s *server) handleGetUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
 		if err != nil {
@@ -129,27 +205,41 @@ func (s *server) handleRemoveUser() http.HandlerFunc {
 			return
 		}
 
-		if err := s.service.Remove(r.Context(), id); err != nil {
+		user, err := s.service.Get(r.Context(), id)
+		if err != nil {
 			writeError(w, err)
 			return
 		}
+		writeUser(w, http.StatusOK, *user)
+	}
+}
+
+func (s *server) handleRemoveUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericUserID)
+			return
+		}
+
+		if err := s.service.Remove(r.Context(), id, ifMatchVersion(r)); err != nil {
+			s.writeUpdateError(w, r, id, err)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
 func (s *server) handleUpdateUser() http.HandlerFunc {
 	type request struct {
-		Name        string `json:"name"`
-		Password    string `json:"password"`
-		Designation string `json:"designation"`
-		EmployeeID  string `json:"employeeid"`
-	}
-	type response struct {
-		ID          int64     `json:"id"`
-		Name        string    `json:"name"`
-		Designation string    `json:"designation"`
-		EmployeeID  string    `json:"employeeID"`
-		CreatedAt   time.Time `json:"createdAt"`
+		Name        string   `json:"name"`
+		Password    string   `json:"password"`
+		Designation string   `json:"designation"`
+		Department  string   `json:"department"`
+		EmployeeID  string   `json:"employeeid"`
+		SiteID      int64    `json:"site_id"`
+		Diet        string   `json:"diet"`
+		Allergens   []string `json:"allergens"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
@@ -164,42 +254,254 @@ func (s *server) handleUpdateUser() http.HandlerFunc {
 			return
 		}
 
-		user, isCreated, err := s.service.Update(r.Context(), pkg.User{ID: id, Name: req.Name, Password: req.Password, Designation: req.Designation, EmployeeID: req.EmployeeID})
+		user, isCreated, err := s.service.Update(r.Context(), pkg.User{ID: id, Name: req.Name, Password: req.Password, Designation: req.Designation, Department: req.Department, EmployeeID: req.EmployeeID, SiteID: req.SiteID, Dietary: dietaryProfile(req.Diet, req.Allergens), Version: ifMatchVersion(r)})
 		if err != nil {
-			writeError(w, err)
+			s.writeUpdateError(w, r, id, err)
 			return
 		}
 
+		status := http.StatusOK
 		if isCreated {
-			w.WriteHeader(http.StatusCreated)
+			status = http.StatusCreated
 		}
+		writeUser(w, status, *user)
+	}
+}
 
-		w.Header().Set(contentTypeKey, contentTypeValue)
-		json.NewEncoder(w).Encode(response{ID: user.ID, Name: user.Name, Designation: user.Designation, EmployeeID: user.EmployeeID})
+func (s *server) handlePatchUser() http.HandlerFunc {
+	type document struct {
+		Name        string   `json:"name"`
+		Password    string   `json:"password"`
+		Designation string   `json:"designation"`
+		Department  string   `json:"department"`
+		EmployeeID  string   `json:"employeeid"`
+		SiteID      int64    `json:"site_id"`
+		Diet        string   `json:"diet"`
+		Allergens   []string `json:"allergens"`
 	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericUserID)
+			return
+		}
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentTypeKey)); mediaType != mergepatch.ContentType {
+			writeError(w, ErrUnsupportedMediaType)
+			return
+		}
+
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		current, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// without If-Match the patch still applies only to the version it
+		// was merged into, so a concurrent write is never silently lost.
+		version := ifMatchVersion(r)
+		if version == 0 {
+			version = current.Version
+		}
+
+		doc, err := json.Marshal(document{Name: current.Name, Password: current.Password, Designation: current.Designation, Department: current.Department, EmployeeID: current.EmployeeID, SiteID: current.SiteID, Diet: string(current.Dietary.Diet), Allergens: allergenCodes(current.Dietary)})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		merged, err := mergepatch.Apply(doc, patch)
+		if err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		var req document
+		if err := json.Unmarshal(merged, &req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		user, _, err := s.service.Update(r.Context(), pkg.User{ID: id, Name: req.Name, Password: req.Password, Designation: req.Designation, Department: req.Department, EmployeeID: req.EmployeeID, SiteID: req.SiteID, Dietary: dietaryProfile(req.Diet, req.Allergens), Version: version})
+		if err != nil {
+			s.writeUpdateError(w, r, id, err)
+			return
+		}
+		writeUser(w, http.StatusOK, *user)
+	}
+}
+
+type dietaryProfileResponse struct {
+	Diet      string   `json:"diet"`
+	Allergens []string `json:"allergens"`
+}
+
+func (s *server) handleGetDietaryProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericUserID)
+			return
+		}
+
+		user, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeDietaryProfile(w, http.StatusOK, *user)
+	}
+}
+
+// handleUpdateDietaryProfile lets a user change what they eat without
+// resending, or being allowed to change, the rest of their record. Only the
+// user themselves can.
+func (s *server) handleUpdateDietaryProfile() http.HandlerFunc {
+	type request struct {
+		Diet      string   `json:"diet"`
+		Allergens []string `json:"allergens"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericUserID)
+			return
+		}
+		userID, ok := callerID(r)
+		if !ok {
+			writeError(w, ErrMissingUserID)
+			return
+		}
+		if userID != id {
+			writeError(w, ErrNotProfileOwner)
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		user, err := s.service.UpdateDietaryProfile(r.Context(), id, ifMatchVersion(r), dietaryProfile(req.Diet, req.Allergens))
+		if err != nil {
+			s.writeUpdateError(w, r, id, err)
+			return
+		}
+		writeDietaryProfile(w, http.StatusOK, *user)
+	}
+}
+
+// callerID returns the user named by the X-User-ID header, if any.
+func callerID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.Header.Get(userIDKey), 10, 64)
+	return id, err == nil && id > 0
+}
+
+func writeDietaryProfile(w http.ResponseWriter, status int, user pkg.User) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.Header().Set(etagKey, etag(user.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(dietaryProfileResponse{Diet: string(user.Dietary.Diet), Allergens: allergenCodes(user.Dietary)})
+}
+
+// writeUpdateError answers a failed precondition with the current
+// representation of the user so the client can retry against it.
+func (s *server) writeUpdateError(w http.ResponseWriter, r *http.Request, id int64, err error) {
+	if err != pkg.ErrUserModified {
+		writeError(w, err)
+		return
+	}
+
+	current, getErr := s.service.Get(r.Context(), id)
+	if getErr != nil {
+		writeError(w, err)
+		return
+	}
+	writeUser(w, http.StatusPreconditionFailed, *current)
+}
+
+func writeUser(w http.ResponseWriter, status int, user pkg.User) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.Header().Set(etagKey, etag(user.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newUserResponse(user))
+}
+
+func etag(version int64) string { return strconv.Quote(strconv.FormatInt(version, 10)) }
+
+// ifMatchVersion returns the version named by a strong If-Match entity tag.
+// Zero means the header was absent or "*", so any version is accepted. A tag
+// this server could not have issued yields -1, which matches no version.
+func ifMatchVersion(r *http.Request) int64 {
+	value := strings.TrimSpace(r.Header.Get(ifMatchKey))
+	if value == "" || value == "*" {
+		return 0
+	}
+	tag, err := strconv.Unquote(value)
+	if err != nil {
+		return -1
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return -1
+	}
+	return version
 }
 
 func writeError(w http.ResponseWriter, err error) {
 	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
 
 	switch err {
 	case ErrResourceNotFound, pkg.ErrUserNotFound:
 		w.WriteHeader(http.StatusNotFound)
+	case ErrMissingUserID:
+		w.WriteHeader(http.StatusUnauthorized)
+	case ErrNotProfileOwner:
+		w.WriteHeader(http.StatusForbidden)
 	case pkg.ErrUserAlreadyExists:
 		w.WriteHeader(http.StatusConflict)
-	case ErrNonNumericUserID:
+	case pkg.ErrUserModified:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	case ErrNonNumericUserID, ErrInvalidQuery, pkg.ErrUnknownSite:
 		w.WriteHeader(http.StatusBadRequest)
 	case ErrMethodNotAllowed:
 		w.WriteHeader(http.StatusMethodNotAllowed)
+	case ErrUnsupportedMediaType:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
 	default:
-		switch err.(type) {
+		switch e := err.(type) {
 		case ErrInvalidRequestBody:
 			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}
-	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
 }
 
 type loggingResponseWriter struct {
@@ -28,25 +28,38 @@ func (s *loggingMiddleware) Save(ctx context.Context, user pkg.User) (_ *pkg.Use
 	return s.Service.Save(ctx, user)
 }
 
-func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.User, err error) {
+func (s *loggingMiddleware) List(ctx context.Context, siteID int64) (_ []pkg.User, err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
 			"method", "list",
+			"site_id", siteID,
 			"took", time.Since(begin),
 			"err", err)
 	}(time.Now())
-	return s.Service.List(ctx)
+	return s.Service.List(ctx, siteID)
 }
 
-func (s *loggingMiddleware) Remove(ctx context.Context, id int64) (err error) {
+func (s *loggingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.User, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Get(ctx, id)
+}
+
+func (s *loggingMiddleware) Remove(ctx context.Context, id, version int64) (err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
 			"method", "remove",
 			"id", id,
+			"version", version,
 			"took", time.Since(begin),
 			"err", err)
 	}(time.Now())
-	return s.Service.Remove(ctx, id)
+	return s.Service.Remove(ctx, id, version)
 }
 
 func (s *loggingMiddleware) Update(ctx context.Context, user pkg.User) (_ *pkg.User, _ bool, err error) {
@@ -59,3 +72,17 @@ func (s *loggingMiddleware) Update(ctx context.Context, user pkg.User) (_ *pkg.U
 	}(time.Now())
 	return s.Service.Update(ctx, user)
 }
+
+func (s *loggingMiddleware) UpdateDietaryProfile(ctx context.Context, id, version int64, profile pkg.DietaryProfile) (_ *pkg.User, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "update_dietary_profile",
+			"id", id,
+			"version", version,
+			"diet", profile.Diet,
+			"allergens", len(profile.Allergens),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.UpdateDietaryProfile(ctx, id, version, profile)
+}
@@ -9,9 +9,14 @@ import (
 
 type Service interface {
 	Save(context.Context, pkg.User) (*pkg.User, error)
-	List(context.Context) ([]pkg.User, error)
+	// List returns the users whose default site is siteID, zero for all.
+	List(ctx context.Context, siteID int64) ([]pkg.User, error)
+	Get(context.Context, int64) (*pkg.User, error)
 	Update(context.Context, pkg.User) (*pkg.User, bool, error)
-	Remove(context.Context, int64) error
+	Remove(ctx context.Context, id, version int64) error
+	// UpdateDietaryProfile replaces just the dietary profile of a user. A
+	// non-zero version must match the stored one.
+	UpdateDietaryProfile(ctx context.Context, id, version int64, profile pkg.DietaryProfile) (*pkg.User, error)
 }
 
 // Middleware describes a Service Middleware
@@ -26,41 +31,104 @@ func NewService(repository pkg.UserRepository) Service {
 }
 
 func (s *service) Save(ctx context.Context, user pkg.User) (*pkg.User, error) {
+	defaultSite(&user)
 	if err := s.repository.Insert(ctx, &user); err != nil {
+		if err == pkg.ErrUnknownSite {
+			return nil, err
+		}
 		return nil, fmt.Errorf("could not save user: %v", err)
 	}
 	return &user, nil
 }
 
-func (s service) List(ctx context.Context) ([]pkg.User, error) {
+func (s service) List(ctx context.Context, siteID int64) ([]pkg.User, error) {
 	list, err := s.repository.FindAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not list all users: %v", err)
 	}
-	return list, nil
+	if siteID == 0 {
+		return list, nil
+	}
+	var atSite []pkg.User
+	for _, user := range list {
+		if user.SiteID == siteID {
+			atSite = append(atSite, user)
+		}
+	}
+	return atSite, nil
+}
+
+func (s *service) Get(ctx context.Context, id int64) (*pkg.User, error) {
+	user, err := s.repository.FindByID(ctx, id)
+	if err == pkg.ErrUserNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	return user, nil
 }
 
 func (s *service) Update(ctx context.Context, user pkg.User) (*pkg.User, bool, error) {
+	defaultSite(&user)
 	err := s.repository.Update(ctx, &user)
+	if err == pkg.ErrUserNotFound && user.Version != 0 {
+		return nil, false, pkg.ErrUserModified
+	}
 	if err == pkg.ErrUserNotFound {
 		err = s.repository.Insert(ctx, &user)
+		if err == pkg.ErrUnknownSite {
+			return nil, false, err
+		}
 		if err != nil {
 			return nil, false, fmt.Errorf("could not create user: %v", err)
 		}
 		return &user, true, nil
 	}
+	if err == pkg.ErrUserModified || err == pkg.ErrUnknownSite {
+		return nil, false, err
+	}
 	if err != nil {
 		return nil, false, fmt.Errorf("could not update user: %v", err)
 	}
 	return &user, false, nil
 }
 
-func (s *service) Remove(ctx context.Context, id int64) error {
-	if err := s.repository.DeleteByID(ctx, id); err != nil {
-		if err == pkg.ErrUserNotFound {
+func (s *service) Remove(ctx context.Context, id, version int64) error {
+	if err := s.repository.DeleteByID(ctx, id, version); err != nil {
+		if err == pkg.ErrUserNotFound || err == pkg.ErrUserModified {
 			return err
 		}
 		return fmt.Errorf("could not remove user: %v", err)
 	}
 	return nil
 }
+
+func (s *service) UpdateDietaryProfile(ctx context.Context, id, version int64, profile pkg.DietaryProfile) (*pkg.User, error) {
+	user, err := s.repository.FindByID(ctx, id)
+	if err == pkg.ErrUserNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+	if version != 0 && version != user.Version {
+		return nil, pkg.ErrUserModified
+	}
+
+	user.Dietary = profile
+	if err := s.repository.Update(ctx, user); err != nil {
+		if err == pkg.ErrUserNotFound || err == pkg.ErrUserModified {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not update dietary profile: %v", err)
+	}
+	return user, nil
+}
+
+// defaultSite puts a user who names no site at the site everyone started at.
+func defaultSite(user *pkg.User) {
+	if user.SiteID == 0 {
+		user.SiteID = pkg.DefaultSiteID
+	}
+}
@@ -0,0 +1,76 @@
+package userlist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware(allergens pkg.AllergenRepository, sites pkg.SiteRepository) Middleware {
+	return func(s Service) Service { return &validationMiddleware{allergens, sites, s} }
+}
+
+type validationMiddleware struct {
+	allergens pkg.AllergenRepository
+	sites     pkg.SiteRepository
+	Service
+}
+
+func (s *validationMiddleware) Save(ctx context.Context, user pkg.User) (*pkg.User, error) {
+	if err := s.validate(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.Service.Save(ctx, user)
+}
+
+func (s *validationMiddleware) Update(ctx context.Context, user pkg.User) (*pkg.User, bool, error) {
+	if err := s.validate(ctx, user); err != nil {
+		return nil, false, err
+	}
+	return s.Service.Update(ctx, user)
+}
+
+func (s *validationMiddleware) UpdateDietaryProfile(ctx context.Context, id, version int64, profile pkg.DietaryProfile) (*pkg.User, error) {
+	var verr pkg.ValidationError
+	if err := s.validateDietaryProfile(ctx, &verr, profile); err != nil {
+		return nil, err
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.UpdateDietaryProfile(ctx, id, version, profile)
+}
+
+func (s *validationMiddleware) validate(ctx context.Context, user pkg.User) error {
+	var verr pkg.ValidationError
+	if strings.TrimSpace(user.Name) == "" {
+		verr.Add("name", "required", "name is required")
+	}
+	if strings.TrimSpace(user.EmployeeID) == "" {
+		verr.Add("employeeid", "required", "employeeid is required")
+	}
+	if user.SiteID < 0 {
+		verr.Add("site_id", "min", "site_id must not be negative")
+	} else if user.SiteID > 0 {
+		if _, err := s.sites.FindByID(ctx, user.SiteID); err == pkg.ErrSiteNotFound {
+			verr.Add("site_id", "unknown", fmt.Sprintf("site %d does not exist", user.SiteID))
+		} else if err != nil {
+			return fmt.Errorf("could not find site: %v", err)
+		}
+	}
+	if err := s.validateDietaryProfile(ctx, &verr, user.Dietary); err != nil {
+		return err
+	}
+	return verr.Err()
+}
+
+func (s *validationMiddleware) validateDietaryProfile(ctx context.Context, verr *pkg.ValidationError, profile pkg.DietaryProfile) error {
+	known, err := s.allergens.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list allergens: %v", err)
+	}
+	profile.Validate(verr, "", known)
+	return nil
+}
@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrWalletAlreadyExists = errors.New("wallet already exists")
+	ErrInsufficientFunds   = errors.New("insufficient funds in wallet")
+	ErrHoldNotFound        = errors.New("no open hold for reservation")
+)
+
+// Wallet is the prepaid credit of a user. Balance is what is available to
+// spend; Held is set aside for booked meals that are not settled yet.
+type Wallet struct {
+	UserID    int64
+	Balance   Money
+	Held      Money
+	CreatedAt time.Time
+}
+
+type TransactionKind string
+
+const (
+	TransactionTopUp      TransactionKind = "topup"
+	TransactionCharge     TransactionKind = "charge"
+	TransactionRefund     TransactionKind = "refund"
+	TransactionAdjustment TransactionKind = "adjustment"
+	TransactionHold       TransactionKind = "hold"
+	TransactionCapture    TransactionKind = "capture"
+	TransactionRelease    TransactionKind = "release"
+)
+
+// PostableKinds are the transactions that can be posted directly; holds are
+// placed and settled through reservations.
+var PostableKinds = []TransactionKind{TransactionTopUp, TransactionCharge, TransactionRefund, TransactionAdjustment}
+
+// Ledger accounts outside of users' wallets.
+const (
+	AccountCash        = "cash"
+	AccountRevenue     = "revenue"
+	AccountAdjustments = "adjustments"
+)
+
+func WalletAccount(userID int64) string { return fmt.Sprintf("wallet:%d", userID) }
+
+func HoldAccount(userID int64) string { return fmt.Sprintf("hold:%d", userID) }
+
+// LedgerTransaction moves Amount for the wallet of UserID. Only adjustments
+// may have a negative Amount, which takes money out of the wallet.
+type LedgerTransaction struct {
+	ID            int64
+	UserID        int64
+	Kind          TransactionKind
+	Amount        Money
+	ReservationID *int64
+	Description   string
+	CreatedAt     time.Time
+}
+
+// LedgerEntry is one leg of a transaction. The entries of a transaction sum
+// to zero and the balance of an account is the sum of its entries.
+type LedgerEntry struct {
+	Account string
+	Amount  Money
+}
+
+// Entries splits the transaction into the legs that record it.
+func (t LedgerTransaction) Entries() []LedgerEntry {
+	from, to := t.accounts()
+	return []LedgerEntry{{Account: from, Amount: -t.Amount}, {Account: to, Amount: t.Amount}}
+}
+
+// accounts names where the money of the transaction comes from and goes.
+func (t LedgerTransaction) accounts() (string, string) {
+	wallet, hold := WalletAccount(t.UserID), HoldAccount(t.UserID)
+	switch t.Kind {
+	case TransactionTopUp:
+		return AccountCash, wallet
+	case TransactionCharge:
+		return wallet, AccountRevenue
+	case TransactionRefund:
+		return AccountRevenue, wallet
+	case TransactionHold:
+		return wallet, hold
+	case TransactionCapture:
+		return hold, AccountRevenue
+	case TransactionRelease:
+		return hold, wallet
+	default:
+		return AccountAdjustments, wallet
+	}
+}
+
+// WalletDelta is how much the transaction changes the available balance.
+func (t LedgerTransaction) WalletDelta() Money {
+	for _, entry := range t.Entries() {
+		if entry.Account == WalletAccount(t.UserID) {
+			return entry.Amount
+		}
+	}
+	return 0
+}
+
+type HoldStatus string
+
+const (
+	HoldOpen     HoldStatus = "held"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+)
+
+// WalletHold sets aside the cost of a reservation until the meal is eaten,
+// missed or cancelled.
+type WalletHold struct {
+	ID            int64
+	UserID        int64
+	ReservationID int64
+	Amount        Money
+	Status        HoldStatus
+	CreatedAt     time.Time
+	SettledAt     *time.Time
+}
+
+// WalletMovement is a transaction as seen from a wallet, with the balance
+// right after it.
+type WalletMovement struct {
+	Transaction LedgerTransaction
+	Amount      Money
+	Balance     Money
+}
+
+type WalletRepository interface {
+	Open(ctx context.Context, userID int64) (*Wallet, error)
+	FindByUser(ctx context.Context, userID int64) (*Wallet, error)
+	// Post records a transaction, failing with ErrInsufficientFunds rather
+	// than letting the wallet's balance go negative.
+	Post(context.Context, *LedgerTransaction) error
+	// Hold places a hold for a reservation, first releasing the open hold it
+	// already has unless that one is for the same amount.
+	Hold(context.Context, *WalletHold) error
+	// Settle captures or releases the open hold of a reservation, or fails
+	// with ErrHoldNotFound.
+	Settle(ctx context.Context, reservationID int64, capture bool) error
+	FindOpenHolds(context.Context) ([]WalletHold, error)
+	// FindHeldReservations returns the reservations for service dates from
+	// first to last, both inclusive, whose cost was held, whether or not the
+	// hold was captured yet.
+	FindHeldReservations(ctx context.Context, first, last time.Time) ([]int64, error)
+	// Movements returns every transaction that changed the balance of the
+	// user's wallet, oldest first.
+	Movements(ctx context.Context, userID int64) ([]WalletMovement, error)
+}
+
+// Payments settles the cost of reservations of users who pay in advance.
+// Users without a wallet are billed through statements, so for them every
+// method does nothing.
+type Payments interface {
+	// Quote returns the hold Hold would place for a reservation without
+	// placing it, nil for a user without a wallet.
+	Quote(context.Context, Reservation) (*WalletHold, error)
+	Hold(context.Context, Reservation) error
+	Capture(ctx context.Context, reservationID int64) error
+	Release(ctx context.Context, reservationID int64) error
+}
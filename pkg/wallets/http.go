@@ -0,0 +1,327 @@
+package wallets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleOpen http.Handler
+	handleOpen = s.handleOpen()
+	handleOpen = httpLoggingMiddleware(logger, "handleOpen")(handleOpen)
+
+	var handleGet http.Handler
+	handleGet = s.handleGet()
+	handleGet = httpLoggingMiddleware(logger, "handleGet")(handleGet)
+
+	var handlePost http.Handler
+	handlePost = s.handlePost()
+	handlePost = httpLoggingMiddleware(logger, "handlePost")(handlePost)
+
+	var handleStatement http.Handler
+	handleStatement = s.handleStatement()
+	handleStatement = httpLoggingMiddleware(logger, "handleStatement")(handleStatement)
+
+	var handleSettleHolds http.Handler
+	handleSettleHolds = s.handleSettleHolds()
+	handleSettleHolds = httpLoggingMiddleware(logger, "handleSettleHolds")(handleSettleHolds)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/wallet/v1/wallets", handleOpen)
+	router.Handle("GET", "/wallet/v1/wallet/:user_id", handleGet)
+	router.Handle("POST", "/wallet/v1/wallet/:user_id/transactions", handlePost)
+	router.Handle("GET", "/wallet/v1/wallet/:user_id/statement", handleStatement)
+	router.Handle("POST", "/wallet/v1/holds/settle", handleSettleHolds)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+	dateLayout       = "2006-01-02"
+)
+
+var (
+	ErrNonNumericID     = errors.New("user_id in path must be numeric")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+// Amounts are integer minor units of the billing currency.
+type walletResponse struct {
+	UserID    int64     `json:"user_id"`
+	Balance   int64     `json:"balance"`
+	Held      int64     `json:"held"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWalletResponse(wallet pkg.Wallet) walletResponse {
+	return walletResponse{UserID: wallet.UserID, Balance: int64(wallet.Balance), Held: int64(wallet.Held), CreatedAt: wallet.CreatedAt}
+}
+
+type transactionResponse struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	Kind          string    `json:"kind"`
+	Amount        int64     `json:"amount"`
+	ReservationID *int64    `json:"reservation_id"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newTransactionResponse(transaction pkg.LedgerTransaction) transactionResponse {
+	return transactionResponse{ID: transaction.ID, UserID: transaction.UserID, Kind: string(transaction.Kind), Amount: int64(transaction.Amount), ReservationID: transaction.ReservationID, Description: transaction.Description, CreatedAt: transaction.CreatedAt}
+}
+
+// movementResponse is a transaction with the amount it added to or, when
+// negative, took from the wallet and the balance right after it.
+type movementResponse struct {
+	transactionResponse
+	Change  int64 `json:"change"`
+	Balance int64 `json:"balance"`
+}
+
+type statementResponse struct {
+	UserID    int64              `json:"user_id"`
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Opening   int64              `json:"opening_balance"`
+	Closing   int64              `json:"closing_balance"`
+	Movements []movementResponse `json:"movements"`
+}
+
+func newStatementResponse(statement Statement) statementResponse {
+	resp := statementResponse{UserID: statement.UserID, From: statement.From.Format(dateLayout), To: statement.To.Format(dateLayout), Opening: int64(statement.Opening), Closing: int64(statement.Closing), Movements: make([]movementResponse, 0, len(statement.Movements))}
+	for _, movement := range statement.Movements {
+		resp.Movements = append(resp.Movements, movementResponse{transactionResponse: newTransactionResponse(movement.Transaction), Change: int64(movement.Amount), Balance: int64(movement.Balance)})
+	}
+	return resp
+}
+
+type holdResponse struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	ReservationID int64      `json:"reservation_id"`
+	Amount        int64      `json:"amount"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SettledAt     *time.Time `json:"settled_at"`
+}
+
+func (s *server) handleOpen() http.HandlerFunc {
+	type request struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		wallet, err := s.service.Open(r.Context(), req.UserID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newWalletResponse(*wallet))
+	}
+}
+
+func (s *server) handleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(way.Param(r.Context(), "user_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		wallet, err := s.service.Get(r.Context(), userID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newWalletResponse(*wallet))
+	}
+}
+
+func (s *server) handlePost() http.HandlerFunc {
+	type request struct {
+		Kind          string `json:"kind"`
+		Amount        int64  `json:"amount"`
+		ReservationID *int64 `json:"reservation_id"`
+		Description   string `json:"description"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(way.Param(r.Context(), "user_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		transaction, err := s.service.Post(r.Context(), pkg.LedgerTransaction{UserID: userID, Kind: pkg.TransactionKind(req.Kind), Amount: pkg.Money(req.Amount), ReservationID: req.ReservationID, Description: req.Description})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newTransactionResponse(*transaction))
+	}
+}
+
+// handleStatement covers ?from= to ?to=, from the first of the current
+// month until today by default.
+func (s *server) handleStatement() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(way.Param(r.Context(), "user_id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		to := pkg.Date(now)
+		query := r.URL.Query()
+		if v := query.Get("from"); v != "" {
+			if from, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+		if v := query.Get("to"); v != "" {
+			if to, err = time.Parse(dateLayout, v); err != nil {
+				writeError(w, ErrInvalidQuery)
+				return
+			}
+		}
+
+		statement, err := s.service.Statement(r.Context(), userID, from, to)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newStatementResponse(*statement))
+	}
+}
+
+func (s *server) handleSettleHolds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settled, err := s.service.SettleHolds(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]holdResponse, 0, len(settled))
+		for _, hold := range settled {
+			resp = append(resp, holdResponse{ID: hold.ID, UserID: hold.UserID, ReservationID: hold.ReservationID, Amount: int64(hold.Amount), Status: string(hold.Status), CreatedAt: hold.CreatedAt, SettledAt: hold.SettledAt})
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrWalletNotFound, pkg.ErrUserNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case pkg.ErrWalletAlreadyExists:
+		w.WriteHeader(http.StatusConflict)
+	case pkg.ErrInsufficientFunds:
+		w.WriteHeader(http.StatusPaymentRequired)
+	case ErrNonNumericID, ErrInvalidQuery, ErrInvalidStatementRange:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
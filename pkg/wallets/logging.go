@@ -0,0 +1,123 @@
+package wallets
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Open(ctx context.Context, userID int64) (_ *pkg.Wallet, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "open",
+			"user_id", userID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Open(ctx, userID)
+}
+
+func (s *loggingMiddleware) Get(ctx context.Context, userID int64) (_ *pkg.Wallet, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get",
+			"user_id", userID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Get(ctx, userID)
+}
+
+func (s *loggingMiddleware) Post(ctx context.Context, transaction pkg.LedgerTransaction) (_ *pkg.LedgerTransaction, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "post",
+			"user_id", transaction.UserID,
+			"kind", transaction.Kind,
+			"amount", transaction.Amount,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Post(ctx, transaction)
+}
+
+func (s *loggingMiddleware) Statement(ctx context.Context, userID int64, from, to time.Time) (_ *Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "statement",
+			"user_id", userID,
+			"from", from,
+			"to", to,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Statement(ctx, userID, from, to)
+}
+
+func (s *loggingMiddleware) SettleHolds(ctx context.Context) (settled []pkg.WalletHold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "settle_holds",
+			"settled", len(settled),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.SettleHolds(ctx)
+}
+
+func (s *loggingMiddleware) Quote(ctx context.Context, reservation pkg.Reservation) (_ *pkg.WalletHold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "quote",
+			"user_id", reservation.UserID,
+			"meal_type_id", reservation.MealTypeID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Quote(ctx, reservation)
+}
+
+func (s *loggingMiddleware) Hold(ctx context.Context, reservation pkg.Reservation) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "hold",
+			"reservation_id", reservation.ID,
+			"user_id", reservation.UserID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Hold(ctx, reservation)
+}
+
+func (s *loggingMiddleware) Capture(ctx context.Context, reservationID int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "capture",
+			"reservation_id", reservationID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Capture(ctx, reservationID)
+}
+
+func (s *loggingMiddleware) Release(ctx context.Context, reservationID int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "release",
+			"reservation_id", reservationID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Release(ctx, reservationID)
+}
@@ -0,0 +1,227 @@
+package wallets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	Open(ctx context.Context, userID int64) (*pkg.Wallet, error)
+	Get(ctx context.Context, userID int64) (*pkg.Wallet, error)
+	Post(context.Context, pkg.LedgerTransaction) (*pkg.LedgerTransaction, error)
+	// Statement returns the movements of a wallet from from to to, both
+	// inclusive, with the balance before and after them.
+	Statement(ctx context.Context, userID int64, from, to time.Time) (*Statement, error)
+	// SettleHolds captures the holds of meals whose day has passed, whether
+	// or not the user turned up, and releases those of reservations that no
+	// longer stand.
+	SettleHolds(context.Context) ([]pkg.WalletHold, error)
+
+	pkg.Payments
+}
+
+var ErrInvalidStatementRange = errors.New("statement range ends before it starts")
+
+// Statement is the activity of a wallet over a range of days.
+type Statement struct {
+	UserID    int64
+	From      time.Time
+	To        time.Time
+	Opening   pkg.Money
+	Closing   pkg.Money
+	Movements []pkg.WalletMovement
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+type service struct {
+	repository   pkg.WalletRepository
+	reservations pkg.ReservationRepository
+	prices       pkg.PriceRepository
+	subsidies    pkg.SubsidyRuleRepository
+	users        pkg.UserRepository
+}
+
+func NewService(repository pkg.WalletRepository, reservations pkg.ReservationRepository, prices pkg.PriceRepository, subsidies pkg.SubsidyRuleRepository, users pkg.UserRepository) Service {
+	return &service{repository: repository, reservations: reservations, prices: prices, subsidies: subsidies, users: users}
+}
+
+func (s *service) Open(ctx context.Context, userID int64) (*pkg.Wallet, error) {
+	wallet, err := s.repository.Open(ctx, userID)
+	if err == pkg.ErrWalletAlreadyExists || err == pkg.ErrUserNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open wallet: %v", err)
+	}
+	return wallet, nil
+}
+
+func (s *service) Get(ctx context.Context, userID int64) (*pkg.Wallet, error) {
+	wallet, err := s.repository.FindByUser(ctx, userID)
+	if err == pkg.ErrWalletNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find wallet: %v", err)
+	}
+	return wallet, nil
+}
+
+func (s *service) Post(ctx context.Context, transaction pkg.LedgerTransaction) (*pkg.LedgerTransaction, error) {
+	if err := s.repository.Post(ctx, &transaction); err != nil {
+		if err == pkg.ErrWalletNotFound || err == pkg.ErrInsufficientFunds {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not post transaction: %v", err)
+	}
+	return &transaction, nil
+}
+
+func (s *service) Statement(ctx context.Context, userID int64, from, to time.Time) (*Statement, error) {
+	from, to = pkg.Date(from), pkg.Date(to)
+	if to.Before(from) {
+		return nil, ErrInvalidStatementRange
+	}
+	if _, err := s.Get(ctx, userID); err != nil {
+		return nil, err
+	}
+	movements, err := s.repository.Movements(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list wallet movements: %v", err)
+	}
+
+	statement := Statement{UserID: userID, From: from, To: to, Movements: []pkg.WalletMovement{}}
+	end := to.AddDate(0, 0, 1)
+	for _, movement := range movements {
+		at := movement.Transaction.CreatedAt
+		if at.Before(from) {
+			statement.Opening = movement.Balance
+			statement.Closing = movement.Balance
+			continue
+		}
+		if !at.Before(end) {
+			break
+		}
+		statement.Movements = append(statement.Movements, movement)
+		statement.Closing = movement.Balance
+	}
+	return &statement, nil
+}
+
+func (s *service) SettleHolds(ctx context.Context) ([]pkg.WalletHold, error) {
+	holds, err := s.repository.FindOpenHolds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list open holds: %v", err)
+	}
+
+	today := pkg.Date(time.Now())
+	var settled []pkg.WalletHold
+	for _, hold := range holds {
+		reservation, err := s.reservations.FindByID(ctx, hold.ReservationID)
+		if err != nil && err != pkg.ErrReservationNotFound {
+			return settled, fmt.Errorf("could not find reservation: %v", err)
+		}
+
+		var capture bool
+		switch {
+		case err == pkg.ErrReservationNotFound || reservation.Status == pkg.ReservationCancelled:
+		case reservation.ServiceDate.Before(today):
+			capture = true
+		default:
+			continue
+		}
+
+		err = s.repository.Settle(ctx, hold.ReservationID, capture)
+		if err == pkg.ErrHoldNotFound {
+			continue
+		}
+		if err != nil {
+			return settled, fmt.Errorf("could not settle hold: %v", err)
+		}
+		hold.Status = pkg.HoldReleased
+		if capture {
+			hold.Status = pkg.HoldCaptured
+		}
+		settled = append(settled, hold)
+	}
+	return settled, nil
+}
+
+func (s *service) Quote(ctx context.Context, reservation pkg.Reservation) (*pkg.WalletHold, error) {
+	if _, err := s.repository.FindByUser(ctx, reservation.UserID); err == pkg.ErrWalletNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not find wallet: %v", err)
+	}
+
+	amount, err := s.cost(ctx, reservation)
+	if err != nil {
+		return nil, err
+	}
+	return &pkg.WalletHold{UserID: reservation.UserID, ReservationID: reservation.ID, Amount: amount}, nil
+}
+
+// Hold sets aside what the user pays for the reservation after subsidy,
+// replacing any hold it already has.
+func (s *service) Hold(ctx context.Context, reservation pkg.Reservation) error {
+	hold, err := s.Quote(ctx, reservation)
+	if hold == nil || err != nil {
+		return err
+	}
+	err = s.repository.Hold(ctx, hold)
+	if err == pkg.ErrInsufficientFunds {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not hold funds: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Capture(ctx context.Context, reservationID int64) error {
+	return s.settle(ctx, reservationID, true)
+}
+
+func (s *service) Release(ctx context.Context, reservationID int64) error {
+	return s.settle(ctx, reservationID, false)
+}
+
+func (s *service) settle(ctx context.Context, reservationID int64, capture bool) error {
+	err := s.repository.Settle(ctx, reservationID, capture)
+	if err == nil || err == pkg.ErrHoldNotFound {
+		return nil
+	}
+	return fmt.Errorf("could not settle hold: %v", err)
+}
+
+// cost prices a reservation the same way its statement line would be.
+func (s *service) cost(ctx context.Context, reservation pkg.Reservation) (pkg.Money, error) {
+	prices, err := s.prices.FindAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list prices: %v", err)
+	}
+	price := pkg.PriceOn(prices, reservation.MealTypeID, reservation.ServiceDate)
+	if price == nil {
+		return 0, pkg.ErrPriceMissing{MealTypeID: reservation.MealTypeID, Date: reservation.ServiceDate}
+	}
+
+	user, err := s.users.FindByID(ctx, reservation.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("could not find user: %v", err)
+	}
+	rules, err := s.subsidies.FindAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list subsidy rules: %v", err)
+	}
+	var percent int64
+	if rule := pkg.SubsidyFor(rules, *user, reservation.ServiceDate); rule != nil {
+		percent = rule.Percent
+	}
+	return pkg.NewStatementLine(reservation, *price, percent).Amount, nil
+}
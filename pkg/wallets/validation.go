@@ -0,0 +1,46 @@
+package wallets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) Post(ctx context.Context, transaction pkg.LedgerTransaction) (*pkg.LedgerTransaction, error) {
+	var verr pkg.ValidationError
+
+	postable := false
+	for _, kind := range pkg.PostableKinds {
+		postable = postable || transaction.Kind == kind
+	}
+	switch {
+	case transaction.Kind == "":
+		verr.Add("kind", "required", "kind is required")
+	case !postable:
+		verr.Add("kind", "unknown", fmt.Sprintf("kind must be one of %v", pkg.PostableKinds))
+	case transaction.Kind == pkg.TransactionAdjustment:
+		if transaction.Amount == 0 {
+			verr.Add("amount", "required", "amount must not be zero")
+		}
+		if strings.TrimSpace(transaction.Description) == "" {
+			verr.Add("description", "required", "description is required for adjustments")
+		}
+	case transaction.Amount <= 0:
+		verr.Add("amount", "min", "amount must be positive")
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.Post(ctx, transaction)
+}
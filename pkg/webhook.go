@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Webhook is a subscription of a URL to some event types. Deliveries to it
+// are signed with Secret.
+type Webhook struct {
+	ID         int64
+	URL        string
+	EventTypes []EventType
+	Secret     string
+	CreatedAt  time.Time
+}
+
+func (w Webhook) Subscribes(eventType EventType) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead is a delivery that failed too often to be retried. It is
+	// only sent again when replayed.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event sent, or still to be sent, to one webhook.
+// ResponseStatus and LastError describe the latest attempt.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        string
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int64
+	NextAttemptAt  time.Time
+	ResponseStatus int64
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+type WebhookRepository interface {
+	Insert(context.Context, *Webhook) error
+	FindAll(context.Context) ([]Webhook, error)
+	FindByID(context.Context, int64) (*Webhook, error)
+	DeleteByID(context.Context, int64) error
+
+	InsertDelivery(context.Context, *WebhookDelivery) error
+	FindDelivery(context.Context, int64) (*WebhookDelivery, error)
+	// FindDeliveries returns the latest deliveries to a webhook, newest
+	// first.
+	FindDeliveries(ctx context.Context, webhookID, limit int64) ([]WebhookDelivery, error)
+	// ClaimDue returns up to limit pending deliveries that are due at now,
+	// each pushed back to leaseUntil so that no other dispatcher picks it up
+	// while it is being sent.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int64) ([]WebhookDelivery, error)
+	UpdateDelivery(context.Context, *WebhookDelivery) error
+}
@@ -0,0 +1,308 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+	"github.com/matryer/way"
+)
+
+func NewServer(service Service, logger log.Logger) http.Handler {
+	s := server{service: service}
+
+	var handleSubscribe http.Handler
+	handleSubscribe = s.handleSubscribe()
+	handleSubscribe = httpLoggingMiddleware(logger, "handleSubscribe")(handleSubscribe)
+
+	var handleList http.Handler
+	handleList = s.handleList()
+	handleList = httpLoggingMiddleware(logger, "handleList")(handleList)
+
+	var handleGet http.Handler
+	handleGet = s.handleGet()
+	handleGet = httpLoggingMiddleware(logger, "handleGet")(handleGet)
+
+	var handleUnsubscribe http.Handler
+	handleUnsubscribe = s.handleUnsubscribe()
+	handleUnsubscribe = httpLoggingMiddleware(logger, "handleUnsubscribe")(handleUnsubscribe)
+
+	var handleDeliveries http.Handler
+	handleDeliveries = s.handleDeliveries()
+	handleDeliveries = httpLoggingMiddleware(logger, "handleDeliveries")(handleDeliveries)
+
+	var handleReplay http.Handler
+	handleReplay = s.handleReplay()
+	handleReplay = httpLoggingMiddleware(logger, "handleReplay")(handleReplay)
+
+	router := way.NewRouter()
+
+	router.Handle("POST", "/webhooks/v1/webhooks", handleSubscribe)
+	router.Handle("GET", "/webhooks/v1/webhooks", handleList)
+	router.Handle("GET", "/webhooks/v1/webhook/:id", handleGet)
+	router.Handle("DELETE", "/webhooks/v1/webhook/:id", handleUnsubscribe)
+	router.Handle("GET", "/webhooks/v1/webhook/:id/deliveries", handleDeliveries)
+	router.Handle("POST", "/webhooks/v1/delivery/:id/replay", handleReplay)
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { writeError(w, ErrResourceNotFound) })
+
+	return router
+}
+
+const (
+	contentTypeKey   = "Content-Type"
+	contentTypeValue = "application/json; charset=utf-8"
+)
+
+var (
+	ErrNonNumericID     = errors.New("id in path must be numeric")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+type ErrInvalidRequestBody struct{ err error }
+
+func (e ErrInvalidRequestBody) Error() string { return fmt.Sprintf("invalid request body: %v", e.err) }
+
+type server struct {
+	service Service
+}
+
+// webhookResponse leaves out the secret, which is only returned when the
+// webhook is created.
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(webhook pkg.Webhook) webhookResponse {
+	resp := webhookResponse{ID: webhook.ID, URL: webhook.URL, EventTypes: make([]string, 0, len(webhook.EventTypes)), CreatedAt: webhook.CreatedAt}
+	for _, t := range webhook.EventTypes {
+		resp.EventTypes = append(resp.EventTypes, string(t))
+	}
+	return resp
+}
+
+// deliveryResponse carries the payload as the JSON it was sent as.
+type deliveryResponse struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int64           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	ResponseStatus int64           `json:"response_status"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func newDeliveryResponse(delivery pkg.WebhookDelivery) deliveryResponse {
+	resp := deliveryResponse{ID: delivery.ID, WebhookID: delivery.WebhookID, EventID: delivery.EventID, EventType: string(delivery.EventType), Payload: delivery.Payload, Status: string(delivery.Status), Attempts: delivery.Attempts, ResponseStatus: delivery.ResponseStatus, LastError: delivery.LastError, CreatedAt: delivery.CreatedAt, DeliveredAt: delivery.DeliveredAt}
+	// only a pending delivery has another attempt coming.
+	if delivery.Status == pkg.DeliveryPending {
+		next := delivery.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
+
+func (s *server) handleSubscribe() http.HandlerFunc {
+	type request struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Secret     string   `json:"secret"`
+	}
+	type response struct {
+		webhookResponse
+		Secret string `json:"secret"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, ErrInvalidRequestBody{err})
+			return
+		}
+
+		webhook := pkg.Webhook{URL: req.URL, Secret: req.Secret}
+		for _, t := range req.EventTypes {
+			webhook.EventTypes = append(webhook.EventTypes, pkg.EventType(t))
+		}
+		created, err := s.service.Subscribe(r.Context(), webhook)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, response{newWebhookResponse(*created), created.Secret})
+	}
+}
+
+func (s *server) handleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.service.List(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]webhookResponse, 0, len(list))
+		for _, webhook := range list {
+			resp = append(resp, newWebhookResponse(webhook))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		webhook, err := s.service.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newWebhookResponse(*webhook))
+	}
+}
+
+func (s *server) handleUnsubscribe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		if err := s.service.Unsubscribe(r.Context(), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) handleDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		list, err := s.service.Deliveries(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := make([]deliveryResponse, 0, len(list))
+		for _, delivery := range list {
+			resp = append(resp, newDeliveryResponse(delivery))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *server) handleReplay() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(way.Param(r.Context(), "id"), 10, 64)
+		if err != nil {
+			writeError(w, ErrNonNumericID)
+			return
+		}
+
+		delivery, err := s.service.Replay(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, newDeliveryResponse(*delivery))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set(contentTypeKey, contentTypeValue)
+	body := map[string]interface{}{"error": err.Error()}
+
+	switch err {
+	case ErrResourceNotFound, pkg.ErrWebhookNotFound, pkg.ErrDeliveryNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case ErrNonNumericID:
+		w.WriteHeader(http.StatusBadRequest)
+	case ErrMethodNotAllowed:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		switch e := err.(type) {
+		case ErrInvalidRequestBody:
+			w.WriteHeader(http.StatusBadRequest)
+		case pkg.ValidationError:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			body["fields"] = fieldErrors(e)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrors(err pkg.ValidationError) []fieldError {
+	fields := make([]fieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, fieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return fields
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func httpLoggingMiddleware(logger log.Logger, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			lrw := &loggingResponseWriter{w, http.StatusOK}
+			next.ServeHTTP(lrw, r)
+			logger.Log(
+				"operation", operation,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"took", time.Since(begin),
+				"status", lrw.statusCode,
+			)
+		})
+	}
+}
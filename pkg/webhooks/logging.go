@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(s Service) Service { return &loggingMiddleware{logger, s} }
+}
+
+type loggingMiddleware struct {
+	logger log.Logger
+	Service
+}
+
+func (s *loggingMiddleware) Subscribe(ctx context.Context, webhook pkg.Webhook) (_ *pkg.Webhook, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "subscribe",
+			"url", webhook.URL,
+			"event_types", len(webhook.EventTypes),
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Subscribe(ctx, webhook)
+}
+
+func (s *loggingMiddleware) List(ctx context.Context) (_ []pkg.Webhook, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list",
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.List(ctx)
+}
+
+func (s *loggingMiddleware) Get(ctx context.Context, id int64) (_ *pkg.Webhook, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "get",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Get(ctx, id)
+}
+
+func (s *loggingMiddleware) Unsubscribe(ctx context.Context, id int64) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "unsubscribe",
+			"id", id,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Unsubscribe(ctx, id)
+}
+
+func (s *loggingMiddleware) Deliveries(ctx context.Context, webhookID int64) (_ []pkg.WebhookDelivery, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "deliveries",
+			"webhook_id", webhookID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Deliveries(ctx, webhookID)
+}
+
+func (s *loggingMiddleware) Replay(ctx context.Context, deliveryID int64) (_ *pkg.WebhookDelivery, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "replay",
+			"delivery_id", deliveryID,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Replay(ctx, deliveryID)
+}
+
+func (s *loggingMiddleware) Dispatch(ctx context.Context) (attempted int, err error) {
+	defer func(begin time.Time) {
+		if attempted == 0 && err == nil {
+			// an idle dispatcher would otherwise log every interval.
+			return
+		}
+		s.logger.Log(
+			"method", "dispatch",
+			"attempted", attempted,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Dispatch(ctx)
+}
+
+func (s *loggingMiddleware) Publish(ctx context.Context, event pkg.Event) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "publish",
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"took", time.Since(begin),
+			"err", err)
+	}(time.Now())
+	return s.Service.Publish(ctx, event)
+}
@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+type Service interface {
+	// Subscribe stores a webhook, making up a secret when it has none. The
+	// secret is only handed out here.
+	Subscribe(context.Context, pkg.Webhook) (*pkg.Webhook, error)
+	List(context.Context) ([]pkg.Webhook, error)
+	Get(context.Context, int64) (*pkg.Webhook, error)
+	Unsubscribe(context.Context, int64) error
+	// Deliveries returns the latest deliveries to a webhook, newest first.
+	Deliveries(ctx context.Context, webhookID int64) ([]pkg.WebhookDelivery, error)
+	// Replay queues the payload of an earlier delivery to be sent again as a
+	// new delivery, whatever became of the original.
+	Replay(ctx context.Context, deliveryID int64) (*pkg.WebhookDelivery, error)
+	// Dispatch makes one attempt at every delivery that is due and returns
+	// how many it attempted.
+	Dispatch(context.Context) (int, error)
+
+	pkg.EventPublisher
+}
+
+// Middleware describes a Service Middleware
+type Middleware func(Service) Service
+
+const (
+	// MaxAttempts is how often a delivery is tried before it is dead.
+	MaxAttempts = 8
+	// FirstRetryDelay is the wait after the first failed attempt. It doubles
+	// with every attempt after that.
+	FirstRetryDelay = 30 * time.Second
+
+	deliveryLogSize = 100
+	dispatchBatch   = 50
+)
+
+type service struct {
+	repository pkg.WebhookRepository
+	client     *http.Client
+}
+
+// NewService sends deliveries with client, whose timeout bounds how long a
+// receiver may take to answer.
+func NewService(repository pkg.WebhookRepository, client *http.Client) Service {
+	return &service{repository: repository, client: client}
+}
+
+func (s *service) Subscribe(ctx context.Context, webhook pkg.Webhook) (*pkg.Webhook, error) {
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("could not generate secret: %v", err)
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	if err := s.repository.Insert(ctx, &webhook); err != nil {
+		return nil, fmt.Errorf("could not save webhook: %v", err)
+	}
+	return &webhook, nil
+}
+
+func (s *service) List(ctx context.Context) ([]pkg.Webhook, error) {
+	list, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all webhooks: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Get(ctx context.Context, id int64) (*pkg.Webhook, error) {
+	webhook, err := s.repository.FindByID(ctx, id)
+	if err == pkg.ErrWebhookNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find webhook: %v", err)
+	}
+	return webhook, nil
+}
+
+func (s *service) Unsubscribe(ctx context.Context, id int64) error {
+	if err := s.repository.DeleteByID(ctx, id); err != nil {
+		if err == pkg.ErrWebhookNotFound {
+			return err
+		}
+		return fmt.Errorf("could not remove webhook: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Deliveries(ctx context.Context, webhookID int64) ([]pkg.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	list, err := s.repository.FindDeliveries(ctx, webhookID, deliveryLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not list deliveries: %v", err)
+	}
+	return list, nil
+}
+
+func (s *service) Replay(ctx context.Context, deliveryID int64) (*pkg.WebhookDelivery, error) {
+	original, err := s.repository.FindDelivery(ctx, deliveryID)
+	if err == pkg.ErrDeliveryNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find delivery: %v", err)
+	}
+
+	replay := pkg.WebhookDelivery{WebhookID: original.WebhookID, EventID: original.EventID, EventType: original.EventType, Payload: original.Payload}
+	if err := s.repository.InsertDelivery(ctx, &replay); err != nil {
+		if err == pkg.ErrWebhookNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not replay delivery: %v", err)
+	}
+	return &replay, nil
+}
+
+// Publish queues a delivery of event to every webhook subscribed to its
+// type. Sending is left to Dispatch.
+func (s *service) Publish(ctx context.Context, event pkg.Event) error {
+	webhooks, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list all webhooks: %v", err)
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("could not encode event: %v", err)
+			}
+		}
+		delivery := pkg.WebhookDelivery{WebhookID: webhook.ID, EventID: event.ID, EventType: event.Type, Payload: payload}
+		if err := s.repository.InsertDelivery(ctx, &delivery); err != nil && err != pkg.ErrWebhookNotFound {
+			return fmt.Errorf("could not queue delivery: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *service) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	// a claimed delivery is not picked up again before the attempt at it
+	// has had time to finish.
+	leaseUntil := now.Add(2*s.client.Timeout + time.Minute)
+	due, err := s.repository.ClaimDue(ctx, now, leaseUntil, dispatchBatch)
+	if err != nil {
+		return 0, fmt.Errorf("could not claim deliveries: %v", err)
+	}
+
+	webhooks := make(map[int64]*pkg.Webhook)
+	for i := range due {
+		delivery := &due[i]
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = s.repository.FindByID(ctx, delivery.WebhookID)
+			if err != nil && err != pkg.ErrWebhookNotFound {
+				return i, fmt.Errorf("could not find webhook: %v", err)
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		if webhook == nil {
+			continue
+		}
+
+		s.attempt(ctx, *webhook, delivery)
+		if err := s.repository.UpdateDelivery(ctx, delivery); err != nil {
+			return i + 1, fmt.Errorf("could not update delivery: %v", err)
+		}
+	}
+	return len(due), nil
+}
+
+// Run dispatches due deliveries every interval until ctx is done. Failures
+// are passed to report rather than stopping the loop.
+func Run(ctx context.Context, service Service, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := service.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			report(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt sends delivery once and records the outcome on it: delivered on a
+// 2xx answer, otherwise retried later or, out of attempts, dead.
+func (s *service) attempt(ctx context.Context, webhook pkg.Webhook, delivery *pkg.WebhookDelivery) {
+	delivery.Attempts++
+	status, err := send(ctx, s.client, webhook, *delivery)
+	delivery.ResponseStatus = int64(status)
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = pkg.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = pkg.DeliveryDead
+		return
+	}
+	delivery.Status = pkg.DeliveryPending
+	delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+}
+
+// retryDelay is how long to wait after the given number of failed attempts.
+func retryDelay(attempts int64) time.Duration {
+	return FirstRetryDelay << uint(attempts-1)
+}
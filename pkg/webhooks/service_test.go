@@ -0,0 +1,327 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// receiver records the requests a webhook gets and answers them with status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) answer(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// memoryRepository keeps webhooks and deliveries in memory.
+type memoryRepository struct {
+	webhooks   map[int64]pkg.Webhook
+	deliveries map[int64]pkg.WebhookDelivery
+	lastID     int64
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{webhooks: make(map[int64]pkg.Webhook), deliveries: make(map[int64]pkg.WebhookDelivery)}
+}
+
+func (m *memoryRepository) Insert(ctx context.Context, webhook *pkg.Webhook) error {
+	m.lastID++
+	webhook.ID = m.lastID
+	m.webhooks[webhook.ID] = *webhook
+	return nil
+}
+
+func (m *memoryRepository) FindAll(ctx context.Context) ([]pkg.Webhook, error) {
+	var list []pkg.Webhook
+	for _, webhook := range m.webhooks {
+		list = append(list, webhook)
+	}
+	return list, nil
+}
+
+func (m *memoryRepository) FindByID(ctx context.Context, id int64) (*pkg.Webhook, error) {
+	webhook, ok := m.webhooks[id]
+	if !ok {
+		return nil, pkg.ErrWebhookNotFound
+	}
+	return &webhook, nil
+}
+
+func (m *memoryRepository) DeleteByID(ctx context.Context, id int64) error {
+	if _, ok := m.webhooks[id]; !ok {
+		return pkg.ErrWebhookNotFound
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+func (m *memoryRepository) InsertDelivery(ctx context.Context, delivery *pkg.WebhookDelivery) error {
+	if _, ok := m.webhooks[delivery.WebhookID]; !ok {
+		return pkg.ErrWebhookNotFound
+	}
+	m.lastID++
+	delivery.ID = m.lastID
+	delivery.Status = pkg.DeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	delivery.CreatedAt = time.Now()
+	m.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (m *memoryRepository) FindDelivery(ctx context.Context, id int64) (*pkg.WebhookDelivery, error) {
+	delivery, ok := m.deliveries[id]
+	if !ok {
+		return nil, pkg.ErrDeliveryNotFound
+	}
+	return &delivery, nil
+}
+
+func (m *memoryRepository) FindDeliveries(ctx context.Context, webhookID, limit int64) ([]pkg.WebhookDelivery, error) {
+	var list []pkg.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID {
+			list = append(list, delivery)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if int64(len(list)) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (m *memoryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int64) ([]pkg.WebhookDelivery, error) {
+	var list []pkg.WebhookDelivery
+	for id, delivery := range m.deliveries {
+		if delivery.Status != pkg.DeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.NextAttemptAt = leaseUntil
+		m.deliveries[id] = delivery
+		list = append(list, delivery)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (m *memoryRepository) UpdateDelivery(ctx context.Context, delivery *pkg.WebhookDelivery) error {
+	m.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+// due makes a pending delivery due now, as if its retry delay had passed.
+func (m *memoryRepository) due(id int64) {
+	delivery := m.deliveries[id]
+	delivery.NextAttemptAt = time.Now()
+	m.deliveries[id] = delivery
+}
+
+// setup subscribes a webhook pointing at rcv to every reservation event and
+// publishes one of them.
+func setup(t *testing.T, rcv *receiver) (Service, *memoryRepository, pkg.WebhookDelivery) {
+	t.Helper()
+	server := httptest.NewServer(rcv)
+	t.Cleanup(server.Close)
+
+	repository := newMemoryRepository()
+	service := NewService(repository, &http.Client{Timeout: 5 * time.Second})
+	ctx := context.Background()
+	webhook, err := service.Subscribe(ctx, pkg.Webhook{URL: server.URL, EventTypes: []pkg.EventType{pkg.EventReservationCreated}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	event := pkg.Event{ID: "evt-1", Type: pkg.EventReservationCreated, AggregateType: "reservation", AggregateID: 7, OccurredAt: time.Now(), Data: []byte(`{"id":7}`)}
+	if err := service.Publish(ctx, event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	deliveries, _ := repository.FindDeliveries(ctx, webhook.ID, deliveryLogSize)
+	if len(deliveries) != 1 {
+		t.Fatalf("Publish() queued %d deliveries, want 1", len(deliveries))
+	}
+	return service, repository, deliveries[0]
+}
+
+func TestDispatchSignsDeliveries(t *testing.T) {
+	rcv := &receiver{status: http.StatusNoContent}
+	service, repository, delivery := setup(t, rcv)
+
+	if n, err := service.Dispatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Dispatch() = %d, %v, want 1, nil", n, err)
+	}
+
+	requests := rcv.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	timestamp, err := strconv.ParseInt(req.header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q, want a unix time", TimestampHeader, req.header.Get(TimestampHeader))
+	}
+	if !Verify("s3cret", timestamp, req.body, req.header.Get(SignatureHeader)) {
+		t.Errorf("%s = %q does not verify", SignatureHeader, req.header.Get(SignatureHeader))
+	}
+	if Verify("other", timestamp, req.body, req.header.Get(SignatureHeader)) {
+		t.Errorf("%s verifies with the wrong secret", SignatureHeader)
+	}
+	if got := req.header.Get(EventHeader); got != string(pkg.EventReservationCreated) {
+		t.Errorf("%s = %q, want %q", EventHeader, got, pkg.EventReservationCreated)
+	}
+	if got := req.header.Get(DeliveryHeader); got != "evt-1" {
+		t.Errorf("%s = %q, want %q", DeliveryHeader, got, "evt-1")
+	}
+
+	stored, _ := repository.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != pkg.DeliveryDelivered || stored.Attempts != 1 || stored.ResponseStatus != http.StatusNoContent {
+		t.Errorf("delivery = %s after %d attempts with status %d, want delivered after 1 with 204", stored.Status, stored.Attempts, stored.ResponseStatus)
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	rcv := &receiver{status: http.StatusServiceUnavailable}
+	service, repository, delivery := setup(t, rcv)
+	ctx := context.Background()
+
+	tests := []struct {
+		attempts int64
+		delay    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 32 * time.Minute},
+	}
+	for _, tt := range tests {
+		repository.due(delivery.ID)
+		before := time.Now()
+		if _, err := service.Dispatch(ctx); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		after := time.Now()
+
+		stored, _ := repository.FindDelivery(ctx, delivery.ID)
+		if stored.Status != pkg.DeliveryPending || stored.Attempts != tt.attempts {
+			t.Fatalf("delivery = %s after %d attempts, want pending after %d", stored.Status, stored.Attempts, tt.attempts)
+		}
+		if stored.ResponseStatus != http.StatusServiceUnavailable || stored.LastError == "" {
+			t.Errorf("attempt %d recorded status %d and error %q, want 503 and an error", tt.attempts, stored.ResponseStatus, stored.LastError)
+		}
+		if stored.NextAttemptAt.Before(before.Add(tt.delay)) || stored.NextAttemptAt.After(after.Add(tt.delay)) {
+			t.Errorf("attempt %d retries in %v, want %v", tt.attempts, stored.NextAttemptAt.Sub(before), tt.delay)
+		}
+
+		// nothing is sent again before the delay is over.
+		if n, _ := service.Dispatch(ctx); n != 0 {
+			t.Errorf("Dispatch() attempted %d deliveries before the retry was due", n)
+		}
+	}
+	if got := len(rcv.received()); got != len(tests) {
+		t.Errorf("receiver got %d requests, want %d", got, len(tests))
+	}
+}
+
+func TestDispatchGivesUpAfterMaxAttempts(t *testing.T) {
+	rcv := &receiver{status: http.StatusInternalServerError}
+	service, repository, delivery := setup(t, rcv)
+	ctx := context.Background()
+
+	for i := 0; i < MaxAttempts; i++ {
+		repository.due(delivery.ID)
+		if _, err := service.Dispatch(ctx); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	}
+
+	stored, _ := repository.FindDelivery(ctx, delivery.ID)
+	if stored.Status != pkg.DeliveryDead || stored.Attempts != MaxAttempts {
+		t.Fatalf("delivery = %s after %d attempts, want dead after %d", stored.Status, stored.Attempts, MaxAttempts)
+	}
+	repository.due(delivery.ID)
+	if n, _ := service.Dispatch(ctx); n != 0 {
+		t.Errorf("Dispatch() attempted %d dead deliveries, want 0", n)
+	}
+	if got := len(rcv.received()); got != MaxAttempts {
+		t.Errorf("receiver got %d requests, want %d", got, MaxAttempts)
+	}
+}
+
+func TestReplaySendsDeadDeliveryAgain(t *testing.T) {
+	rcv := &receiver{status: http.StatusInternalServerError}
+	service, repository, delivery := setup(t, rcv)
+	ctx := context.Background()
+
+	for i := 0; i < MaxAttempts; i++ {
+		repository.due(delivery.ID)
+		service.Dispatch(ctx)
+	}
+
+	rcv.answer(http.StatusOK)
+	replay, err := service.Replay(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if replay.ID == delivery.ID || replay.Status != pkg.DeliveryPending {
+		t.Fatalf("Replay() = delivery %d, %s, want a new pending delivery", replay.ID, replay.Status)
+	}
+	if n, err := service.Dispatch(ctx); err != nil || n != 1 {
+		t.Fatalf("Dispatch() = %d, %v, want 1, nil", n, err)
+	}
+
+	requests := rcv.received()
+	first, last := requests[0], requests[len(requests)-1]
+	if string(last.body) != string(first.body) {
+		t.Errorf("replay sent %s, want the original payload %s", last.body, first.body)
+	}
+	if last.header.Get(DeliveryHeader) != first.header.Get(DeliveryHeader) {
+		t.Errorf("replay %s = %q, want the original %q", DeliveryHeader, last.header.Get(DeliveryHeader), first.header.Get(DeliveryHeader))
+	}
+
+	original, _ := repository.FindDelivery(ctx, delivery.ID)
+	if original.Status != pkg.DeliveryDead {
+		t.Errorf("original delivery = %s, want it left dead", original.Status)
+	}
+	replayed, _ := repository.FindDelivery(ctx, replay.ID)
+	if replayed.Status != pkg.DeliveryDelivered {
+		t.Errorf("replayed delivery = %s, want delivered", replayed.Status)
+	}
+	log, _ := service.Deliveries(ctx, delivery.WebhookID)
+	if len(log) != 2 || log[0].ID != replay.ID {
+		t.Errorf("Deliveries() = %d deliveries, want the replay first of 2", len(log))
+	}
+}
@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+// Every delivery is a POST of the JSON payload with these headers. The
+// signature is "sha256=" and the hex HMAC-SHA256, keyed with the webhook's
+// secret, of the timestamp header, a dot and the body, so that receivers
+// can reject replays of old requests. The delivery header holds the event
+// ID, which retries and replays of an event share, for spotting duplicates.
+const (
+	SignatureHeader = "X-Messapp-Signature"
+	TimestampHeader = "X-Messapp-Timestamp"
+	EventHeader     = "X-Messapp-Event"
+	DeliveryHeader  = "X-Messapp-Delivery"
+)
+
+// Sign returns the value of the signature header for a body sent at
+// timestamp, a unix time in seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether signature is what Sign returns for the same input.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// send posts delivery to webhook and returns the status it answered with, if
+// it answered at all. Anything but a 2xx is an error.
+func send(ctx context.Context, client *http.Client, webhook pkg.Webhook, delivery pkg.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "messapp-webhooks")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.EventID)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// a bit of the body helps whoever looks into a failed delivery.
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
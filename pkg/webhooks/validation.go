@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/markhaur/messapp-backend/pkg"
+)
+
+func ValidationMiddleware() Middleware {
+	return func(s Service) Service { return &validationMiddleware{s} }
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+func (s *validationMiddleware) Subscribe(ctx context.Context, webhook pkg.Webhook) (*pkg.Webhook, error) {
+	var verr pkg.ValidationError
+
+	if webhook.URL == "" {
+		verr.Add("url", "required", "url is required")
+	} else if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "invalid", "url must be an absolute http or https URL")
+	}
+
+	if len(webhook.EventTypes) == 0 {
+		verr.Add("event_types", "required", "at least one event type is required")
+	}
+	seen := make(map[pkg.EventType]bool)
+	for _, eventType := range webhook.EventTypes {
+		known := false
+		for _, t := range pkg.EventTypes {
+			known = known || eventType == t
+		}
+		switch {
+		case !known:
+			verr.Add("event_types", "unknown", fmt.Sprintf("event type %q is not one of %v", eventType, pkg.EventTypes))
+		case seen[eventType]:
+			verr.Add("event_types", "duplicate", fmt.Sprintf("event type %q is listed twice", eventType))
+		}
+		seen[eventType] = true
+	}
+
+	if len(webhook.Secret) > 128 {
+		verr.Add("secret", "too_long", "secret must be at most 128 characters")
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.Service.Subscribe(ctx, webhook)
+}